@@ -8,81 +8,68 @@ import (
 )
 
 func AlphaBeta(game *chess.Game, depth, alpha, beta int, maximizingPlayer bool) (int, error) {
+	// moves are made and taken back in place on a copy of the game's position
+	return alphaBeta(game.Position().Clone(), depth, alpha, beta, maximizingPlayer), nil
+}
+
+func alphaBeta(pos *chess.Position, depth, alpha, beta int, maximizingPlayer bool) int {
 	// check if we've reached the maximum depth or if the game is over
-	if game.Method() == chess.Checkmate {
-		return math.MaxInt32, nil
+	if pos.Status() == chess.Checkmate {
+		return math.MaxInt32
 	}
 	if depth == 0 {
-		return game.Evaluate(), nil
+		return pos.Evaluate()
 	}
 
 	// generate all possible moves
-	moves := game.ValidMoves()
+	moves := pos.ValidMoves()
 	sort.Sort(chess.MoveSlice(moves))
 
 	// if the maximizing player is playing
 	if maximizingPlayer {
 		bestScore := math.MinInt32
 		for _, move := range moves {
-			// apply the move to the game state
-			err := game.Move(move)
-			if err != nil {
-				return 0, err
-			}
+			// apply the move to the position
+			u := pos.MakeMove(move)
 
 			// calculate the score of this move using alpha-beta pruning
-			score, err := AlphaBeta(game, depth-1, alpha, beta, false)
-			if err != nil {
-				return 0, err
-			}
+			score := alphaBeta(pos, depth-1, alpha, beta, false)
 
 			// update the best score and alpha value
 			bestScore = max(bestScore, score)
 			alpha = max(alpha, bestScore)
 
 			// undo the move
-			err = game.UndoMove()
-			if err != nil {
-				return 0, err
-			}
+			pos.UnmakeMove(move, u)
 
 			// check if we can prune the remaining moves
 			if beta <= alpha {
 				break
 			}
 		}
-		return bestScore, nil
+		return bestScore
 	} else { // if the minimizing player is playing
 		bestScore := math.MaxInt32
 		for _, move := range moves {
-			// apply the move to the game state
-			err := game.Move(move)
-			if err != nil {
-				return 0, err
-			}
+			// apply the move to the position
+			u := pos.MakeMove(move)
 
 			// calculate the score of this move using alpha-beta pruning
-			score, err := AlphaBeta(game, depth-1, alpha, beta, true)
-			if err != nil {
-				return 0, err
-			}
+			score := alphaBeta(pos, depth-1, alpha, beta, true)
 
 			// update the best score and beta value
 			bestScore = min(bestScore, score)
 			beta = min(beta, bestScore)
 
 			// undo the move
-			err = game.UndoMove()
-			if err != nil {
-				return 0, err
-			}
+			pos.UnmakeMove(move, u)
 
 			// check if we can prune the remaining moves
 			if beta <= alpha {
 				break
 			}
 		}
-		return bestScore, nil
+		return bestScore
 	}
 }
 
@@ -103,42 +90,40 @@ func min(a, b int) int {
 }
 
 func Minimax(g *chess.Game, depth int, alpha int, beta int) (int, *chess.Move, error) {
+	// moves are made and taken back in place on a copy of the game's position
+	score, move := minimax(g.Position().Clone(), depth, alpha, beta)
+	return score, move, nil
+}
+
+func minimax(pos *chess.Position, depth int, alpha int, beta int) (int, *chess.Move) {
+	status := pos.Status()
 	if depth == 0 {
-		if g.Method() == chess.Checkmate {
-			return math.MaxInt32, &chess.Move{}, nil
+		if status == chess.Checkmate {
+			return math.MaxInt32, &chess.Move{}
 		}
-		return g.Evaluate(), &chess.Move{}, nil
-	} else if g.Method() == chess.Checkmate {
-		return math.MaxInt32, &chess.Move{}, nil
-	} else if g.Method() == chess.Stalemate {
-		return 0, &chess.Move{}, nil
+		return pos.Evaluate(), &chess.Move{}
+	} else if status == chess.Checkmate {
+		return math.MaxInt32, &chess.Move{}
+	} else if status == chess.Stalemate {
+		return 0, &chess.Move{}
 	}
 	bestScore := -99999
 	var bestMove *chess.Move
 
-	validMoves := g.ValidMoves()
+	validMoves := pos.ValidMoves()
 	sort.Sort(chess.MoveSlice(validMoves))
 	for _, move := range validMoves {
-		err := g.Move(move)
-		if err != nil {
-			return 0, nil, err
-		}
-		score, _, err := Minimax(g, depth-1, alpha, beta)
-		if err != nil {
-			return 0, nil, err
-		}
-		err = g.UndoMove()
-		if err != nil {
-			return 0, nil, err
-		}
+		u := pos.MakeMove(move)
+		score, _ := minimax(pos, depth-1, alpha, beta)
+		pos.UnmakeMove(move, u)
 
-		if g.Position().Turn() == chess.White && score > bestScore {
+		if pos.Turn() == chess.White && score > bestScore {
 			bestScore = score
 			bestMove = move
 			if score > alpha {
 				alpha = score
 			}
-		} else if g.Position().Turn() == chess.Black && score < bestScore {
+		} else if pos.Turn() == chess.Black && score < bestScore {
 			bestScore = score
 			bestMove = move
 			if score < beta {
@@ -151,5 +136,5 @@ func Minimax(g *chess.Game, depth int, alpha int, beta int) (int, *chess.Move, e
 		}
 	}
 
-	return bestScore, bestMove, nil
+	return bestScore, bestMove
 }
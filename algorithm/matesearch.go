@@ -176,23 +176,19 @@ func (a MateMoveSlice) Less(i, j int) bool {
 }
 
 func createSearchableMoves(game *chess.Game) (MateMoveSlice, error) {
-	nextMoves := game.ValidMoves()
+	// moves are made and taken back in place on a copy of the game's position
+	pos := game.Position().Clone()
+	nextMoves := pos.ValidMoves()
 	result := make([]*MateMove, len(nextMoves))
 
 	for index, move := range nextMoves {
-		err := game.Move(move)
-		if err != nil {
-			return nil, err
-		}
-		mobility := len(game.ValidMoves())
+		u := pos.MakeMove(move)
+		mobility := len(pos.ValidMoves())
 		result[index] = &MateMove{
 			Mobility: mobility,
 			Move:     *move,
 		}
-		err = game.UndoMove()
-		if err != nil {
-			return nil, err
-		}
+		pos.UnmakeMove(move, u)
 	}
 	sort.Sort(MateMoveSlice(result))
 	return result, nil
@@ -0,0 +1,60 @@
+package chess
+
+// AntichessVariant is Antichess, also known as losing chess: captures
+// are compulsory, kings are ordinary pieces that can be captured and
+// promoted to and there is no check or castling.  A player wins by
+// losing all their pieces or by having no moves.
+type AntichessVariant struct{}
+
+// String implements the Variant interface.
+func (AntichessVariant) String() string {
+	return "Antichess"
+}
+
+// StartingPosition implements the Variant interface.  The starting
+// position has no castle rights.
+func (AntichessVariant) StartingPosition() *Position {
+	pos := StartingPosition()
+	pos.castleRights = "-"
+	pos.setVariant(AntichessVariant{})
+	return pos
+}
+
+// DecodeFEN implements the Variant interface.
+func (AntichessVariant) DecodeFEN(fen string) (*Position, error) {
+	pos, err := decodeFEN(fen)
+	if err != nil {
+		return nil, err
+	}
+	pos.setVariant(AntichessVariant{})
+	return pos, nil
+}
+
+// EncodeFEN implements the Variant interface.
+func (AntichessVariant) EncodeFEN(pos *Position) string {
+	return pos.fen()
+}
+
+// ValidMoves implements the Variant interface.
+func (AntichessVariant) ValidMoves(pos *Position) []*Move {
+	return engine{}.CalcMoves(pos, false)
+}
+
+// Status implements the Variant interface.  AllPiecesCaptured is
+// returned once the side to move has no pieces left.
+func (AntichessVariant) Status(pos *Position) Method {
+	return engine{}.Status(pos)
+}
+
+// Outcome implements the Variant interface.  The side to move wins
+// if it has no pieces or is stalemated.
+func (AntichessVariant) Outcome(pos *Position, method Method) Outcome {
+	switch method {
+	case Stalemate, AllPiecesCaptured:
+		if pos.Turn() == White {
+			return WhiteWon
+		}
+		return BlackWon
+	}
+	return StandardVariant{}.Outcome(pos, method)
+}
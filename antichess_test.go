@@ -0,0 +1,74 @@
+package chess
+
+import "testing"
+
+func TestAntichess(t *testing.T) {
+	pgn := `[Variant "Antichess"]
+
+1. e3 b5 2. Bxb5 Bb7 3. Bxd7 Kxd7 *`
+	g := NewGame()
+	if err := g.UnmarshalText([]byte(pgn)); err != nil {
+		t.Fatal(err)
+	}
+	expected := "rn1q1bnr/pbpkpppp/8/8/8/4P3/PPPP1PPP/RNBQK1NR w - - 0 4"
+	if g.Position().String() != expected {
+		t.Fatalf("expected %s but got %s", expected, g.Position().String())
+	}
+	g2 := NewGame()
+	if err := g2.UnmarshalText([]byte(g.String())); err != nil {
+		t.Fatal(err)
+	}
+	if g2.Position().String() != expected || g2.Variant().String() != "Antichess" {
+		t.Fatalf("expected %s but got %s", expected, g2.Position().String())
+	}
+}
+
+func TestAntichessForcedCaptures(t *testing.T) {
+	pos := unsafeVariantFEN(AntichessVariant{}, "rnbqkbnr/p1pppppp/8/1p6/8/4P3/PPPP1PPP/RNBQKBNR w - - 0 2")
+	moves := pos.ValidMoves()
+	if len(moves) != 1 || moves[0].String() != "f1b5" {
+		t.Fatalf("expected only Bxb5 but got %v", moves)
+	}
+	// kings can walk into attacks and capture like any other piece
+	pos = unsafeVariantFEN(AntichessVariant{}, "8/8/8/8/8/8/4r3/4K3 w - - 0 1")
+	moves = pos.ValidMoves()
+	if len(moves) != 1 || moves[0].String() != "e1e2" || pos.inCheck {
+		t.Fatalf("expected only Kxe2 but got %v", moves)
+	}
+}
+
+func TestAntichessOutcome(t *testing.T) {
+	fen, err := FEN("8/8/8/8/8/8/p7/1R6 b - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGame(WithVariant(AntichessVariant{}), fen)
+	if len(g.ValidMoves()) != 5 {
+		t.Fatalf("expected five promotions but got %v", g.ValidMoves())
+	}
+	if err := g.MoveStr("axb1=K"); err != nil {
+		t.Fatal(err)
+	}
+	if g.Outcome() != WhiteWon || g.Method() != AllPiecesCaptured {
+		t.Fatalf("expected white to win by losing all pieces but got %s by %s", g.Outcome(), g.Method())
+	}
+	fen, err = FEN("8/8/8/8/8/p7/P7/8 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g = NewGame(WithVariant(AntichessVariant{}), fen)
+	if g.Outcome() != WhiteWon || g.Method() != Stalemate {
+		t.Fatalf("expected white to win by stalemate but got %s by %s", g.Outcome(), g.Method())
+	}
+}
+
+func TestAntichessPerft(t *testing.T) {
+	fen := AntichessVariant{}.EncodeFEN(AntichessVariant{}.StartingPosition())
+	res, err := PerftDivide(fen, 3, PerftVariant(AntichessVariant{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total.Nodes != 8067 {
+		t.Fatalf("expected 8067 nodes but got %d", res.Total.Nodes)
+	}
+}
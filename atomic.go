@@ -0,0 +1,183 @@
+package chess
+
+// AtomicVariant is Atomic chess: a capture explodes the captured
+// piece, the capturing piece and every piece but pawns next to the
+// capture square.  Kings can't capture and a game is won by
+// exploding the enemy king, which takes priority over keeping one's
+// own king out of check.  Kings standing next to each other can't
+// give check as capturing one would explode both.
+type AtomicVariant struct{}
+
+// String implements the Variant interface.
+func (AtomicVariant) String() string {
+	return "Atomic"
+}
+
+// StartingPosition implements the Variant interface.
+func (AtomicVariant) StartingPosition() *Position {
+	pos := StartingPosition()
+	pos.setVariant(AtomicVariant{})
+	return pos
+}
+
+// DecodeFEN implements the Variant interface.
+func (AtomicVariant) DecodeFEN(fen string) (*Position, error) {
+	pos, err := decodeFEN(fen)
+	if err != nil {
+		return nil, err
+	}
+	pos.setVariant(AtomicVariant{})
+	return pos, nil
+}
+
+// EncodeFEN implements the Variant interface.
+func (AtomicVariant) EncodeFEN(pos *Position) string {
+	return pos.fen()
+}
+
+// ValidMoves implements the Variant interface.
+func (AtomicVariant) ValidMoves(pos *Position) []*Move {
+	return engine{}.CalcMoves(pos, false)
+}
+
+// Status implements the Variant interface.  Explosion is returned
+// once a king has been exploded.
+func (AtomicVariant) Status(pos *Position) Method {
+	return engine{}.Status(pos)
+}
+
+// Outcome implements the Variant interface.
+func (AtomicVariant) Outcome(pos *Position, method Method) Outcome {
+	return variantOutcome(pos, method)
+}
+
+// explode removes the pieces exploded by the capture m from the
+// position, records them in exploded and returns the change of the
+// Zobrist key.  Exploded rooks and kings lose their castle rights.
+func (pos *Position) explode(m *Move, exploded *[9]Piece) uint64 {
+	pos.board.explode(m.s2, exploded)
+	h := zobristPieces[exploded[0]][m.s2]
+	i := 1
+	for bb := bbKingMoves[m.s2]; bb != 0; bb &= bb - 1 {
+		if exploded[i] != NoPiece {
+			h ^= zobristPieces[exploded[i]][bb.lastSquare()]
+		}
+		i++
+	}
+	if pos.castleRights == "-" {
+		return h
+	}
+	rights := pos.castleRights.bits()
+	for i, sq := range pos.castleRooks {
+		if pos.board.bbForPiece(NewPiece(Rook, Color(i/2+1)))&bbForSquare(sq) == 0 {
+			rights &^= 1 << i
+		}
+	}
+	if pos.board.bbWhiteKing == 0 {
+		rights &^= bitsCastleWhiteKing | bitsCastleWhiteQueen
+	}
+	if pos.board.bbBlackKing == 0 {
+		rights &^= bitsCastleBlackKing | bitsCastleBlackQueen
+	}
+	pos.castleRights = castleRightsStrs[rights]
+	return h
+}
+
+// explode removes the piece on sq, which just captured, and the
+// pieces other than pawns around it.  The removed pieces are recorded
+// in exploded, the capturing piece first followed by the surrounding
+// squares in bitboard order.
+func (b *Board) explode(sq Square, exploded *[9]Piece) {
+	exploded[0] = b.Piece(sq)
+	b.setBBForPiece(exploded[0], b.bbForPiece(exploded[0]) & ^bbForSquare(sq))
+	i := 1
+	for bb := bbKingMoves[sq]; bb != 0; bb &= bb - 1 {
+		s := bb.lastSquare()
+		p := b.Piece(s)
+		exploded[i] = NoPiece
+		if p != NoPiece && p.Type() != Pawn {
+			exploded[i] = p
+			b.setBBForPiece(p, b.bbForPiece(p) & ^bbForSquare(s))
+		}
+		i++
+	}
+	b.calcConvienceBBs(nil)
+}
+
+// unexplode puts back the pieces removed by explode.
+func (b *Board) unexplode(sq Square, exploded *[9]Piece) {
+	b.setBBForPiece(exploded[0], b.bbForPiece(exploded[0])|bbForSquare(sq))
+	i := 1
+	for bb := bbKingMoves[sq]; bb != 0; bb &= bb - 1 {
+		if p := exploded[i]; p != NoPiece {
+			b.setBBForPiece(p, b.bbForPiece(p)|bbForSquare(bb.lastSquare()))
+		}
+		i++
+	}
+	b.calcConvienceBBs(nil)
+}
+
+// atomicKingAttacked returns true if the king of the given color is
+// in check by the Atomic rules.
+func (b *Board) atomicKingAttacked(c Color) bool {
+	kingSq, enemySq := b.whiteKingSq, b.blackKingSq
+	if c == Black {
+		kingSq, enemySq = enemySq, kingSq
+	}
+	if kingSq == NoSquare {
+		return false
+	}
+	if enemySq != NoSquare && bbKingMoves[kingSq]&bbForSquare(enemySq) != 0 {
+		return false
+	}
+	return b.attackersTo(kingSq, c.Other(), ^b.emptySqs) != 0
+}
+
+// atomicTags plays the move on the board to tag it with Check or, if
+// it is illegal, inCheck.
+func (g *moveGen) atomicTags(m *Move) {
+	b := g.b
+	captured := NoPiece
+	var exploded [9]Piece
+	var kingTo, rookFrom, rookTo Square
+	castle := m.HasTag(KingSideCastle) || m.HasTag(QueenSideCastle)
+	if castle {
+		kingTo, rookFrom, rookTo = g.pos.castleSquares(m, g.us)
+		b.castle(g.us, m.s1, kingTo, rookFrom, rookTo)
+	} else {
+		captured = b.update(m)
+		if captured != NoPiece {
+			b.explode(m.s2, &exploded)
+		}
+	}
+	ourKing := b.bbForPiece(NewPiece(King, g.us))
+	theirKing := b.bbForPiece(NewPiece(King, g.them))
+	switch {
+	case ourKing == 0 && g.kingSq != NoSquare:
+		m.addTag(inCheck)
+	case theirKing == 0 && g.enemyKingSq != NoSquare:
+		// exploding the enemy king wins at once
+	case b.atomicKingAttacked(g.us):
+		m.addTag(inCheck)
+	case b.atomicKingAttacked(g.them):
+		m.addTag(Check)
+	}
+	if castle {
+		b.castle(g.us, kingTo, m.s1, rookTo, rookFrom)
+		return
+	}
+	if captured != NoPiece {
+		b.unexplode(m.s2, &exploded)
+	}
+	b.undo(m, captured)
+}
+
+// atomicHasSufficientMaterial returns false if neither side can
+// explode the other's king: only the kings and at most a single
+// bishop or knight are left.
+func (b *Board) atomicHasSufficientMaterial() bool {
+	kings := b.bbWhiteKing | b.bbBlackKing
+	minors := b.bbWhiteBishop | b.bbWhiteKnight | b.bbBlackBishop | b.bbBlackKnight
+	others := ^b.emptySqs &^ kings
+	return others&^minors != 0 || others.count() > 1
+}
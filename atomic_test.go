@@ -0,0 +1,102 @@
+package chess
+
+import "testing"
+
+func unsafeVariantFEN(v Variant, s string) *Position {
+	pos, err := v.DecodeFEN(s)
+	if err != nil {
+		panic(err)
+	}
+	pos.setVariant(v)
+	pos.inCheck = isInCheck(pos)
+	return pos
+}
+
+func TestAtomicExplosion(t *testing.T) {
+	fen, err := FEN("rnbqkbnr/pppppppp/8/7Q/8/8/PPPPPPPP/RNB1KBNR w KQkq - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGame(WithVariant(AtomicVariant{}), fen)
+	if err := g.MoveStr("Qxf7"); err != nil {
+		t.Fatal(err)
+	}
+	expected := "rnbq3r/ppppp1pp/8/8/8/8/PPPPPPPP/RNB1KBNR b KQ - 0 1"
+	if g.Position().String() != expected {
+		t.Fatalf("expected %s but got %s", expected, g.Position().String())
+	}
+	if g.Outcome() != WhiteWon || g.Method() != Explosion {
+		t.Fatalf("expected white to win by explosion but got %s by %s", g.Outcome(), g.Method())
+	}
+	if len(g.ValidMoves()) != 0 {
+		t.Fatal("expected no moves after the king exploded")
+	}
+}
+
+func TestAtomicLegality(t *testing.T) {
+	tests := []struct {
+		fen     string
+		illegal string
+		inCheck bool
+	}{
+		// kings can't capture
+		{"4k3/8/8/8/8/8/4p3/4K3 w - - 0 1", "e1e2", false},
+		// captures next to the own king explode it
+		{"4k3/8/8/8/8/8/3n4/4K2Q w - - 0 1", "h1d1", false},
+		// kings next to each other can't be checked
+		{"8/8/8/8/8/8/3k4/r3K3 w - - 0 1", "", false},
+		{"8/8/8/8/8/3k4/8/r3K3 w - - 0 1", "", true},
+	}
+	for _, test := range tests {
+		pos := unsafeVariantFEN(AtomicVariant{}, test.fen)
+		if pos.inCheck != test.inCheck {
+			t.Fatalf("expected %s in check to be %t", test.fen, test.inCheck)
+		}
+		for _, m := range pos.ValidMoves() {
+			if m.String() == test.illegal {
+				t.Fatalf("expected %s to be illegal in %s", test.illegal, test.fen)
+			}
+		}
+	}
+	// exploding the enemy king is legal even if it leaves the own king in check
+	pos := unsafeVariantFEN(AtomicVariant{}, "3qk3/8/8/8/8/8/8/3QK2r w - - 0 1")
+	if _, err := (AlgebraicNotation{}).Decode(pos, "Qxd8"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAtomicPerft(t *testing.T) {
+	tests := []struct {
+		fen   string
+		depth int
+		nodes int
+	}{
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", 4, 197326},
+		{"rn1qkb1r/p5pp/2p5/3p4/N3P3/5P2/PPP4P/R1BQK3 w Qkq - 0 1", 3, 23353},
+		{"8/8/8/8/8/8/2k5/rR4KR w HB - 0 1", 3, 4364},
+	}
+	for _, test := range tests {
+		res, err := PerftDivide(test.fen, test.depth, PerftVariant(AtomicVariant{}), PerftWorkers(4))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Total.Nodes != test.nodes {
+			t.Fatalf("perft %s depth %d expected %d but got %d", test.fen, test.depth, test.nodes, res.Total.Nodes)
+		}
+	}
+}
+
+func TestAtomicMakeUnmake(t *testing.T) {
+	pos := unsafeVariantFEN(AtomicVariant{}, "rn1qkb1r/p5pp/2p5/3p4/N3P3/5P2/PPP4P/R1BQK3 w Qkq - 0 1")
+	for _, m := range pos.ValidMoves() {
+		before := pos.String()
+		u := pos.MakeMove(m)
+		if pos.hash != pos.zobrist() {
+			t.Fatalf("after move %s to %s expected key %x but got %x", m, pos, pos.zobrist(), pos.hash)
+		}
+		pos.UnmakeMove(m, u)
+		if pos.String() != before {
+			t.Fatalf("taking back %s expected %s but got %s", m, before, pos)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package chess
+
+// A Pin is a piece that can't leave the line between its king and
+// the enemy bishop, rook or queen aiming at the king through it.
+type Pin struct {
+	// Square is the square of the pinned piece.
+	Square Square
+	// Pinner is the square of the pinning piece.
+	Pinner Square
+	// Ray are the squares from the king, exclusive, to the pinner,
+	// inclusive.  The pinned piece may only move along them.
+	Ray []Square
+}
+
+// seeValues are the piece values in centipawns used by SEE.
+var seeValues = [7]int{
+	NoPieceType: 0,
+	King:        20000,
+	Queen:       900,
+	Rook:        500,
+	Bishop:      300,
+	Knight:      300,
+	Pawn:        100,
+}
+
+// PawnAttacks returns the squares a pawn of the given color attacks
+// from sq.
+func PawnAttacks(c Color, sq Square) Bitboard {
+	if (c != White && c != Black) || sq.Bitboard() == 0 {
+		return 0
+	}
+	return bbPawnAttacks[c][sq]
+}
+
+// KnightAttacks returns the squares a knight attacks from sq.
+func KnightAttacks(sq Square) Bitboard {
+	if sq.Bitboard() == 0 {
+		return 0
+	}
+	return bbKnightMoves[sq]
+}
+
+// KingAttacks returns the squares a king attacks from sq.
+func KingAttacks(sq Square) Bitboard {
+	if sq.Bitboard() == 0 {
+		return 0
+	}
+	return bbKingMoves[sq]
+}
+
+// BishopAttacks returns the squares a bishop attacks from sq given
+// the occupied squares.  Occupied squares stop the bishop and are
+// included.
+func BishopAttacks(sq Square, occupied Bitboard) Bitboard {
+	if sq.Bitboard() == 0 {
+		return 0
+	}
+	return bishopAttacks(occupied, sq)
+}
+
+// RookAttacks returns the squares a rook attacks from sq given the
+// occupied squares.  Occupied squares stop the rook and are included.
+func RookAttacks(sq Square, occupied Bitboard) Bitboard {
+	if sq.Bitboard() == 0 {
+		return 0
+	}
+	return rookAttacks(occupied, sq)
+}
+
+// QueenAttacks returns the squares a queen attacks from sq given the
+// occupied squares.  Occupied squares stop the queen and are included.
+func QueenAttacks(sq Square, occupied Bitboard) Bitboard {
+	return BishopAttacks(sq, occupied) | RookAttacks(sq, occupied)
+}
+
+// Attackers returns the squares of the pieces of the given color
+// attacking the square in ascending order.
+func (pos *Position) Attackers(sq Square, c Color) []Square {
+	b := pos.board
+	return b.attackersTo(sq, c, ^b.emptySqs).Squares()
+}
+
+// AttackedSquares returns the squares attacked by the pieces of the
+// given color in ascending order.  Squares holding pieces of the same
+// color count as attacked as they are defended.
+func (pos *Position) AttackedSquares(c Color) []Square {
+	b := pos.board
+	return b.attackedSquares(c, ^b.emptySqs).Squares()
+}
+
+// Checkers returns the squares of the pieces giving check to the side
+// to move in ascending order.
+func (pos *Position) Checkers() []Square {
+	if !isInCheck(pos) {
+		return nil
+	}
+	b := pos.board
+	kingSq := b.whiteKingSq
+	if pos.turn == Black {
+		kingSq = b.blackKingSq
+	}
+	return b.attackersTo(kingSq, pos.turn.Other(), ^b.emptySqs).Squares()
+}
+
+// Pinned returns the pieces of the given color pinned to their king
+// ordered by square.  Positions without a king of the color, and
+// Antichess positions whose kings are ordinary pieces, have no pins.
+func (pos *Position) Pinned(c Color) []Pin {
+	b := pos.board
+	kingSq := b.whiteKingSq
+	if c == Black {
+		kingSq = b.blackKingSq
+	}
+	if kingSq == NoSquare || pos.antichess {
+		return nil
+	}
+	occupied := ^b.emptySqs
+	pinned := b.blockers(kingSq, c.Other(), occupied) & b.Occupied(c)
+	queens := b.bbForPiece(NewPiece(Queen, c.Other()))
+	snipers := (rookAttacks(0, kingSq) & (b.bbForPiece(NewPiece(Rook, c.Other())) | queens)) |
+		(bishopAttacks(0, kingSq) & (b.bbForPiece(NewPiece(Bishop, c.Other())) | queens))
+	var pins []Pin
+	for _, sq := range pinned.Squares() {
+		for _, pinner := range (snipers & bbLine[kingSq][sq]).Squares() {
+			between := bbBetween[kingSq][pinner]
+			if between&occupied != bbForSquare(sq) {
+				continue
+			}
+			ray := between | bbForSquare(pinner)
+			pins = append(pins, Pin{Square: sq, Pinner: pinner, Ray: ray.Squares()})
+		}
+	}
+	return pins
+}
+
+// SEE returns the static exchange evaluation of the move in
+// centipawns: the material won, or lost if negative, once both sides
+// have captured on the destination square for as long as it pays.
+// Pins are ignored.  Castles and quiet moves to safe squares are
+// worth 0.
+func (pos *Position) SEE(m *Move) int {
+	if m.HasTag(KingSideCastle) || m.HasTag(QueenSideCastle) {
+		return 0
+	}
+	b := pos.board
+	occupied := ^b.emptySqs | bbForSquare(m.s2)
+	var gain [32]int
+	attacker := m.drop
+	if m.drop == NoPieceType {
+		attacker = b.Piece(m.s1).Type()
+		occupied &^= bbForSquare(m.s1)
+	}
+	if m.HasTag(EnPassant) {
+		gain[0] = seeValues[Pawn]
+		occupied &^= bbForSquare(enPassantCaptureSquare(m.s2, pos.turn))
+	} else {
+		gain[0] = seeValues[b.Piece(m.s2).Type()]
+	}
+	if m.promo != NoPieceType {
+		gain[0] += seeValues[m.promo] - seeValues[Pawn]
+		attacker = m.promo
+	}
+	side := pos.turn.Other()
+	d := 0
+	for d+1 < len(gain) {
+		attackers := b.attackersTo(m.s2, side, occupied) & occupied
+		if attackers == 0 {
+			break
+		}
+		sq, pt := b.leastValuable(attackers, side)
+		if rest := occupied &^ bbForSquare(sq); pt == King && b.attackersTo(m.s2, side.Other(), rest)&rest != 0 {
+			// the king can't capture into a defended square
+			break
+		}
+		d++
+		gain[d] = seeValues[attacker] - gain[d-1]
+		attacker = pt
+		occupied &^= bbForSquare(sq)
+		side = side.Other()
+	}
+	for ; d > 0; d-- {
+		if -gain[d] < gain[d-1] {
+			gain[d-1] = -gain[d]
+		}
+	}
+	return gain[0]
+}
+
+// leastValuable returns the square and type of the least valuable of
+// the attackers of the given color.
+func (b *Board) leastValuable(attackers Bitboard, c Color) (Square, PieceType) {
+	for _, pt := range []PieceType{Pawn, Knight, Bishop, Rook, Queen, King} {
+		if bb := attackers & b.bbForPiece(NewPiece(pt, c)); bb != 0 {
+			return bb.lastSquare(), pt
+		}
+	}
+	return NoSquare, NoPieceType
+}
@@ -0,0 +1,71 @@
+package chess
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAttackers(t *testing.T) {
+	pos := StartingPosition()
+	if sqs := pos.Attackers(F3, White); !reflect.DeepEqual(sqs, []Square{G1, E2, G2}) {
+		t.Fatalf("expected the attackers of f3 to be g1, e2 and g2 but got %v", sqs)
+	}
+	if sqs := pos.Attackers(E4, White); len(sqs) != 0 {
+		t.Fatalf("expected no attackers of e4 but got %v", sqs)
+	}
+	if sqs := pos.AttackedSquares(White); len(sqs) != 22 {
+		t.Fatalf("expected 22 attacked squares but got %v", sqs)
+	}
+}
+
+func TestCheckers(t *testing.T) {
+	pos := unsafeFEN("4k3/8/8/8/7b/8/8/r3K3 w - - 0 1")
+	if sqs := pos.Checkers(); !reflect.DeepEqual(sqs, []Square{A1, H4}) {
+		t.Fatalf("expected a1 and h4 to give check but got %v", sqs)
+	}
+	if sqs := StartingPosition().Checkers(); len(sqs) != 0 {
+		t.Fatalf("expected no checkers but got %v", sqs)
+	}
+}
+
+func TestPinned(t *testing.T) {
+	pos := unsafeFEN("4k3/4r3/8/8/7b/8/4NB2/4K3 w - - 0 1")
+	expected := []Pin{
+		{Square: E2, Pinner: E7, Ray: []Square{E2, E3, E4, E5, E6, E7}},
+		{Square: F2, Pinner: H4, Ray: []Square{F2, G3, H4}},
+	}
+	if pins := pos.Pinned(White); !reflect.DeepEqual(pins, expected) {
+		t.Fatalf("expected %v but got %v", expected, pins)
+	}
+	if pins := pos.Pinned(Black); len(pins) != 0 {
+		t.Fatalf("expected no black pins but got %v", pins)
+	}
+}
+
+func TestSEE(t *testing.T) {
+	tests := []struct {
+		fen      string
+		move     string
+		expected int
+	}{
+		{"4k3/8/8/4p3/8/8/8/4RK2 w - - 0 1", "Rxe5", 100},
+		{"4k3/8/3p4/4p3/8/8/8/4RK2 w - - 0 1", "Rxe5", -400},
+		{"4k3/8/3p4/4p3/8/8/4R3/4RK2 w - - 0 1", "Rxe5", -300},
+		{"4k3/8/3p4/4p3/8/5N2/8/4RK2 w - - 0 1", "Nxe5", -100},
+		{"4k3/8/8/3q4/8/8/8/3RK3 w - - 0 1", "Rxd5", 900},
+		{"4k3/8/8/3q4/8/8/8/4K2R w - - 0 1", "Rh5", -500},
+		{"4k3/8/8/3q4/8/8/8/4K2R w - - 0 1", "Rh3", 0},
+		{"1r2k3/P7/8/8/8/8/8/4K3 w - - 0 1", "axb8=Q", 1300},
+		{"4k3/8/8/3p4/4K3/8/8/8 w - - 0 1", "Kxd5", 100},
+	}
+	for _, test := range tests {
+		pos := unsafeFEN(test.fen)
+		m, err := AlgebraicNotation{}.Decode(pos, test.move)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if see := pos.SEE(m); see != test.expected {
+			t.Fatalf("expected %s in %s to be worth %d but got %d", test.move, test.fen, test.expected, see)
+		}
+	}
+}
@@ -5,11 +5,99 @@ import (
 	"strings"
 )
 
-// bitboard is a board representation encoded in an unsigned 64-bit integer.  The
+// Bitboard is a set of squares encoded in an unsigned 64-bit integer.  The
 // 64 board positions begin with A1 as the most significant bit and H8 as the least.
-type bitboard uint64
+type Bitboard uint64
 
-func newBitboard(m map[Square]bool) (bitboard, error) {
+// NewBitboard returns the bitboard of the given squares.
+func NewBitboard(sqs ...Square) Bitboard {
+	var bb Bitboard
+	for _, sq := range sqs {
+		bb |= bbForSquare(sq)
+	}
+	return bb
+}
+
+// A Direction is one of the eight directions a square's neighbours
+// lie in, North being towards the eighth rank.
+type Direction int8
+
+const (
+	// North is towards the eighth rank.
+	North Direction = iota
+	// NorthEast is towards the eighth rank and the h file.
+	NorthEast
+	// East is towards the h file.
+	East
+	// SouthEast is towards the first rank and the h file.
+	SouthEast
+	// South is towards the first rank.
+	South
+	// SouthWest is towards the first rank and the a file.
+	SouthWest
+	// West is towards the a file.
+	West
+	// NorthWest is towards the eighth rank and the a file.
+	NorthWest
+)
+
+// Count returns the number of squares in the bitboard.
+func (b Bitboard) Count() int {
+	return b.count()
+}
+
+// Shift returns the bitboard with every square moved one step in the
+// direction.  Squares moved off the board are dropped.
+func (b Bitboard) Shift(d Direction) Bitboard {
+	switch d {
+	case North:
+		return b >> 8
+	case NorthEast:
+		return (b &^ bbFileH) >> 9
+	case East:
+		return (b &^ bbFileH) >> 1
+	case SouthEast:
+		return (b &^ bbFileH) << 7
+	case South:
+		return b << 8
+	case SouthWest:
+		return (b &^ bbFileA) << 9
+	case West:
+		return (b &^ bbFileA) << 1
+	case NorthWest:
+		return (b &^ bbFileA) >> 7
+	}
+	return b
+}
+
+// Ray returns the squares from sq, exclusive, to the edge of the
+// board in the direction.
+func Ray(sq Square, d Direction) Bitboard {
+	if sq < A1 || sq > H8 || d < North || d > NorthWest {
+		return 0
+	}
+	return bbRays[d][sq]
+}
+
+// Between returns the squares strictly between two squares on the
+// same rank, file or diagonal and is empty otherwise.
+func Between(s1, s2 Square) Bitboard {
+	if s1 < A1 || s1 > H8 || s2 < A1 || s2 > H8 {
+		return 0
+	}
+	return bbBetween[s1][s2]
+}
+
+// Line returns the whole rank, file or diagonal through two distinct
+// squares and is empty if they don't share one.
+func Line(s1, s2 Square) Bitboard {
+	if s1 < A1 || s1 > H8 || s2 < A1 || s2 > H8 {
+		return 0
+	}
+	return bbLine[s1][s2]
+}
+
+func newBitboard(m map[Square]bool) (Bitboard, error) {
 	s := ""
 	for sq := 0; sq < numOfSquaresInBoard; sq++ {
 		if m[Square(sq)] {
@@ -20,12 +108,12 @@ func newBitboard(m map[Square]bool) (bitboard, error) {
 	}
 	bb, err := strconv.ParseUint(s, 2, 64)
 	if err != nil {
-		return bitboard(0), err
+		return Bitboard(0), err
 	}
-	return bitboard(bb), nil
+	return Bitboard(bb), nil
 }
 
-func (b bitboard) Mapping() map[Square]bool {
+func (b Bitboard) Mapping() map[Square]bool {
 	m := map[Square]bool{}
 	for sq := 0; sq < numOfSquaresInBoard; sq++ {
 		if b&bbForSquare(Square(sq)) > 0 {
@@ -35,14 +123,26 @@ func (b bitboard) Mapping() map[Square]bool {
 	return m
 }
 
+// Squares returns the squares set in the bitboard in ascending order.
+func (b Bitboard) Squares() []Square {
+	sqs := make([]Square, 0, b.count())
+	for ; b != 0; b &= b - 1 {
+		sqs = append(sqs, b.lastSquare())
+	}
+	for i, j := 0, len(sqs)-1; i < j; i, j = i+1, j-1 {
+		sqs[i], sqs[j] = sqs[j], sqs[i]
+	}
+	return sqs
+}
+
 // String returns a 64 character string of 1s and 0s starting with the most significant bit.
-func (b bitboard) String() string {
+func (b Bitboard) String() string {
 	s := strconv.FormatUint(uint64(b), 2)
 	return strings.Repeat("0", numOfSquaresInBoard-len(s)) + s
 }
 
 // Draw returns visual representation of the bitboard useful for debugging.
-func (b bitboard) Draw() string {
+func (b Bitboard) Draw() string {
 	s := "\n A B C D E F G H\n"
 	for r := 7; r >= 0; r-- {
 		s += Rank(r).String()
@@ -5,11 +5,35 @@ package chess
 import "math/bits"
 
 // Reverse returns a bitboard where the bit order is reversed.
-func (b bitboard) Reverse() bitboard {
-	return bitboard(bits.Reverse64(uint64(b)))
+func (b Bitboard) Reverse() Bitboard {
+	return Bitboard(bits.Reverse64(uint64(b)))
 }
 
 // Occupied returns true if the square's bitboard position is 1.
-func (b bitboard) Occupied(sq Square) bool {
+func (b Bitboard) Occupied(sq Square) bool {
 	return (bits.RotateLeft64(uint64(b), int(sq)+1) & 1) == 1
 }
+
+// firstSquare returns the lowest square set in the bitboard or
+// NoSquare if the bitboard is empty.
+func (b Bitboard) firstSquare() Square {
+	if b == 0 {
+		return NoSquare
+	}
+	return Square(bits.LeadingZeros64(uint64(b)))
+}
+
+// count returns the number of squares set in the bitboard.
+func (b Bitboard) count() int {
+	return bits.OnesCount64(uint64(b))
+}
+
+// lastSquare returns the highest square set in the bitboard or
+// NoSquare if the bitboard is empty.  It is the square cleared by
+// b &= b - 1 which makes for fast iteration when order doesn't matter.
+func (b Bitboard) lastSquare() Square {
+	if b == 0 {
+		return NoSquare
+	}
+	return Square(63 - bits.TrailingZeros64(uint64(b)))
+}
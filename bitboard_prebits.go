@@ -4,8 +4,8 @@ package chess
 
 // Reverse returns a bitboard where the bit order is reversed.
 // Implementation from: http://stackoverflow.com/questions/746171/best-algorithm-for-bit-reversal-from-msb-lsb-to-lsb-msb-in-c
-func (b bitboard) Reverse() bitboard {
-	return bitboard((bitReverseLookupTable[b&0xff] << 56) |
+func (b Bitboard) Reverse() Bitboard {
+	return Bitboard((bitReverseLookupTable[b&0xff] << 56) |
 		(bitReverseLookupTable[(b>>8)&0xff] << 48) |
 		(bitReverseLookupTable[(b>>16)&0xff] << 40) |
 		(bitReverseLookupTable[(b>>24)&0xff] << 32) |
@@ -35,8 +35,40 @@ var bitReverseLookupTable = []uint64{
 }
 
 // Occupied returns true if the square's bitboard position is 1.
-func (b bitboard) Occupied(sq Square) bool {
+func (b Bitboard) Occupied(sq Square) bool {
 	return (uint64(b) >> uint64(63-sq) & 1) == 1
 }
 
+// firstSquare returns the lowest square set in the bitboard or
+// NoSquare if the bitboard is empty.
+func (b Bitboard) firstSquare() Square {
+	for sq := 0; sq < numOfSquaresInBoard; sq++ {
+		if b.Occupied(Square(sq)) {
+			return Square(sq)
+		}
+	}
+	return NoSquare
+}
+
+// lastSquare returns the highest square set in the bitboard or
+// NoSquare if the bitboard is empty.  It is the square cleared by
+// b &= b - 1 which makes for fast iteration when order doesn't matter.
+func (b Bitboard) lastSquare() Square {
+	for sq := numOfSquaresInBoard - 1; sq >= 0; sq-- {
+		if b.Occupied(Square(sq)) {
+			return Square(sq)
+		}
+	}
+	return NoSquare
+}
+
+// count returns the number of squares set in the bitboard.
+func (b Bitboard) count() int {
+	n := 0
+	for ; b != 0; b &= b - 1 {
+		n++
+	}
+	return n
+}
+
 //
@@ -1,6 +1,9 @@
 package chess
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+)
 
 type bitboardTestPair struct {
 	initial  uint64
@@ -26,7 +29,7 @@ var (
 
 func TestBitboardReverse(t *testing.T) {
 	for _, p := range tests {
-		r := uint64(bitboard(p.initial).Reverse())
+		r := uint64(Bitboard(p.initial).Reverse())
 		if r != p.reversed {
 			t.Fatalf("bitboard reverse of %s expected %s but got %s", intStr(p.initial), intStr(p.reversed), intStr(r))
 		}
@@ -43,13 +46,88 @@ func TestBitboardOccupied(t *testing.T) {
 	}
 }
 
+func TestBitboardSquares(t *testing.T) {
+	bb := NewBitboard(H8, A1, E4)
+	if bb.Count() != 3 {
+		t.Fatalf("expected 3 squares but got %d", bb.Count())
+	}
+	if sqs := bb.Squares(); !reflect.DeepEqual(sqs, []Square{A1, E4, H8}) {
+		t.Fatalf("expected a1, e4 and h8 but got %v", sqs)
+	}
+	if sqs := Bitboard(0).Squares(); len(sqs) != 0 {
+		t.Fatalf("expected no squares but got %v", sqs)
+	}
+}
+
+func TestBitboardShift(t *testing.T) {
+	tests := []struct {
+		bb       Bitboard
+		d        Direction
+		expected Bitboard
+	}{
+		{NewBitboard(E4), North, NewBitboard(E5)},
+		{NewBitboard(E4), SouthWest, NewBitboard(D3)},
+		{NewBitboard(H4, A4), East, NewBitboard(B4)},
+		{NewBitboard(H4, A4), West, NewBitboard(G4)},
+		{NewBitboard(A8, H1), NorthEast, 0},
+		{FileA.Bitboard(), NorthWest, 0},
+		{Rank1.Bitboard(), South, 0},
+		{Rank1.Bitboard(), North, Rank2.Bitboard()},
+	}
+	for _, test := range tests {
+		if bb := test.bb.Shift(test.d); bb != test.expected {
+			t.Fatalf("expected %v shifted %d to be %v but got %v", test.bb.Squares(), test.d, test.expected.Squares(), bb.Squares())
+		}
+	}
+}
+
+func TestBitboardTables(t *testing.T) {
+	if bb := Ray(C3, NorthEast); bb != NewBitboard(D4, E5, F6, G7, H8) {
+		t.Fatalf("expected the ray to h8 but got %v", bb.Squares())
+	}
+	if bb := Ray(A1, West); bb != 0 {
+		t.Fatalf("expected an empty ray but got %v", bb.Squares())
+	}
+	if bb := Between(A1, A4); bb != NewBitboard(A2, A3) {
+		t.Fatalf("expected a2 and a3 but got %v", bb.Squares())
+	}
+	if bb := Between(A1, B3); bb != 0 {
+		t.Fatalf("expected no squares between a1 and b3 but got %v", bb.Squares())
+	}
+	if bb := Line(B2, C3); bb != A1.Diagonal() || bb.Count() != 8 {
+		t.Fatalf("expected the long diagonal but got %v", bb.Squares())
+	}
+	if bb := E4.AntiDiagonal(); bb != NewBitboard(B7, C6, D5, E4, F3, G2, H1, A8) {
+		t.Fatalf("expected the a8-h1 diagonal but got %v", bb.Squares())
+	}
+	if bb := KnightAttacks(A1); bb != NewBitboard(B3, C2) {
+		t.Fatalf("expected b3 and c2 but got %v", bb.Squares())
+	}
+	if bb := RookAttacks(A1, NewBitboard(A3)); bb != NewBitboard(A2, A3)|Rank1.Bitboard()&^A1.Bitboard() {
+		t.Fatalf("expected the rook to stop on a3 but got %v", bb.Squares())
+	}
+}
+
+func TestBoardBitboards(t *testing.T) {
+	b := StartingPosition().Board()
+	if bb := b.Pieces(WhiteKnight); bb != NewBitboard(B1, G1) {
+		t.Fatalf("expected knights on b1 and g1 but got %v", bb.Squares())
+	}
+	if bb := b.Occupied(Black); bb != Rank7.Bitboard()|Rank8.Bitboard() {
+		t.Fatalf("expected black on ranks 7 and 8 but got %v", bb.Squares())
+	}
+	if b.Occupied(NoColor).Count() != 32 {
+		t.Fatalf("expected 32 pieces but got %d", b.Occupied(NoColor).Count())
+	}
+}
+
 func BenchmarkBitboardReverse(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		u := uint64(9223372036854775807)
-		bitboard(u).Reverse()
+		Bitboard(u).Reverse()
 	}
 }
 
 func intStr(i uint64) string {
-	return bitboard(i).String()
+	return Bitboard(i).String()
 }
@@ -10,21 +10,21 @@ import (
 
 // A Board represents a chess board and its relationship between squares and pieces.
 type Board struct {
-	bbWhiteKing   bitboard
-	bbWhiteQueen  bitboard
-	bbWhiteRook   bitboard
-	bbWhiteBishop bitboard
-	bbWhiteKnight bitboard
-	bbWhitePawn   bitboard
-	bbBlackKing   bitboard
-	bbBlackQueen  bitboard
-	bbBlackRook   bitboard
-	bbBlackBishop bitboard
-	bbBlackKnight bitboard
-	bbBlackPawn   bitboard
-	whiteSqs      bitboard
-	blackSqs      bitboard
-	emptySqs      bitboard
+	bbWhiteKing   Bitboard
+	bbWhiteQueen  Bitboard
+	bbWhiteRook   Bitboard
+	bbWhiteBishop Bitboard
+	bbWhiteKnight Bitboard
+	bbWhitePawn   Bitboard
+	bbBlackKing   Bitboard
+	bbBlackQueen  Bitboard
+	bbBlackRook   Bitboard
+	bbBlackBishop Bitboard
+	bbBlackKnight Bitboard
+	bbBlackPawn   Bitboard
+	whiteSqs      Bitboard
+	blackSqs      Bitboard
+	emptySqs      Bitboard
 	whiteKingSq   Square
 	blackKingSq   Square
 }
@@ -204,7 +204,7 @@ func (b *Board) MarshalText() (text []byte, err error) {
 // UnmarshalText implements the encoding.TextUnarshaler interface and takes
 // a string in the FEN board format: rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR
 func (b *Board) UnmarshalText(text []byte) error {
-	cp, err := fenBoard(string(text))
+	cp, err := fenBoard(string(text), false)
 	if err != nil {
 		return err
 	}
@@ -217,7 +217,7 @@ func (b *Board) UnmarshalText(text []byte) error {
 // in the following order: WhiteKing, WhiteQueen, WhiteRook, WhiteBishop, WhiteKnight
 // WhitePawn, BlackKing, BlackQueen, BlackRook, BlackBishop, BlackKnight, BlackPawn
 func (b *Board) MarshalBinary() (data []byte, err error) {
-	bbs := []bitboard{b.bbWhiteKing, b.bbWhiteQueen, b.bbWhiteRook, b.bbWhiteBishop, b.bbWhiteKnight, b.bbWhitePawn,
+	bbs := []Bitboard{b.bbWhiteKing, b.bbWhiteQueen, b.bbWhiteRook, b.bbWhiteBishop, b.bbWhiteKnight, b.bbWhitePawn,
 		b.bbBlackKing, b.bbBlackQueen, b.bbBlackRook, b.bbBlackBishop, b.bbBlackKnight, b.bbBlackPawn}
 	buf := new(bytes.Buffer)
 	err = binary.Write(buf, binary.BigEndian, bbs)
@@ -232,67 +232,109 @@ func (b *Board) UnmarshalBinary(data []byte) error {
 	if len(data) != 96 {
 		return errors.New("chess: invalid number of bytes for board unmarshal binary")
 	}
-	b.bbWhiteKing = bitboard(binary.BigEndian.Uint64(data[:8]))
-	b.bbWhiteQueen = bitboard(binary.BigEndian.Uint64(data[8:16]))
-	b.bbWhiteRook = bitboard(binary.BigEndian.Uint64(data[16:24]))
-	b.bbWhiteBishop = bitboard(binary.BigEndian.Uint64(data[24:32]))
-	b.bbWhiteKnight = bitboard(binary.BigEndian.Uint64(data[32:40]))
-	b.bbWhitePawn = bitboard(binary.BigEndian.Uint64(data[40:48]))
-	b.bbBlackKing = bitboard(binary.BigEndian.Uint64(data[48:56]))
-	b.bbBlackQueen = bitboard(binary.BigEndian.Uint64(data[56:64]))
-	b.bbBlackRook = bitboard(binary.BigEndian.Uint64(data[64:72]))
-	b.bbBlackBishop = bitboard(binary.BigEndian.Uint64(data[72:80]))
-	b.bbBlackKnight = bitboard(binary.BigEndian.Uint64(data[80:88]))
-	b.bbBlackPawn = bitboard(binary.BigEndian.Uint64(data[88:96]))
+	b.bbWhiteKing = Bitboard(binary.BigEndian.Uint64(data[:8]))
+	b.bbWhiteQueen = Bitboard(binary.BigEndian.Uint64(data[8:16]))
+	b.bbWhiteRook = Bitboard(binary.BigEndian.Uint64(data[16:24]))
+	b.bbWhiteBishop = Bitboard(binary.BigEndian.Uint64(data[24:32]))
+	b.bbWhiteKnight = Bitboard(binary.BigEndian.Uint64(data[32:40]))
+	b.bbWhitePawn = Bitboard(binary.BigEndian.Uint64(data[40:48]))
+	b.bbBlackKing = Bitboard(binary.BigEndian.Uint64(data[48:56]))
+	b.bbBlackQueen = Bitboard(binary.BigEndian.Uint64(data[56:64]))
+	b.bbBlackRook = Bitboard(binary.BigEndian.Uint64(data[64:72]))
+	b.bbBlackBishop = Bitboard(binary.BigEndian.Uint64(data[72:80]))
+	b.bbBlackKnight = Bitboard(binary.BigEndian.Uint64(data[80:88]))
+	b.bbBlackPawn = Bitboard(binary.BigEndian.Uint64(data[88:96]))
 	b.calcConvienceBBs(nil)
 	return nil
 }
 
-func (b *Board) update(m *Move) {
+// update applies the move to the board in place and returns the
+// piece it captured, or NoPiece.  The move isn't validated.
+func (b *Board) update(m *Move) Piece {
 	p1 := b.Piece(m.s1)
 	s1BB := bbForSquare(m.s1)
 	s2BB := bbForSquare(m.s2)
 
-	// move s1 piece to s2
-	for _, p := range allPieces {
-		bb := b.bbForPiece(p)
-		// remove what was at s2
-		b.setBBForPiece(p, bb & ^s2BB)
-		// move what was at s1 to s2
-		if bb.Occupied(m.s1) {
-			bb = b.bbForPiece(p)
-			b.setBBForPiece(p, (bb & ^s1BB)|s2BB)
-		}
+	// remove what was at s2
+	captured := b.Piece(m.s2)
+	if captured != NoPiece {
+		b.setBBForPiece(captured, b.bbForPiece(captured) & ^s2BB)
 	}
+	// move s1 piece to s2
+	b.setBBForPiece(p1, (b.bbForPiece(p1) & ^s1BB)|s2BB)
 	// check promotion
 	if m.promo != NoPieceType {
 		newPiece := NewPiece(m.promo, p1.Color())
 		// remove pawn
-		bbPawn := b.bbForPiece(p1)
-		b.setBBForPiece(p1, bbPawn & ^s2BB)
+		b.setBBForPiece(p1, b.bbForPiece(p1) & ^s2BB)
 		// add promo piece
-		bbPromo := b.bbForPiece(newPiece)
-		b.setBBForPiece(newPiece, bbPromo|s2BB)
+		b.setBBForPiece(newPiece, b.bbForPiece(newPiece)|s2BB)
 	}
 	// remove captured en passant piece
 	if m.HasTag(EnPassant) {
-		if p1.Color() == White {
-			b.bbBlackPawn = ^(bbForSquare(m.s2) << 8) & b.bbBlackPawn
-		} else {
-			b.bbWhitePawn = ^(bbForSquare(m.s2) >> 8) & b.bbWhitePawn
+		captured = NewPiece(Pawn, p1.Color().Other())
+		capBB := bbForSquare(enPassantCaptureSquare(m.s2, p1.Color()))
+		b.setBBForPiece(captured, b.bbForPiece(captured) & ^capBB)
+	}
+	b.calcConvienceBBs(m)
+	return captured
+}
+
+// undo reverts a move previously applied with update.  captured
+// is the piece update returned.
+func (b *Board) undo(m *Move, captured Piece) {
+	p2 := b.Piece(m.s2)
+	s1BB := bbForSquare(m.s1)
+	s2BB := bbForSquare(m.s2)
+
+	// move the piece back to s1, demoting it if needed
+	p1 := p2
+	b.setBBForPiece(p2, b.bbForPiece(p2) & ^s2BB)
+	if m.promo != NoPieceType {
+		p1 = NewPiece(Pawn, p2.Color())
+	}
+	b.setBBForPiece(p1, b.bbForPiece(p1)|s1BB)
+	// put back the captured piece
+	if captured != NoPiece {
+		capBB := s2BB
+		if m.HasTag(EnPassant) {
+			capBB = bbForSquare(enPassantCaptureSquare(m.s2, p1.Color()))
 		}
+		b.setBBForPiece(captured, b.bbForPiece(captured)|capBB)
 	}
-	// move rook for castle
-	if p1.Color() == White && m.HasTag(KingSideCastle) {
-		b.bbWhiteRook = (b.bbWhiteRook & ^bbForSquare(H1) | bbForSquare(F1))
-	} else if p1.Color() == White && m.HasTag(QueenSideCastle) {
-		b.bbWhiteRook = (b.bbWhiteRook & ^bbForSquare(A1)) | bbForSquare(D1)
-	} else if p1.Color() == Black && m.HasTag(KingSideCastle) {
-		b.bbBlackRook = (b.bbBlackRook & ^bbForSquare(H8) | bbForSquare(F8))
-	} else if p1.Color() == Black && m.HasTag(QueenSideCastle) {
-		b.bbBlackRook = (b.bbBlackRook & ^bbForSquare(A8)) | bbForSquare(D8)
+	b.calcConvienceBBs(nil)
+}
+
+// castle moves the king and rook of color c for a castle move.  The
+// king and rook are moved independently so either may land on the
+// other's origin as happens in Chess960.  Swapping the origins and
+// destinations takes the castle back.
+func (b *Board) castle(c Color, kingFrom, kingTo, rookFrom, rookTo Square) {
+	king, rook := NewPiece(King, c), NewPiece(Rook, c)
+	b.setBBForPiece(king, (b.bbForPiece(king) & ^bbForSquare(kingFrom))|bbForSquare(kingTo))
+	b.setBBForPiece(rook, (b.bbForPiece(rook) & ^bbForSquare(rookFrom))|bbForSquare(rookTo))
+	b.calcConvienceBBs(nil)
+}
+
+// put places the piece on the empty square, as Crazyhouse drops do.
+func (b *Board) put(p Piece, sq Square) {
+	b.setBBForPiece(p, b.bbForPiece(p)|bbForSquare(sq))
+	b.calcConvienceBBs(nil)
+}
+
+// remove takes the piece off the square.  It reverts put.
+func (b *Board) remove(p Piece, sq Square) {
+	b.setBBForPiece(p, b.bbForPiece(p) & ^bbForSquare(sq))
+	b.calcConvienceBBs(nil)
+}
+
+// enPassantCaptureSquare returns the square of the pawn captured
+// en passant by color c moving to sq.
+func enPassantCaptureSquare(sq Square, c Color) Square {
+	if c == White {
+		return sq - 8
 	}
-	b.calcConvienceBBs(m)
+	return sq + 8
 }
 
 func (b *Board) calcConvienceBBs(m *Move) {
@@ -303,17 +345,8 @@ func (b *Board) calcConvienceBBs(m *Move) {
 	b.blackSqs = blackSqs
 	b.emptySqs = emptySqs
 	if m == nil {
-		b.whiteKingSq = NoSquare
-		b.blackKingSq = NoSquare
-
-		for sq := 0; sq < numOfSquaresInBoard; sq++ {
-			sqr := Square(sq)
-			if b.bbWhiteKing.Occupied(sqr) {
-				b.whiteKingSq = sqr
-			} else if b.bbBlackKing.Occupied(sqr) {
-				b.blackKingSq = sqr
-			}
-		}
+		b.whiteKingSq = b.bbWhiteKing.firstSquare()
+		b.blackKingSq = b.bbBlackKing.firstSquare()
 	} else if m.s1 == b.whiteKingSq {
 		b.whiteKingSq = m.s2
 	} else if m.s1 == b.blackKingSq {
@@ -395,7 +428,48 @@ func (b *Board) HasSufficientMaterial() bool {
 	return true
 }
 
-func (b *Board) bbForPiece(p Piece) bitboard {
+// HasMatingMaterial returns true if the color could checkmate by some
+// series of legal moves, with its opponent's pieces helping.  A player
+// whose time runs out only loses if the opponent has mating material.
+func (b *Board) HasMatingMaterial(c Color) bool {
+	us, them := b.Occupied(c), b.Occupied(c.Other())
+	pawns := b.bbWhitePawn | b.bbBlackPawn
+	knights := b.bbWhiteKnight | b.bbBlackKnight
+	bishops := b.bbWhiteBishop | b.bbBlackBishop
+	if us&(pawns|b.bbWhiteRook|b.bbBlackRook|b.bbWhiteQueen|b.bbBlackQueen) != 0 {
+		return true
+	}
+	if us&knights != 0 {
+		// a lone knight needs the opponent to block its king
+		// with a piece other than a queen
+		return us.Count() > 2 || them&^(b.bbWhiteKing|b.bbBlackKing|b.bbWhiteQueen|b.bbBlackQueen) != 0
+	}
+	if us&bishops != 0 {
+		// bishops of a single square color need another piece
+		// to block the king
+		return bishops&bbDarkSquares != 0 && bishops&^bbDarkSquares != 0 || pawns|knights != 0
+	}
+	return false
+}
+
+// Pieces returns the squares of the given piece.
+func (b *Board) Pieces(p Piece) Bitboard {
+	return b.bbForPiece(p)
+}
+
+// Occupied returns the squares of the pieces of the given color or,
+// for NoColor, of all the pieces.
+func (b *Board) Occupied(c Color) Bitboard {
+	switch c {
+	case White:
+		return b.whiteSqs
+	case Black:
+		return b.blackSqs
+	}
+	return ^b.emptySqs
+}
+
+func (b *Board) bbForPiece(p Piece) Bitboard {
 	switch p {
 	case WhiteKing:
 		return b.bbWhiteKing
@@ -422,10 +496,10 @@ func (b *Board) bbForPiece(p Piece) bitboard {
 	case BlackPawn:
 		return b.bbBlackPawn
 	}
-	return bitboard(0)
+	return Bitboard(0)
 }
 
-func (b *Board) setBBForPiece(p Piece, bb bitboard) {
+func (b *Board) setBBForPiece(p Piece, bb Bitboard) {
 	switch p {
 	case WhiteKing:
 		b.bbWhiteKing = bb
@@ -91,3 +91,37 @@ func TestBoardTranspose(t *testing.T) {
 		t.Fatalf(BOARD_STRING_ERROR_MESSAGE, b, board.String())
 	}
 }
+
+func TestBoardHasMatingMaterial(t *testing.T) {
+	tests := []struct {
+		fen          string
+		white, black bool
+	}{
+		{INITIAL_FEN_POSITION, true, true},
+		{"4k3/8/8/8/8/8/8/4K3 w - - 0 1", false, false},
+		{"4k3/8/8/8/8/8/8/4KQ2 w - - 0 1", true, false},
+		{"4k3/8/8/8/8/8/8/4KN2 w - - 0 1", false, false},
+		{"4k3/8/8/8/8/8/8/3NKN2 w - - 0 1", true, false},
+		{"4kq2/8/8/8/8/8/8/4KN2 w - - 0 1", false, true},
+		{"4kb2/8/8/8/8/8/8/4KN2 w - - 0 1", true, true},
+		{"4k3/8/8/8/8/8/8/4KB2 w - - 0 1", false, false},
+		{"4kb2/8/8/8/8/8/8/4K2B w - - 0 1", true, true},
+		{"4kb2/8/8/8/8/8/8/4K1B1 w - - 0 1", false, false},
+		{"4k3/p7/8/8/8/8/8/4KB2 w - - 0 1", true, true},
+	}
+	for _, test := range tests {
+		b := unsafeFEN(test.fen).Board()
+		if b.HasMatingMaterial(White) != test.white || b.HasMatingMaterial(Black) != test.black {
+			t.Fatalf("expected %s to have mating material %t for white and %t for black", test.fen, test.white, test.black)
+		}
+	}
+	b := unsafeFEN("4kb2/8/8/8/8/8/8/4K2B w - - 0 1").Board()
+	if allocs := testing.AllocsPerRun(10, func() { b.HasMatingMaterial(White) }); allocs != 0 {
+		t.Fatalf("expected no allocations but got %f", allocs)
+	}
+	for sq := A1; sq <= H8; sq++ {
+		if dark := bbDarkSquares&bbForSquare(sq) != 0; dark != (sq.color() == Black) {
+			t.Fatalf("expected %s to be dark %t", sq, !dark)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package chess
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrMissingKing is reported when a side has no king.
+	ErrMissingKing = errors.New("chess: missing king")
+	// ErrTooManyKings is reported when a side has more than one king.
+	ErrTooManyKings = errors.New("chess: too many kings")
+	// ErrPawnOnBackRank is reported for pawns on the first or last rank.
+	ErrPawnOnBackRank = errors.New("chess: pawn on the first or last rank")
+	// ErrOppositeCheck is reported when the side not to move is in
+	// check, as its king could be captured.
+	ErrOppositeCheck = errors.New("chess: side not to move is in check")
+	// ErrInvalidCastleRights is reported for castle rights that don't
+	// match the placement of the king and rooks.
+	ErrInvalidCastleRights = errors.New("chess: invalid castle rights")
+	// ErrInvalidEnPassant is reported for an en passant square no
+	// pawn can just have crossed.
+	ErrInvalidEnPassant = errors.New("chess: invalid en passant square")
+	// ErrInvalidMoveCounters is reported for a negative half move
+	// clock or a move count below one.
+	ErrInvalidMoveCounters = errors.New("chess: invalid half move clock or move count")
+)
+
+// PositionError is an illegality found by PositionBuilder.Build.
+// Err is one of the Err variables above and can be tested with
+// errors.Is.
+type PositionError struct {
+	Err error
+	// Color is the side at fault or NoColor.
+	Color Color
+	// Square is the square at fault or NoSquare.
+	Square Square
+}
+
+// Error implements the error interface.
+func (e *PositionError) Error() string {
+	var details []string
+	if e.Color != NoColor {
+		details = append(details, e.Color.Name())
+	}
+	if e.Square != NoSquare {
+		details = append(details, e.Square.String())
+	}
+	if len(details) == 0 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s (%s)", e.Err, strings.Join(details, " "))
+}
+
+// Unwrap returns Err.
+func (e *PositionError) Unwrap() error {
+	return e.Err
+}
+
+// PositionBuilder sets up a position piece by piece along with the
+// side to move, castle rights, en passant square and clocks.  Build
+// checks the result is a legal standard or Chess960 position.
+//
+//	pos, err := chess.NewPositionBuilder().
+//		Place(chess.WhiteKing, chess.E1).
+//		Place(chess.BlackKing, chess.E8).
+//		Place(chess.WhiteRook, chess.H1).
+//		SetCastleRights("K").
+//		Build()
+type PositionBuilder struct {
+	pieces        [numOfSquaresInBoard]Piece
+	turn          Color
+	castleRights  CastleRights
+	enPassant     Square
+	halfMoveClock int
+	moveCount     int
+}
+
+// NewPositionBuilder returns a builder of an empty board with White
+// to move, no castle rights and the move count at one.
+func NewPositionBuilder() *PositionBuilder {
+	return &PositionBuilder{
+		turn:         White,
+		castleRights: "-",
+		enPassant:    NoSquare,
+		moveCount:    1,
+	}
+}
+
+// NewPositionBuilderFrom returns a builder starting from the
+// position.  Chess960 castle rights are kept in X-FEN form.
+func NewPositionBuilderFrom(pos *Position) *PositionBuilder {
+	pb := NewPositionBuilder()
+	for sq, p := range pos.board.SquareMap() {
+		pb.pieces[sq] = p
+	}
+	pb.turn = pos.turn
+	pb.castleRights = CastleRights(pos.xfenCastleRights())
+	pb.enPassant = pos.enPassantSquare
+	pb.halfMoveClock = pos.halfMoveClock
+	pb.moveCount = pos.moveCount
+	return pb
+}
+
+// Place puts the piece on the square replacing any piece there.
+func (pb *PositionBuilder) Place(p Piece, sq Square) *PositionBuilder {
+	if sq >= A1 && sq <= H8 {
+		pb.pieces[sq] = p
+	}
+	return pb
+}
+
+// Remove takes the piece off the square.
+func (pb *PositionBuilder) Remove(sq Square) *PositionBuilder {
+	return pb.Place(NoPiece, sq)
+}
+
+// Clear removes every piece.
+func (pb *PositionBuilder) Clear() *PositionBuilder {
+	pb.pieces = [numOfSquaresInBoard]Piece{}
+	return pb
+}
+
+// Piece returns the piece on the square.
+func (pb *PositionBuilder) Piece(sq Square) Piece {
+	if sq < A1 || sq > H8 {
+		return NoPiece
+	}
+	return pb.pieces[sq]
+}
+
+// SetTurn sets the side to move.
+func (pb *PositionBuilder) SetTurn(c Color) *PositionBuilder {
+	pb.turn = c
+	return pb
+}
+
+// SetCastleRights sets the castle rights in FEN, X-FEN or
+// Shredder-FEN form.  Ex. KQkq, HAha or "-"
+func (pb *PositionBuilder) SetCastleRights(cr CastleRights) *PositionBuilder {
+	if cr == "" {
+		cr = "-"
+	}
+	pb.castleRights = cr
+	return pb
+}
+
+// SetEnPassant sets the square a pawn just crossed with a double
+// step or NoSquare.
+func (pb *PositionBuilder) SetEnPassant(sq Square) *PositionBuilder {
+	pb.enPassant = sq
+	return pb
+}
+
+// SetHalfMoveClock sets the number of half moves since the last
+// capture or pawn move.
+func (pb *PositionBuilder) SetHalfMoveClock(n int) *PositionBuilder {
+	pb.halfMoveClock = n
+	return pb
+}
+
+// SetMoveCount sets the number of the full move, starting at one.
+func (pb *PositionBuilder) SetMoveCount(n int) *PositionBuilder {
+	pb.moveCount = n
+	return pb
+}
+
+// Build returns the position or the illegalities found, joined as
+// *PositionError values.
+func (pb *PositionBuilder) Build() (*Position, error) {
+	m := map[Square]Piece{}
+	for sq, p := range pb.pieces {
+		if p != NoPiece {
+			m[Square(sq)] = p
+		}
+	}
+	b := NewBoard(m)
+	var errs []error
+	for _, c := range []Color{White, Black} {
+		switch b.bbForPiece(NewPiece(King, c)).count() {
+		case 0:
+			errs = append(errs, &PositionError{Err: ErrMissingKing, Color: c, Square: NoSquare})
+		case 1:
+		default:
+			errs = append(errs, &PositionError{Err: ErrTooManyKings, Color: c, Square: NoSquare})
+		}
+	}
+	pawns := (b.bbWhitePawn | b.bbBlackPawn) & (bbRank1 | bbRank8)
+	for _, sq := range pawns.Squares() {
+		errs = append(errs, &PositionError{Err: ErrPawnOnBackRank, Color: b.Piece(sq).Color(), Square: sq})
+	}
+	turn := pb.turn
+	if turn != Black {
+		turn = White
+	}
+	if b.isKingAttacked(turn.Other()) {
+		errs = append(errs, &PositionError{Err: ErrOppositeCheck, Color: turn.Other(), Square: NoSquare})
+	}
+	rights, rooks, chess960, err := formCastleRights(string(pb.castleRights), b, false)
+	if err != nil || !castleRightsMatch(b, rights, rooks, chess960) {
+		errs = append(errs, &PositionError{Err: ErrInvalidCastleRights, Color: NoColor, Square: NoSquare})
+	}
+	if !enPassantPossible(b, turn, pb.enPassant) {
+		errs = append(errs, &PositionError{Err: ErrInvalidEnPassant, Color: NoColor, Square: pb.enPassant})
+	}
+	if pb.halfMoveClock < 0 || pb.moveCount < 1 {
+		errs = append(errs, &PositionError{Err: ErrInvalidMoveCounters, Color: NoColor, Square: NoSquare})
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	pos := &Position{
+		board:           b,
+		turn:            turn,
+		castleRights:    rights,
+		enPassantSquare: pb.enPassant,
+		halfMoveClock:   pb.halfMoveClock,
+		moveCount:       pb.moveCount,
+		chess960:        chess960,
+		castleRooks:     rooks,
+	}
+	pos.inCheck = isInCheck(pos)
+	pos.hash = pos.zobrist()
+	return pos, nil
+}
+
+// castleRightsMatch returns true if the king of every side with a
+// right stands on its back rank, on the e-file unless Chess960, with
+// the right's rook on that side of it.
+func castleRightsMatch(b *Board, rights CastleRights, rooks [4]Square, chess960 bool) bool {
+	bits := rights.bits()
+	for i, rookSq := range rooks {
+		if bits&(1<<i) == 0 {
+			continue
+		}
+		c, rank, kingSq := White, Rank1, b.whiteKingSq
+		if i >= 2 {
+			c, rank, kingSq = Black, Rank8, b.blackKingSq
+		}
+		if kingSq == NoSquare || kingSq.Rank() != rank || rookSq.Rank() != rank {
+			return false
+		}
+		if !chess960 && kingSq.File() != FileE {
+			return false
+		}
+		if b.bbForPiece(NewPiece(Rook, c))&bbForSquare(rookSq) == 0 {
+			return false
+		}
+		kingSide := i%2 == 0
+		if kingSide != (rookSq.File() > kingSq.File()) {
+			return false
+		}
+	}
+	return true
+}
+
+// enPassantPossible returns true if sq is NoSquare or a square the
+// side not to move's pawn just crossed: the pawn stands in front of
+// it and both it and the square the pawn came from are empty.
+func enPassantPossible(b *Board, turn Color, sq Square) bool {
+	if sq == NoSquare {
+		return true
+	}
+	rank, from := Rank6, sq+8
+	if turn == Black {
+		rank, from = Rank3, sq-8
+	}
+	if sq < A1 || sq > H8 || sq.Rank() != rank {
+		return false
+	}
+	pawn := NewPiece(Pawn, turn.Other())
+	return b.Piece(enPassantCaptureSquare(sq, turn)) == pawn &&
+		b.Piece(sq) == NoPiece && b.Piece(from) == NoPiece
+}
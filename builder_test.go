@@ -0,0 +1,79 @@
+package chess
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPositionBuilder(t *testing.T) {
+	pos, err := NewPositionBuilder().
+		Place(WhiteKing, E1).
+		Place(WhiteRook, H1).
+		Place(BlackKing, E8).
+		Place(BlackPawn, D4).
+		Place(WhitePawn, E4).
+		SetTurn(Black).
+		SetCastleRights("K").
+		SetEnPassant(E3).
+		SetMoveCount(12).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "4k3/8/8/8/3pP3/8/8/4K2R b K e3 0 12"
+	if pos.String() != expected {
+		t.Fatalf("expected %s but got %s", expected, pos.String())
+	}
+	if pos.ZobristHash() != unsafeFEN(expected).ZobristHash() {
+		t.Fatal("expected the key to match the FEN's")
+	}
+	if len(pos.ValidMoves()) != 7 {
+		t.Fatalf("expected 7 moves including dxe3 but got %v", pos.ValidMoves())
+	}
+	pos, err = NewPositionBuilderFrom(StartingPosition()).Remove(G1).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pos.String() != "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKB1R w KQkq - 0 1" {
+		t.Fatalf("expected the knight removed but got %s", pos.String())
+	}
+}
+
+func TestPositionBuilderErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		builder  *PositionBuilder
+		expected []error
+	}{
+		{"missing kings", NewPositionBuilder(), []error{ErrMissingKing}},
+		{"extra king", NewPositionBuilder().Place(WhiteKing, E1).Place(WhiteKing, E2).Place(BlackKing, E8), []error{ErrTooManyKings}},
+		{"back rank pawn", NewPositionBuilder().Place(WhiteKing, E1).Place(BlackKing, E8).Place(WhitePawn, A8), []error{ErrPawnOnBackRank}},
+		{"opposite check", NewPositionBuilder().Place(WhiteKing, E1).Place(BlackKing, E8).Place(WhiteRook, E4), []error{ErrOppositeCheck}},
+		{"castle without rook", NewPositionBuilder().Place(WhiteKing, E1).Place(BlackKing, E8).SetCastleRights("Q"), []error{ErrInvalidCastleRights}},
+		{"castle king off rank", NewPositionBuilder().Place(WhiteKing, E2).Place(WhiteRook, H1).Place(BlackKing, E8).SetCastleRights("K"), []error{ErrInvalidCastleRights}},
+		{"castle garbage", NewPositionBuilder().Place(WhiteKing, E1).Place(BlackKing, E8).SetCastleRights("X"), []error{ErrInvalidCastleRights}},
+		{"ep without pawn", NewPositionBuilder().Place(WhiteKing, E1).Place(BlackKing, E8).SetEnPassant(D6), []error{ErrInvalidEnPassant}},
+		{"ep wrong rank", NewPositionBuilder().Place(WhiteKing, E1).Place(BlackKing, E8).Place(WhitePawn, E4).SetEnPassant(E3), []error{ErrInvalidEnPassant}},
+		{"move count", NewPositionBuilder().Place(WhiteKing, E1).Place(BlackKing, E8).SetMoveCount(0), []error{ErrInvalidMoveCounters}},
+		{"several", NewPositionBuilder().Place(WhiteKing, E1).Place(BlackPawn, H1).SetHalfMoveClock(-1), []error{ErrMissingKing, ErrPawnOnBackRank, ErrInvalidMoveCounters}},
+	}
+	for _, test := range tests {
+		pos, err := test.builder.Build()
+		if err == nil {
+			t.Fatalf("%s: expected an error but got %s", test.name, pos)
+		}
+		for _, expected := range test.expected {
+			if !errors.Is(err, expected) {
+				t.Fatalf("%s: expected %v but got %v", test.name, expected, err)
+			}
+		}
+		var pe *PositionError
+		if !errors.As(err, &pe) {
+			t.Fatalf("%s: expected a *PositionError but got %T", test.name, err)
+		}
+	}
+	_, err := NewPositionBuilder().Place(WhiteKing, E1).Place(BlackPawn, H1).Place(BlackKing, E8).Build()
+	if err == nil || err.Error() != "chess: pawn on the first or last rank (Black h1)" {
+		t.Fatalf("expected the pawn's color and square in the error but got %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package chess
+
+import (
+	"fmt"
+	"strings"
+)
+
+// chess960Knights lists the knight placements on the five squares
+// left after placing the bishops and queen.
+var chess960Knights = [10][2]int{
+	{0, 1}, {0, 2}, {0, 3}, {0, 4}, {1, 2},
+	{1, 3}, {1, 4}, {2, 3}, {2, 4}, {3, 4},
+}
+
+// Chess960StartingPosition returns the Chess960 (Fischer Random)
+// starting position of the given index in Scharnagl's numbering
+// from 0 to 959.  Index 518 is the standard starting position.
+func Chess960StartingPosition(index int) (*Position, error) {
+	if index < 0 || index > 959 {
+		return nil, fmt.Errorf("chess: chess960 index %d out of range 0-959", index)
+	}
+	var rank [8]byte
+	n := index
+	rank[n%4*2+1] = 'B'
+	n /= 4
+	rank[n%4*2] = 'B'
+	n /= 4
+	place := func(piece byte, skip int) {
+		for f := range rank {
+			if rank[f] != 0 {
+				continue
+			}
+			if skip == 0 {
+				rank[f] = piece
+				return
+			}
+			skip--
+		}
+	}
+	place('Q', n%6)
+	n /= 6
+	knights := chess960Knights[n]
+	// the second knight is placed after the first took a square
+	place('N', knights[0])
+	place('N', knights[1]-1)
+	place('R', 0)
+	place('K', 0)
+	place('R', 0)
+	white := string(rank[:])
+	fen := strings.ToLower(white) + "/pppppppp/8/8/8/8/PPPPPPPP/" + white + " w KQkq - 0 1"
+	return decodeFENMode(fen, FENChess960)
+}
+
+// Chess960 takes a Chess960 starting position index and returns
+// a function that starts the game from that position.  The game
+// gets the Variant, SetUp and FEN tag pairs so its PGN can be
+// decoded again.  The returned function is designed to be used
+// in the NewGame constructor.  An error is returned if the index
+// is out of range.
+func Chess960(index int) (func(*Game), error) {
+	pos, err := Chess960StartingPosition(index)
+	if err != nil {
+		return nil, err
+	}
+	return func(g *Game) {
+		g.setRoot(&MoveNode{pos: pos})
+		g.AddTagPair("Variant", "Chess960")
+		g.AddTagPair("SetUp", "1")
+		g.AddTagPair("FEN", pos.String())
+		g.updatePosition()
+	}, nil
+}
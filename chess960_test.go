@@ -0,0 +1,232 @@
+package chess
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestChess960StartingPosition(t *testing.T) {
+	tests := map[int]string{
+		0:   "bbqnnrkr/pppppppp/8/8/8/8/PPPPPPPP/BBQNNRKR w KQkq - 0 1",
+		518: INITIAL_FEN_POSITION,
+		959: "rkrnnqbb/pppppppp/8/8/8/8/PPPPPPPP/RKRNNQBB w KQkq - 0 1",
+	}
+	for index, fen := range tests {
+		pos, err := Chess960StartingPosition(index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pos.String() != fen {
+			t.Fatalf("expected position %d to be %s but got %s", index, fen, pos.String())
+		}
+		if !pos.Chess960() {
+			t.Fatalf("expected position %d to be a chess960 position", index)
+		}
+	}
+	seen := map[string]bool{}
+	for index := 0; index < 960; index++ {
+		pos, err := Chess960StartingPosition(index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rank := strings.Split(pos.String(), "/")[7][:8]
+		if seen[rank] {
+			t.Fatalf("position %d %s is a duplicate", index, rank)
+		}
+		seen[rank] = true
+		b1, b2 := strings.Index(rank, "B"), strings.LastIndex(rank, "B")
+		r1, k, r2 := strings.Index(rank, "R"), strings.Index(rank, "K"), strings.LastIndex(rank, "R")
+		if b1%2 == b2%2 || !(r1 < k && k < r2) {
+			t.Fatalf("position %d %s is invalid", index, rank)
+		}
+	}
+	if _, err := Chess960StartingPosition(960); err == nil {
+		t.Fatal("expected an error for index 960")
+	}
+}
+
+func TestChess960FEN(t *testing.T) {
+	tests := []struct {
+		fen      string
+		expected string
+		rights   CastleRights
+	}{
+		// Shredder-FEN is written as X-FEN
+		{
+			fen:      "bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w HFhf - 2 9",
+			expected: "bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w KQkq - 2 9",
+			rights:   "KQkq",
+		},
+		// an inner rook needs its file letter
+		{
+			fen:      "1k1r2r1/8/8/8/8/8/8/1K1R2R1 w Dd - 0 1",
+			expected: "1k1r2r1/8/8/8/8/8/8/1K1R2R1 w Dd - 0 1",
+			rights:   "Kk",
+		},
+		{
+			fen:      "1k1r2r1/8/8/8/8/8/8/1K1R2R1 w Gg - 0 1",
+			expected: "1k1r2r1/8/8/8/8/8/8/1K1R2R1 w Kk - 0 1",
+			rights:   "Kk",
+		},
+	}
+	for _, test := range tests {
+		pos, err := decodeFEN(test.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !pos.Chess960() {
+			t.Fatalf("expected %s to be a chess960 position", test.fen)
+		}
+		if pos.String() != test.expected {
+			t.Fatalf("expected %s but got %s", test.expected, pos.String())
+		}
+		if pos.CastleRights() != test.rights {
+			t.Fatalf("expected castle rights %s but got %s", test.rights, pos.CastleRights())
+		}
+	}
+	if unsafeFEN(INITIAL_FEN_POSITION).Chess960() {
+		t.Fatal("expected the starting position not to be a chess960 position")
+	}
+	// KQkq is only read as X-FEN when asked for
+	const xfen = "bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w KQkq - 2 9"
+	pos, err := DecodeFEN(xfen, FENChess960)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shredder := unsafeFEN(tests[0].fen)
+	if !pos.Chess960() || pos.castleRooks != shredder.castleRooks {
+		t.Fatalf("expected %s to be read as the chess960 position", xfen)
+	}
+	if pos := unsafeFEN(xfen); pos.Chess960() {
+		t.Fatalf("expected %s not to be a chess960 position", xfen)
+	}
+	for _, m := range unsafeFEN(xfen).ValidMoves() {
+		if m.HasTag(KingSideCastle) || m.HasTag(QueenSideCastle) {
+			t.Fatalf("expected no castles off the e-file but got %s", m)
+		}
+	}
+	if _, err := decodeFEN("1k1r2r1/8/8/8/8/8/8/1K1R2R1 w B - 0 1"); err == nil {
+		t.Fatal("expected an error for a castle right on the king's file")
+	}
+}
+
+func TestChess960Castle(t *testing.T) {
+	fen := "1r2k2r/8/8/8/8/8/8/1RK4R w HBhb - 0 1"
+	g := NewGame(unsafeFENGame(fen), UseNotation(UCINotation{}))
+	moves := []struct {
+		move     string
+		san      string
+		expected string
+	}{
+		{"c1b1", "O-O-O", "1r2k2r/8/8/8/8/8/8/2KR3R b kq - 1 1"},
+		{"e8h8", "O-O", "1r3rk1/8/8/8/8/8/8/2KR3R w - - 2 2"},
+	}
+	for _, m := range moves {
+		pos := g.Position()
+		if err := g.MoveStr(m.move); err != nil {
+			t.Fatal(err)
+		}
+		last := g.Moves()[len(g.Moves())-1]
+		if san := (AlgebraicNotation{}).Encode(pos, last); san != m.san {
+			t.Fatalf("expected %s to be %s but got %s", m.move, m.san, san)
+		}
+		if g.Position().String() != m.expected {
+			t.Fatalf("after %s expected %s but got %s", m.move, m.expected, g.Position().String())
+		}
+	}
+	pos := unsafeFEN(fen)
+	for _, m := range pos.ValidMoves() {
+		u := pos.MakeMove(m)
+		pos.UnmakeMove(m, u)
+		if pos.String() != "1r2k2r/8/8/8/8/8/8/1RK4R w KQkq - 0 1" || pos.hash != pos.zobrist() {
+			t.Fatalf("taking back %s left %s", m, pos.String())
+		}
+	}
+}
+
+func TestChess960UCINotation(t *testing.T) {
+	pos := unsafeFEN("r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1")
+	n := UCINotation{Chess960: true}
+	for _, s := range []string{"e1h1", "e1a1"} {
+		m, err := n.Decode(pos, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		valid := pos.validMove(m)
+		if valid == nil {
+			t.Fatalf("expected %s to be a valid castle", s)
+		}
+		if enc := n.Encode(pos, valid); enc != s {
+			t.Fatalf("expected %s but got %s", s, enc)
+		}
+		if enc := (UCINotation{}).Encode(pos, valid); enc == s {
+			t.Fatalf("expected %s without Chess960 to be encoded as the king's move", s)
+		}
+	}
+}
+
+func TestChess960PGN(t *testing.T) {
+	pgn := `[Variant "Chess960"]
+[SetUp "1"]
+[FEN "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"]
+
+1. e4 e5 2. Nf3 Nc6 3. Bc4 Bc5 4. O-O *`
+	g := NewGame()
+	if err := g.UnmarshalText([]byte(pgn)); err != nil {
+		t.Fatal(err)
+	}
+	last := g.Moves()[len(g.Moves())-1]
+	if last.String() != "e1h1" {
+		t.Fatalf("expected castle e1h1 in a chess960 game but got %s", last)
+	}
+	opt, err := Chess960(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g = NewGame(opt)
+	for _, s := range []string{"d2d4", "d7d5"} {
+		m, err := (UCINotation{}).Decode(g.Position(), s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := g.Move(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := g.MoveStr("O-O"); err == nil {
+		t.Fatal("expected O-O to be blocked by the queen side rook")
+	}
+	g2 := NewGame()
+	if err := g2.UnmarshalText([]byte(g.String())); err != nil {
+		t.Fatal(err)
+	}
+	if !g2.Position().Chess960() || g2.Position().String() != g.Position().String() {
+		t.Fatalf("expected %s but got %s", g.Position().String(), g2.Position().String())
+	}
+}
+
+func TestChess960Perft(t *testing.T) {
+	f, err := os.Open("fixtures/perft/chess960.epd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	results, err := PerftSuite(f, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if !r.Passed() {
+			t.Fatalf("perft %s depth %d expected %d but got %d", r.FEN, r.Depth, r.Expected, r.Nodes)
+		}
+	}
+}
+
+func unsafeFENGame(fen string) func(*Game) {
+	f, err := FEN(fen)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
@@ -0,0 +1,309 @@
+package chess
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A ClockMode is how a time control stage gives time for moves.
+type ClockMode uint8
+
+const (
+	// FischerIncrement adds the increment to the clock after each
+	// move.
+	FischerIncrement ClockMode = iota
+	// BronsteinDelay adds back the time a move used, up to the
+	// increment.
+	BronsteinDelay
+	// SimpleDelay waits the increment before the clock starts
+	// running for a move.
+	SimpleDelay
+	// Sandclock is an hourglass of the stage's time: the time a move
+	// uses is added to the opponent's clock.
+	Sandclock
+)
+
+// ErrUnknownTimeControl is returned by ParseTimeControl for the ? of
+// a game whose time control isn't known.
+var ErrUnknownTimeControl = errors.New("chess: unknown time control")
+
+// TimeControlStage is a period of a time control.
+type TimeControlStage struct {
+	// Moves is the number of moves of the stage or zero for the rest
+	// of the game.
+	Moves int
+	// Time is added to the clock at the start of the stage.
+	Time time.Duration
+	// Increment is the time given for each move as set by Mode.
+	Increment time.Duration
+	Mode      ClockMode
+}
+
+// A TimeControl is the stages of a game's time control.  The last
+// stage is repeated once its moves are played.  Ex. 40 moves in 90
+// minutes and 30 minutes for the rest of the game with a 30 second
+// increment from the first move:
+//
+//	chess.TimeControl{
+//		{Moves: 40, Time: 90 * time.Minute, Increment: 30 * time.Second},
+//		{Time: 30 * time.Minute, Increment: 30 * time.Second},
+//	}
+type TimeControl []TimeControlStage
+
+// ParseTimeControl parses the value of a PGN TimeControl tag, stages
+// separated by colons written as seconds, moves/seconds, seconds+increment
+// or moves/seconds+increment.  Ex. 300, 180+2 or 40/5400+30:1800+30.  A
+// d or b in place of the + is a simple or Bronstein delay and *seconds
+// is a Sandclock.  A dash is no time control and returns nil and a
+// question mark returns ErrUnknownTimeControl.
+func ParseTimeControl(s string) (TimeControl, error) {
+	switch {
+	case s == "-":
+		return nil, nil
+	case s == "?":
+		return nil, ErrUnknownTimeControl
+	case strings.HasPrefix(s, "*"):
+		t, err := parseSeconds(s[1:])
+		if err != nil {
+			return nil, fmt.Errorf("chess: invalid time control %q", s)
+		}
+		return TimeControl{{Time: t, Mode: Sandclock}}, nil
+	}
+	tc := TimeControl{}
+	for _, field := range strings.Split(s, ":") {
+		stage := TimeControlStage{}
+		if moves, rest, ok := strings.Cut(field, "/"); ok {
+			n, err := strconv.Atoi(moves)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("chess: invalid time control %q", s)
+			}
+			stage.Moves, field = n, rest
+		}
+		if i := strings.IndexAny(field, "+db"); i >= 0 {
+			stage.Mode = map[byte]ClockMode{'+': FischerIncrement, 'b': BronsteinDelay, 'd': SimpleDelay}[field[i]]
+			inc, err := parseSeconds(field[i+1:])
+			if err != nil {
+				return nil, fmt.Errorf("chess: invalid time control %q", s)
+			}
+			stage.Increment, field = inc, field[:i]
+		}
+		t, err := parseSeconds(field)
+		if err != nil {
+			return nil, fmt.Errorf("chess: invalid time control %q", s)
+		}
+		stage.Time = t
+		tc = append(tc, stage)
+	}
+	return tc, nil
+}
+
+func parseSeconds(s string) (time.Duration, error) {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(v) || v < 0 || v >= math.MaxInt64/float64(time.Second) {
+		return 0, fmt.Errorf("chess: invalid seconds %q", s)
+	}
+	return time.Duration(v * float64(time.Second)), nil
+}
+
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+}
+
+// String returns the PGN TimeControl tag value.
+func (tc TimeControl) String() string {
+	if len(tc) == 0 {
+		return "-"
+	}
+	fields := make([]string, len(tc))
+	for i, stage := range tc {
+		if stage.Mode == Sandclock {
+			fields[i] = "*" + formatSeconds(stage.Time)
+			continue
+		}
+		s := formatSeconds(stage.Time)
+		if stage.Moves > 0 {
+			s = fmt.Sprintf("%d/%s", stage.Moves, s)
+		}
+		if stage.Increment > 0 {
+			switch stage.Mode {
+			case BronsteinDelay:
+				s += "b"
+			case SimpleDelay:
+				s += "d"
+			default:
+				s += "+"
+			}
+			s += formatSeconds(stage.Increment)
+		}
+		fields[i] = s
+	}
+	return strings.Join(fields, ":")
+}
+
+// stage returns the stage of a color's nth move, counting from one,
+// and true if the move is the last of the stage.
+func (tc TimeControl) stage(n int) (TimeControlStage, bool) {
+	for i, s := range tc {
+		if s.Moves == 0 || n <= s.Moves || i == len(tc)-1 {
+			if s.Moves > 0 {
+				n = (n-1)%s.Moves + 1
+			}
+			return s, s.Moves > 0 && n == s.Moves
+		}
+		n -= s.Moves
+	}
+	return TimeControlStage{}, false
+}
+
+// WithTimeControl returns a function that sets the game's time control
+// and its TimeControl tag.
+func WithTimeControl(tc TimeControl) func(*Game) {
+	return func(g *Game) {
+		g.timeControl = tc
+		g.AddTagPair("TimeControl", tc.String())
+	}
+}
+
+// TimeControl returns the game's time control or nil if it has none.
+func (g *Game) TimeControl() TimeControl {
+	return g.timeControl
+}
+
+// TimeControlError returns the error reading the game's TimeControl
+// tag or nil.  Games decoded from a PGN have no time control if the
+// tag's value can't be read.
+func (g *Game) TimeControlError() error {
+	tp := g.GetTagPair("TimeControl")
+	if tp == nil {
+		return nil
+	}
+	_, err := ParseTimeControl(tp.Value)
+	return err
+}
+
+// Clock returns the time left on the color's clock, read from the
+// [%clk] command of its last move in the main line or, before any, the
+// time of the first stage.  On a Sandclock it is the sand the opponent's
+// last move left in the other half.
+func (g *Game) Clock(c Color) time.Duration {
+	sandclock := len(g.timeControl) > 0 && g.timeControl[0].Mode == Sandclock
+	for i := len(g.nodes) - 1; i > 0; i-- {
+		n := g.nodes[i]
+		if n.parent.pos.turn != c && !sandclock {
+			continue
+		}
+		d, ok := n.Clock()
+		if !ok {
+			break
+		}
+		if n.parent.pos.turn != c {
+			d = g.timeControl[0].Time - d
+		}
+		return d
+	}
+	if len(g.timeControl) == 0 {
+		return 0
+	}
+	return g.timeControl[0].Time
+}
+
+// TimedMove plays the move, which used the given time, and updates the
+// clock of the side to move by the game's time control.  The clock and
+// the time used are kept in the move's [%clk] and [%emt] commands.  If
+// the time used runs out the clock the move isn't played, the game ends
+// by Timeout and an error is returned.
+func (g *Game) TimedMove(m *Move, used time.Duration) error {
+	if len(g.timeControl) == 0 {
+		return fmt.Errorf("chess: game has no time control")
+	}
+	tail := g.nodes[len(g.nodes)-1]
+	if tail.pos.validMove(m) == nil {
+		return fmt.Errorf("chess: invalid move %s", m)
+	}
+	c := g.pos.turn
+	moves := 1
+	for _, n := range g.nodes[1:] {
+		if n.parent.pos.turn == c {
+			moves++
+		}
+	}
+	stage, last := g.timeControl.stage(moves)
+	clock, charged := g.Clock(c), used
+	if stage.Mode == SimpleDelay {
+		charged = max(used-stage.Increment, 0)
+	}
+	if charged >= clock {
+		g.Timeout(c)
+		return fmt.Errorf("chess: %s ran out of time", c.Name())
+	}
+	clock -= charged
+	switch stage.Mode {
+	case FischerIncrement:
+		clock += stage.Increment
+	case BronsteinDelay:
+		clock += min(used, stage.Increment)
+	}
+	if last {
+		next, _ := g.timeControl.stage(moves + 1)
+		clock += next.Time
+	}
+	n, err := g.addMove(tail, m)
+	if err != nil {
+		return err
+	}
+	n.SetClock(clock)
+	n.SetMoveTime(used)
+	return nil
+}
+
+// TimedMoveStr decodes the move in the game's notation and calls
+// TimedMove.
+func (g *Game) TimedMoveStr(s string, used time.Duration) error {
+	m, err := g.notation.Decode(g.pos, s)
+	if err != nil {
+		return err
+	}
+	return g.TimedMove(m, used)
+}
+
+// Timeout ends the game for the color running out of time.  The
+// opponent wins if it could still checkmate and otherwise the game is
+// drawn.  If the game has already been completed then the game is not
+// updated.
+func (g *Game) Timeout(color Color) {
+	if g.outcome != NoOutcome || color == NoColor {
+		return
+	}
+	switch {
+	case !g.pos.board.HasMatingMaterial(color.Other()):
+		g.outcome = Draw
+	case color == White:
+		g.outcome = BlackWon
+	default:
+		g.outcome = WhiteWon
+	}
+	g.method = Timeout
+}
+
+// MoveTime returns the time the move used from its [%emt] command and
+// true or false if the move has none.
+func (n *MoveNode) MoveTime() (time.Duration, bool) {
+	v, ok := n.Command("emt")
+	if !ok {
+		return 0, false
+	}
+	d, err := parseClock(v)
+	if err != nil {
+		return 0, false
+	}
+	return d, true
+}
+
+// SetMoveTime sets the move's [%emt] command.  Ex. [%emt 0:00:12]
+func (n *MoveNode) SetMoveTime(d time.Duration) {
+	n.SetCommand("emt", formatClock(d))
+}
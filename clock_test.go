@@ -0,0 +1,209 @@
+package chess
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTimeControl(t *testing.T) {
+	tests := []struct {
+		s  string
+		tc TimeControl
+	}{
+		{"300", TimeControl{{Time: 5 * time.Minute}}},
+		{"180+2", TimeControl{{Time: 3 * time.Minute, Increment: 2 * time.Second}}},
+		{"300d5", TimeControl{{Time: 5 * time.Minute, Increment: 5 * time.Second, Mode: SimpleDelay}}},
+		{"300b5", TimeControl{{Time: 5 * time.Minute, Increment: 5 * time.Second, Mode: BronsteinDelay}}},
+		{"40/5400+30:1800+30", TimeControl{
+			{Moves: 40, Time: 90 * time.Minute, Increment: 30 * time.Second},
+			{Time: 30 * time.Minute, Increment: 30 * time.Second},
+		}},
+		{"40/7200:20/3600:900+30", TimeControl{
+			{Moves: 40, Time: 2 * time.Hour},
+			{Moves: 20, Time: time.Hour},
+			{Time: 15 * time.Minute, Increment: 30 * time.Second},
+		}},
+		{"0.5+0.1", TimeControl{{Time: 500 * time.Millisecond, Increment: 100 * time.Millisecond}}},
+		{"*60", TimeControl{{Time: time.Minute, Mode: Sandclock}}},
+	}
+	for _, test := range tests {
+		tc, err := ParseTimeControl(test.s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tc) != len(test.tc) {
+			t.Fatalf("expected %s to have %d stages but got %d", test.s, len(test.tc), len(tc))
+		}
+		for i := range tc {
+			if tc[i] != test.tc[i] {
+				t.Fatalf("expected %s stage %d to be %+v but got %+v", test.s, i, test.tc[i], tc[i])
+			}
+		}
+		if tc.String() != test.s {
+			t.Fatalf("expected %s but got %s", test.s, tc.String())
+		}
+	}
+	if tc, err := ParseTimeControl("-"); tc != nil || err != nil {
+		t.Fatalf("expected no time control but got %v %v", tc, err)
+	}
+	if s := (TimeControl{{Time: time.Minute, Increment: time.Second, Mode: ClockMode(9)}}).String(); s != "60+1" {
+		t.Fatalf("expected an unknown mode to be written as an increment but got %s", s)
+	}
+	if tc, err := ParseTimeControl("?"); tc != nil || err != ErrUnknownTimeControl {
+		t.Fatalf("expected an unknown time control but got %v %v", tc, err)
+	}
+	for _, s := range []string{"", "0/300", "40/", "300+", "*", "60:*60", "5 min", "NaN", "Inf", "300+NaN", "*Inf", "1e300"} {
+		if _, err := ParseTimeControl(s); err == nil {
+			t.Fatalf("expected %q to be invalid", s)
+		}
+	}
+}
+
+func timedMoves(t *testing.T, g *Game, moves []string, used time.Duration) {
+	t.Helper()
+	for _, m := range moves {
+		if err := g.TimedMoveStr(m, used); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestTimedMove(t *testing.T) {
+	tests := []struct {
+		tc           string
+		used         time.Duration
+		white, black time.Duration
+	}{
+		{"180+2", 5 * time.Second, 171 * time.Second, 171 * time.Second},
+		{"180b5", 3 * time.Second, 180 * time.Second, 180 * time.Second},
+		{"180b5", 8 * time.Second, 171 * time.Second, 171 * time.Second},
+		{"180d5", 3 * time.Second, 180 * time.Second, 180 * time.Second},
+		{"180d5", 8 * time.Second, 171 * time.Second, 171 * time.Second},
+		// the second stage's minute is added after the second move
+		{"2/60:60", 10 * time.Second, 90 * time.Second, 90 * time.Second},
+		// the last stage repeats
+		{"1/10", 5 * time.Second, 25 * time.Second, 25 * time.Second},
+	}
+	for _, test := range tests {
+		tc, err := ParseTimeControl(test.tc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g := NewGame(WithTimeControl(tc))
+		timedMoves(t, g, []string{"e4", "e5", "Nf3", "Nc6", "Bc4", "Bc5"}, test.used)
+		if g.Clock(White) != test.white || g.Clock(Black) != test.black {
+			t.Fatalf("%s: expected clocks %s and %s but got %s and %s", test.tc, test.white, test.black, g.Clock(White), g.Clock(Black))
+		}
+		if d, ok := g.Node(6).MoveTime(); !ok || d != test.used {
+			t.Fatalf("%s: expected the move time %s but got %s", test.tc, test.used, d)
+		}
+	}
+	if err := NewGame().TimedMoveStr("e4", time.Second); err == nil {
+		t.Fatal("expected an error without a time control")
+	}
+}
+
+func TestTimedMoveSandclock(t *testing.T) {
+	g := NewGame(WithTimeControl(TimeControl{{Time: time.Minute, Mode: Sandclock}}))
+	timedMoves(t, g, []string{"e4"}, 10*time.Second)
+	timedMoves(t, g, []string{"e5"}, 4*time.Second)
+	if g.Clock(White) != 54*time.Second || g.Clock(Black) != 6*time.Second {
+		t.Fatalf("expected clocks 54s and 6s but got %s and %s", g.Clock(White), g.Clock(Black))
+	}
+	timedMoves(t, g, []string{"Nf3"}, 50*time.Second)
+	if err := g.TimedMoveStr("Nc6", 56*time.Second); err == nil || g.Method() != Timeout {
+		t.Fatalf("expected the flag to fall with %s left", g.Clock(Black))
+	}
+}
+
+func TestTimedMoveFlagFall(t *testing.T) {
+	g := NewGame(WithTimeControl(TimeControl{{Time: time.Minute}}))
+	timedMoves(t, g, []string{"e4", "e5"}, 30*time.Second)
+	if err := g.TimedMoveStr("Nf3", 30*time.Second); err == nil {
+		t.Fatal("expected the flag to fall")
+	}
+	if len(g.Moves()) != 2 || g.Outcome() != BlackWon || g.Method() != Timeout {
+		t.Fatalf("expected black to win on time but got %s by %s after %d moves", g.Outcome(), g.Method(), len(g.Moves()))
+	}
+
+	fen, err := FEN("4k3/8/8/8/8/8/8/4KQ2 b - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g = NewGame(fen, WithTimeControl(TimeControl{{Time: time.Minute}}))
+	if err := g.TimedMoveStr("Kd7", time.Minute); err == nil {
+		t.Fatal("expected the flag to fall")
+	}
+	if g.Outcome() != WhiteWon || g.Method() != Timeout {
+		t.Fatalf("expected white to win on time but got %s by %s", g.Outcome(), g.Method())
+	}
+	g = NewGame(fen)
+	g.Timeout(White)
+	if g.Outcome() != Draw || g.Method() != Timeout {
+		t.Fatalf("expected a draw as black can't mate but got %s by %s", g.Outcome(), g.Method())
+	}
+}
+
+func TestTimedMovePGN(t *testing.T) {
+	tc, err := ParseTimeControl("180+2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGame(WithTimeControl(tc))
+	timedMoves(t, g, []string{"e4", "e5"}, 3500*time.Millisecond)
+	s := g.String()
+	expected := `[TimeControl "180+2"]
+
+1. e4 {[%clk 0:02:58.5] [%emt 0:00:03.5]} 1... e5 {[%clk 0:02:58.5] [%emt 0:00:03.5]} *`
+	if s != expected {
+		t.Fatalf("expected\n%s\nbut got\n%s", expected, s)
+	}
+	cp, err := decodePGN(nil, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp.TimeControl().String() != "180+2" || cp.Clock(White) != g.Clock(White) {
+		t.Fatalf("expected the time control and clocks to be read but got %s and %s", cp.TimeControl(), cp.Clock(White))
+	}
+	timedMoves(t, cp, []string{"Nf3"}, 10*time.Second)
+	if cp.Clock(White) != 170500*time.Millisecond {
+		t.Fatalf("expected 2:50.5 left but got %s", cp.Clock(White))
+	}
+	if err := cp.UndoMove(); err != nil {
+		t.Fatal(err)
+	}
+	if cp.Clock(White) != 178500*time.Millisecond {
+		t.Fatalf("expected the clock to be restored but got %s", cp.Clock(White))
+	}
+	if !strings.Contains(cp.Clone().String(), "[%emt") {
+		t.Fatal("expected the clone to keep the move times")
+	}
+}
+
+func TestTimeControlTag(t *testing.T) {
+	g, err := decodePGN(nil, "[TimeControl \"?\"]\n\n1. e4 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.TimeControl() != nil || g.GetTagPair("TimeControl").Value != "?" || g.TimeControlError() != ErrUnknownTimeControl {
+		t.Fatalf("expected an unknown time control but got %s", g.TimeControl())
+	}
+	g, err = decodePGN(nil, "[TimeControl \"*180\"]\n\n1. e4 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.TimeControl().String() != "*180" {
+		t.Fatalf("expected a sandclock but got %s", g.TimeControl())
+	}
+	if g.TimeControlError() != nil {
+		t.Fatal(g.TimeControlError())
+	}
+	g, err = decodePGN(nil, "[TimeControl \"NaN\"]\n\n1. e4 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.TimeControl() != nil || g.GetTagPair("TimeControl").Value != "NaN" || g.TimeControlError() == nil {
+		t.Fatalf("expected the invalid time control to be left out but got %s", g.TimeControl())
+	}
+}
@@ -0,0 +1,327 @@
+package chess
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A MarkColor is the color of a highlighted square or arrow drawn in
+// a [%csl] or [%cal] comment command.
+type MarkColor byte
+
+const (
+	// MarkRed is drawn in red.
+	MarkRed MarkColor = 'R'
+	// MarkGreen is drawn in green.
+	MarkGreen MarkColor = 'G'
+	// MarkYellow is drawn in yellow.
+	MarkYellow MarkColor = 'Y'
+	// MarkBlue is drawn in blue.
+	MarkBlue MarkColor = 'B'
+)
+
+// String implements the fmt.Stringer interface.
+func (c MarkColor) String() string {
+	return string(c)
+}
+
+// A SquareMark is a highlighted square.
+type SquareMark struct {
+	Square Square
+	Color  MarkColor
+}
+
+// An Arrow is an arrow drawn from one square to another.
+type Arrow struct {
+	From  Square
+	To    Square
+	Color MarkColor
+}
+
+// An Eval is an engine evaluation from White's point of view.
+type Eval struct {
+	// Centipawns is the score in hundredths of a pawn.
+	Centipawns int
+	// Mate is the number of moves to mate, negative if Black mates.
+	// Zero is a mate score only if IsMate is set.
+	Mate int
+	// IsMate is set if the score is a mate, including a mate already
+	// delivered, #0.  Scores with a non zero Mate are mates too.
+	IsMate bool
+	// Depth is the search depth or zero if not given.
+	Depth int
+}
+
+// String implements the fmt.Stringer interface and returns the
+// value of the [%eval] command.  Ex. 0.24, #-3, #0 or 1.05,22
+func (e Eval) String() string {
+	s := fmt.Sprintf("%.2f", float64(e.Centipawns)/100)
+	if e.IsMate || e.Mate != 0 {
+		s = fmt.Sprintf("#%d", e.Mate)
+	}
+	if e.Depth > 0 {
+		s += fmt.Sprintf(",%d", e.Depth)
+	}
+	return s
+}
+
+var commandRe = regexp.MustCompile(`\[%(\w+)\s*([^\]]*)\]`)
+
+// Command returns the value of the comment command with the name, as
+// in [%name value], and true or false if the move has none.
+func (n *MoveNode) Command(name string) (string, bool) {
+	for _, c := range n.comments {
+		for _, m := range commandRe.FindAllStringSubmatch(c, -1) {
+			if m[1] == name {
+				return strings.TrimSpace(m[2]), true
+			}
+		}
+	}
+	return "", false
+}
+
+// SetCommand sets the value of the comment command with the name.  An
+// existing command is replaced, otherwise the command is added to the
+// comment holding the move's other commands or to a new comment.
+func (n *MoveNode) SetCommand(name, value string) {
+	cmd := "[%" + name + " " + value + "]"
+	comments := n.Comments()
+	for i, c := range comments {
+		for _, loc := range commandRe.FindAllStringSubmatchIndex(c, -1) {
+			if c[loc[2]:loc[3]] == name {
+				comments[i] = c[:loc[0]] + cmd + c[loc[1]:]
+				n.comments = comments
+				return
+			}
+		}
+	}
+	for i, c := range comments {
+		if commandRe.MatchString(c) {
+			comments[i] = c + " " + cmd
+			n.comments = comments
+			return
+		}
+	}
+	n.comments = append(comments, cmd)
+}
+
+// RemoveCommand removes the comment command with the name.  Comments
+// left empty are removed as well.
+func (n *MoveNode) RemoveCommand(name string) {
+	comments := []string{}
+	for _, c := range n.comments {
+		cp := commandRe.ReplaceAllStringFunc(c, func(cmd string) string {
+			if commandRe.FindStringSubmatch(cmd)[1] == name {
+				return ""
+			}
+			return cmd
+		})
+		if cp != c {
+			cp = strings.Join(strings.Fields(cp), " ")
+			if cp == "" {
+				continue
+			}
+		}
+		comments = append(comments, cp)
+	}
+	n.comments = comments
+}
+
+// Clock returns the clock time left after the move from its [%clk]
+// command and true or false if the move has none.
+func (n *MoveNode) Clock() (time.Duration, bool) {
+	v, ok := n.Command("clk")
+	if !ok {
+		return 0, false
+	}
+	d, err := parseClock(v)
+	if err != nil {
+		return 0, false
+	}
+	return d, true
+}
+
+// SetClock sets the move's [%clk] command.  Ex. [%clk 1:05:00]
+func (n *MoveNode) SetClock(d time.Duration) {
+	n.SetCommand("clk", formatClock(d))
+}
+
+// Eval returns the evaluation after the move from its [%eval] command
+// and true or false if the move has none.
+func (n *MoveNode) Eval() (Eval, bool) {
+	v, ok := n.Command("eval")
+	if !ok {
+		return Eval{}, false
+	}
+	e, err := parseEval(v)
+	if err != nil {
+		return Eval{}, false
+	}
+	return e, true
+}
+
+// SetEval sets the move's [%eval] command.  Ex. [%eval #-3]
+func (n *MoveNode) SetEval(e Eval) {
+	n.SetCommand("eval", e.String())
+}
+
+// SquareMarks returns the squares highlighted by the move's [%csl]
+// commands.
+func (n *MoveNode) SquareMarks() []SquareMark {
+	var marks []SquareMark
+	for _, tok := range n.commandList("csl") {
+		if len(tok) != 3 {
+			continue
+		}
+		sq, ok := strToSquareMap[tok[1:]]
+		if !ok {
+			continue
+		}
+		marks = append(marks, SquareMark{Square: sq, Color: MarkColor(tok[0])})
+	}
+	return marks
+}
+
+// SetSquareMarks sets the move's [%csl] command or removes it if there
+// are no marks.  Ex. [%csl Ge4,Rd5]
+func (n *MoveNode) SetSquareMarks(marks []SquareMark) {
+	toks := make([]string, len(marks))
+	for i, m := range marks {
+		toks[i] = m.Color.String() + m.Square.String()
+	}
+	n.setCommandList("csl", toks)
+}
+
+// Arrows returns the arrows drawn by the move's [%cal] commands.
+func (n *MoveNode) Arrows() []Arrow {
+	var arrows []Arrow
+	for _, tok := range n.commandList("cal") {
+		if len(tok) != 5 {
+			continue
+		}
+		from, ok1 := strToSquareMap[tok[1:3]]
+		to, ok2 := strToSquareMap[tok[3:]]
+		if !ok1 || !ok2 {
+			continue
+		}
+		arrows = append(arrows, Arrow{From: from, To: to, Color: MarkColor(tok[0])})
+	}
+	return arrows
+}
+
+// SetArrows sets the move's [%cal] command or removes it if there are
+// no arrows.  Ex. [%cal Ge2e4,Rd1h5]
+func (n *MoveNode) SetArrows(arrows []Arrow) {
+	toks := make([]string, len(arrows))
+	for i, a := range arrows {
+		toks[i] = a.Color.String() + a.From.String() + a.To.String()
+	}
+	n.setCommandList("cal", toks)
+}
+
+// commandList returns the comma separated values of every command
+// with the name.
+func (n *MoveNode) commandList(name string) []string {
+	var toks []string
+	for _, c := range n.comments {
+		for _, m := range commandRe.FindAllStringSubmatch(c, -1) {
+			if m[1] != name {
+				continue
+			}
+			for _, tok := range strings.Split(m[2], ",") {
+				if tok = strings.TrimSpace(tok); tok != "" {
+					toks = append(toks, tok)
+				}
+			}
+		}
+	}
+	return toks
+}
+
+func (n *MoveNode) setCommandList(name string, toks []string) {
+	n.RemoveCommand(name)
+	if len(toks) > 0 {
+		n.SetCommand(name, strings.Join(toks, ","))
+	}
+}
+
+// parseClock parses a [%clk] value.  Ex. 1:05:00 or 0:00:04.2
+func parseClock(s string) (time.Duration, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("chess: invalid clock %q", s)
+	}
+	var d time.Duration
+	for i, p := range parts {
+		unit := time.Second
+		switch len(parts) - 1 - i {
+		case 1:
+			unit = time.Minute
+		case 2:
+			unit = time.Hour
+		}
+		if i < len(parts)-1 {
+			v, err := strconv.Atoi(p)
+			if err != nil || v < 0 {
+				return 0, fmt.Errorf("chess: invalid clock %q", s)
+			}
+			d += time.Duration(v) * unit
+			continue
+		}
+		v, err := strconv.ParseFloat(p, 64)
+		if err != nil || v < 0 {
+			return 0, fmt.Errorf("chess: invalid clock %q", s)
+		}
+		d += time.Duration(math.Round(v * float64(time.Second)))
+	}
+	return d, nil
+}
+
+// formatClock returns the [%clk] value of the duration.  Fractions
+// of a second are only written when present.
+func formatClock(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	d = d.Round(time.Millisecond)
+	h := d / time.Hour
+	m := (d % time.Hour) / time.Minute
+	sec := (d % time.Minute) / time.Second
+	s := fmt.Sprintf("%d:%02d:%02d", h, m, sec)
+	if ms := d % time.Second / time.Millisecond; ms > 0 {
+		s += strings.TrimRight(fmt.Sprintf(".%03d", ms), "0")
+	}
+	return s
+}
+
+// parseEval parses an [%eval] value.  Ex. 0.24, #-3 or 1.05,22
+func parseEval(s string) (Eval, error) {
+	var e Eval
+	score, depth, found := strings.Cut(s, ",")
+	if found {
+		d, err := strconv.Atoi(strings.TrimSpace(depth))
+		if err != nil {
+			return e, fmt.Errorf("chess: invalid eval %q", s)
+		}
+		e.Depth = d
+	}
+	score = strings.TrimSpace(score)
+	if strings.HasPrefix(score, "#") {
+		m, err := strconv.Atoi(score[1:])
+		if err != nil {
+			return e, fmt.Errorf("chess: invalid eval %q", s)
+		}
+		e.Mate, e.IsMate = m, true
+		return e, nil
+	}
+	v, err := strconv.ParseFloat(score, 64)
+	if err != nil {
+		return e, fmt.Errorf("chess: invalid eval %q", s)
+	}
+	e.Centipawns = int(math.Round(v * 100))
+	return e, nil
+}
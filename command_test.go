@@ -0,0 +1,142 @@
+package chess
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommandsDecode(t *testing.T) {
+	pgn, err := mustParsePGN()("fixtures/pgns/0005.pgn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	game, err := decodePGN(nil, pgn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 4... Bg7?! { ... } { [%eval 0.39] [%clk 0:05:05] }
+	n := game.Node(8)
+	if d, ok := n.Clock(); !ok || d != 5*time.Minute+5*time.Second {
+		t.Fatalf("expected clock 5m5s but got %s", d)
+	}
+	if e, ok := n.Eval(); !ok || e != (Eval{Centipawns: 39}) {
+		t.Fatalf("expected eval 39 centipawns but got %+v", e)
+	}
+	// 20... f6? { ... } { [%eval #1] [%clk 0:02:31] }
+	if e, ok := game.Node(40).Eval(); !ok || e.Mate != 1 {
+		t.Fatalf("expected mate in 1 but got %+v", e)
+	}
+	// 21. g4# { [%clk 0:00:46] }
+	if _, ok := game.Node(41).Eval(); ok {
+		t.Fatal("expected no eval for the last move")
+	}
+}
+
+func TestCommandValues(t *testing.T) {
+	clocks := map[string]time.Duration{
+		"0:05:00":   5 * time.Minute,
+		"1:30:15":   time.Hour + 30*time.Minute + 15*time.Second,
+		"0:00:04.2": 4200 * time.Millisecond,
+		"12:03":     12*time.Minute + 3*time.Second,
+	}
+	for s, d := range clocks {
+		got, err := parseClock(s)
+		if err != nil || got != d {
+			t.Fatalf("expected clock %s to be %s but got %s %v", s, d, got, err)
+		}
+	}
+	if s := formatClock(4200 * time.Millisecond); s != "0:00:04.2" {
+		t.Fatalf("expected 0:00:04.2 but got %s", s)
+	}
+	evals := map[string]Eval{
+		"0.24":    {Centipawns: 24},
+		"-4.17":   {Centipawns: -417},
+		"#-3":     {Mate: -3, IsMate: true},
+		"#0":      {IsMate: true},
+		"#0,12":   {IsMate: true, Depth: 12},
+		"1.05,22": {Centipawns: 105, Depth: 22},
+	}
+	for s, e := range evals {
+		got, err := parseEval(s)
+		if err != nil || got != e {
+			t.Fatalf("expected eval %s to be %+v but got %+v %v", s, e, got, err)
+		}
+	}
+	if s := (Eval{Centipawns: -417}).String(); s != "-4.17" {
+		t.Fatalf("expected -4.17 but got %s", s)
+	}
+	for s, e := range evals {
+		if e.String() != s {
+			t.Fatalf("expected %+v to be written as %s but got %s", e, s, e.String())
+		}
+	}
+	if s := (Eval{Mate: 2}).String(); s != "#2" {
+		t.Fatalf("expected #2 but got %s", s)
+	}
+	for _, s := range []string{"x", "1:2:3:4", "0:-1:00"} {
+		if _, err := parseClock(s); err == nil {
+			t.Fatalf("expected clock error for %s", s)
+		}
+	}
+	for _, s := range []string{"x", "#x", "0.5,x"} {
+		if _, err := parseEval(s); err == nil {
+			t.Fatalf("expected eval error for %s", s)
+		}
+	}
+}
+
+func TestMarks(t *testing.T) {
+	game, err := decodePGN(nil, "1. e4 { good [%csl Ge4,Rd5] [%cal Ge2e4,Bd1h5] } e5 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := game.Node(1)
+	marks := []SquareMark{{Square: E4, Color: MarkGreen}, {Square: D5, Color: MarkRed}}
+	if got := n.SquareMarks(); !reflect.DeepEqual(got, marks) {
+		t.Fatalf("expected marks %v but got %v", marks, got)
+	}
+	arrows := []Arrow{{From: E2, To: E4, Color: MarkGreen}, {From: D1, To: H5, Color: MarkBlue}}
+	if got := n.Arrows(); !reflect.DeepEqual(got, arrows) {
+		t.Fatalf("expected arrows %v but got %v", arrows, got)
+	}
+	n.SetArrows(arrows[:1])
+	n.SetSquareMarks(nil)
+	if got := n.Comments(); len(got) != 1 || got[0] != "good [%cal Ge2e4]" {
+		t.Fatalf("unexpected comments %q", got)
+	}
+}
+
+func TestSetCommands(t *testing.T) {
+	game, err := decodePGN(nil, "1. e4 { best by test } e5 { [%clk 0:05:00] } *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := game.Node(1)
+	n.SetClock(4*time.Minute + 58*time.Second)
+	n.SetEval(Eval{Centipawns: 30})
+	if got := n.Comments(); len(got) != 2 || got[1] != "[%clk 0:04:58] [%eval 0.30]" {
+		t.Fatalf("unexpected comments %q", got)
+	}
+	black := game.Node(2)
+	black.SetClock(time.Minute)
+	if got := black.Comments(); len(got) != 1 || got[0] != "[%clk 0:01:00]" {
+		t.Fatalf("unexpected comments %q", got)
+	}
+	s := game.String()
+	if !strings.Contains(s, "1. e4 {best by test} {[%clk 0:04:58] [%eval 0.30]} 1... e5 {[%clk 0:01:00]}") {
+		t.Fatalf("unexpected pgn %s", s)
+	}
+	cp, err := decodePGN(nil, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d, ok := cp.Node(2).Clock(); !ok || d != time.Minute {
+		t.Fatalf("expected clock to round trip but got %s", d)
+	}
+	black.RemoveCommand("clk")
+	if len(black.Comments()) != 0 {
+		t.Fatalf("expected empty comment to be removed but got %q", black.Comments())
+	}
+}
@@ -0,0 +1,265 @@
+package chess
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxPocketCount bounds the number of pieces of a type in a pocket.
+// Crazyhouse FENs holding more than a board's worth of pieces are
+// rejected so the count never exceeds it.
+const maxPocketCount = numOfSquaresInBoard
+
+// A Pocket holds the pieces a Crazyhouse player has captured and
+// may drop back on the board.
+type Pocket struct {
+	counts [7]uint8
+}
+
+// Count returns the number of pieces of the type in the pocket.
+func (p Pocket) Count(pt PieceType) int {
+	if pt < NoPieceType || pt > Pawn {
+		return 0
+	}
+	return int(p.counts[pt])
+}
+
+// String implements the fmt.Stringer interface and returns the
+// pocket's pieces in FEN order.  Ex. QNPP
+func (p Pocket) String() string {
+	sb := strings.Builder{}
+	for pt := Queen; pt <= Pawn; pt++ {
+		sb.WriteString(strings.Repeat(strings.ToUpper(pt.String()), int(p.counts[pt])))
+	}
+	return sb.String()
+}
+
+// Pocket returns the pocket of the given color.  Pockets are empty
+// unless the position is a Crazyhouse position.
+func (pos *Position) Pocket(c Color) Pocket {
+	if c != White && c != Black {
+		return Pocket{}
+	}
+	return pos.pockets[c]
+}
+
+// addToPocket adds n pieces of the type to the pocket of color c and
+// returns the change of the Zobrist key.
+func (pos *Position) addToPocket(c Color, pt PieceType, n int) uint64 {
+	old := pos.pockets[c].counts[pt]
+	pos.pockets[c].counts[pt] = uint8(int(old) + n)
+	return zobristPocket[c][pt][old] ^ zobristPocket[c][pt][pos.pockets[c].counts[pt]]
+}
+
+// pocketCapture moves the piece captured by m, a board move by color
+// c, into c's pocket and keeps track of the promoted pieces.  Captured
+// promoted pieces go back to the pocket as pawns.  The change of the
+// Zobrist key, for the pocket and the promoted pieces, is returned.
+func (pos *Position) pocketCapture(m *Move, c Color, captured Piece) uint64 {
+	var h uint64
+	s1BB, s2BB := bbForSquare(m.s1), bbForSquare(m.s2)
+	if captured != NoPiece {
+		pt := captured.Type()
+		if pos.promoted&s2BB != 0 {
+			pt = Pawn
+		}
+		h = pos.addToPocket(c, pt, 1)
+	}
+	before := pos.promoted
+	moved := pos.promoted&s1BB != 0 || m.promo != NoPieceType
+	pos.promoted &^= s1BB | s2BB
+	if moved {
+		pos.promoted |= s2BB
+	}
+	return h ^ zobristPromotedSquares(before^pos.promoted)
+}
+
+// dropString returns the drop in UCI and SAN notation without
+// check marks.  Ex. N@f3
+func dropString(m *Move) string {
+	return strings.ToUpper(m.drop.String()) + "@" + m.s2.String()
+}
+
+// dropPieceType returns the piece type of a drop's letter which may
+// be either case.
+func dropPieceType(c byte) PieceType {
+	switch c {
+	case 'P', 'p':
+		return Pawn
+	case 'N', 'n':
+		return Knight
+	case 'B', 'b':
+		return Bishop
+	case 'R', 'r':
+		return Rook
+	case 'Q', 'q':
+		return Queen
+	}
+	return NoPieceType
+}
+
+// CrazyhouseVariant is Crazyhouse: captured pieces change sides and
+// go to the capturer's pocket from which they can be dropped on any
+// empty square instead of moving.  Pawns can't be dropped on the
+// first or last rank and promoted pieces go back to the pocket as
+// pawns when captured.
+//
+// Crazyhouse FENs hold the pockets in brackets after the board and
+// mark promoted pieces with a tilde.  Ex.
+// r1bqk2r/pppp1ppp/2n5/4p3/1bB1P3/5Q2/PPPP1PPP/RNB1K1NR[Nn] w KQkq - 0 6
+type CrazyhouseVariant struct{}
+
+// String implements the Variant interface.
+func (CrazyhouseVariant) String() string {
+	return "Crazyhouse"
+}
+
+// StartingPosition implements the Variant interface.
+func (CrazyhouseVariant) StartingPosition() *Position {
+	pos := StartingPosition()
+	pos.setVariant(CrazyhouseVariant{})
+	return pos
+}
+
+// DecodeFEN implements the Variant interface.  Besides the bracket
+// form, pockets given as a ninth rank are accepted.  Ex.
+// rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR/Nn w KQkq - 0 1
+func (CrazyhouseVariant) DecodeFEN(fen string) (*Position, error) {
+	fen = strings.TrimSpace(fen)
+	parts := strings.SplitN(fen, " ", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("chess: fen invalid notation %s must have 6 sections", fen)
+	}
+	board, pocket := parts[0], ""
+	if i := strings.IndexByte(board, '['); i >= 0 {
+		if !strings.HasSuffix(board, "]") {
+			return nil, fmt.Errorf("chess: fen invalid pocket %s", board[i:])
+		}
+		board, pocket = board[:i], board[i+1:len(board)-1]
+	} else if strings.Count(board, "/") == 8 {
+		i := strings.LastIndexByte(board, '/')
+		board, pocket = board[:i], board[i+1:]
+	}
+	if pocket == "-" {
+		pocket = ""
+	}
+	board, promoted, err := fenPromotedPieces(board)
+	if err != nil {
+		return nil, err
+	}
+	pos, err := decodeFEN(board + " " + parts[1])
+	if err != nil {
+		return nil, err
+	}
+	total := (^pos.board.emptySqs).count()
+	for i := 0; i < len(pocket); i++ {
+		pt := dropPieceType(pocket[i])
+		c := White
+		if pocket[i] >= 'a' {
+			c = Black
+		}
+		if pt == NoPieceType {
+			return nil, fmt.Errorf("chess: fen invalid pocket %s", pocket)
+		}
+		if total++; total > maxPocketCount {
+			return nil, fmt.Errorf("chess: fen pocket %s holds too many pieces", pocket)
+		}
+		pos.pockets[c].counts[pt]++
+	}
+	kings := pos.board.bbWhiteKing | pos.board.bbBlackKing
+	pawns := pos.board.bbWhitePawn | pos.board.bbBlackPawn
+	pos.promoted = promoted & ^pos.board.emptySqs &^ kings &^ pawns
+	pos.setVariant(CrazyhouseVariant{})
+	return pos, nil
+}
+
+// fenPromotedPieces strips the tildes marking promoted pieces from
+// the FEN board and returns their squares.
+func fenPromotedPieces(board string) (string, Bitboard, error) {
+	if !strings.Contains(board, "~") {
+		return board, 0, nil
+	}
+	var promoted Bitboard
+	sb := strings.Builder{}
+	rank, file := Rank8, 0
+	for _, r := range board {
+		switch {
+		case r == '~':
+			if file == 0 || file > numOfSquaresInRow {
+				return "", 0, fmt.Errorf("chess: fen invalid board %s", board)
+			}
+			promoted |= bbForSquare(NewSquare(File(file-1), rank))
+			continue
+		case r == '/':
+			if rank--; rank < Rank1 {
+				return "", 0, fmt.Errorf("chess: fen invalid board %s", board)
+			}
+			file = 0
+		case r >= '1' && r <= '8':
+			file += int(r - '0')
+		default:
+			file++
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String(), promoted, nil
+}
+
+// EncodeFEN implements the Variant interface.
+func (CrazyhouseVariant) EncodeFEN(pos *Position) string {
+	fen := pos.fen()
+	board := pos.board.String()
+	if pos.promoted != 0 {
+		board = pos.promotedBoardString()
+	}
+	pocket := pos.pockets[White].String() + strings.ToLower(pos.pockets[Black].String())
+	return board + "[" + pocket + "]" + fen[strings.IndexByte(fen, ' '):]
+}
+
+// promotedBoardString returns the FEN board with a tilde after the
+// promoted pieces.
+func (pos *Position) promotedBoardString() string {
+	sb := strings.Builder{}
+	for r := 7; r >= 0; r-- {
+		empty := 0
+		for f := 0; f < numOfSquaresInRow; f++ {
+			sq := NewSquare(File(f), Rank(r))
+			p := pos.board.Piece(sq)
+			if p == NoPiece {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				fmt.Fprintf(&sb, "%d", empty)
+				empty = 0
+			}
+			sb.WriteString(p.getFENChar())
+			if pos.promoted&bbForSquare(sq) != 0 {
+				sb.WriteByte('~')
+			}
+		}
+		if empty > 0 {
+			fmt.Fprintf(&sb, "%d", empty)
+		}
+		if r != 0 {
+			sb.WriteByte('/')
+		}
+	}
+	return sb.String()
+}
+
+// ValidMoves implements the Variant interface.
+func (CrazyhouseVariant) ValidMoves(pos *Position) []*Move {
+	return engine{}.CalcMoves(pos, false)
+}
+
+// Status implements the Variant interface.  Crazyhouse games can't
+// end by insufficient material as captured pieces come back.
+func (CrazyhouseVariant) Status(pos *Position) Method {
+	return engine{}.Status(pos)
+}
+
+// Outcome implements the Variant interface.
+func (CrazyhouseVariant) Outcome(pos *Position, method Method) Outcome {
+	return StandardVariant{}.Outcome(pos, method)
+}
@@ -0,0 +1,218 @@
+package chess
+
+import (
+	"testing"
+)
+
+func unsafeCrazyhouseFEN(s string) *Position {
+	pos, err := CrazyhouseVariant{}.DecodeFEN(s)
+	if err != nil {
+		panic(err)
+	}
+	pos.setVariant(CrazyhouseVariant{})
+	pos.inCheck = isInCheck(pos)
+	return pos
+}
+
+func TestCrazyhouseGame(t *testing.T) {
+	g := NewGame(WithVariant(CrazyhouseVariant{}))
+	moves := []struct {
+		move     string
+		expected string
+	}{
+		{"e4", "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR[] b KQkq e3 0 1"},
+		{"d5", "rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR[] w KQkq d6 0 2"},
+		{"exd5", "rnbqkbnr/ppp1pppp/8/3P4/8/8/PPPP1PPP/RNBQKBNR[P] b KQkq - 0 2"},
+		{"Qxd5", "rnb1kbnr/ppp1pppp/8/3q4/8/8/PPPP1PPP/RNBQKBNR[Pp] w KQkq - 0 3"},
+		{"Nc3", "rnb1kbnr/ppp1pppp/8/3q4/8/2N5/PPPP1PPP/R1BQKBNR[Pp] b KQkq - 1 3"},
+		{"Qe6+", "rnb1kbnr/ppp1pppp/4q3/8/8/2N5/PPPP1PPP/R1BQKBNR[Pp] w KQkq - 2 4"},
+		{"P@e2", "rnb1kbnr/ppp1pppp/4q3/8/8/2N5/PPPPPPPP/R1BQKBNR[p] b KQkq - 0 4"},
+		{"P@d3", "rnb1kbnr/ppp1pppp/4q3/8/8/2Np4/PPPPPPPP/R1BQKBNR[] w KQkq - 0 5"},
+	}
+	for _, m := range moves {
+		if err := g.MoveStr(m.move); err != nil {
+			t.Fatal(err)
+		}
+		if g.Position().String() != m.expected {
+			t.Fatalf("after %s expected %s but got %s", m.move, m.expected, g.Position().String())
+		}
+	}
+	last := g.Moves()[len(g.Moves())-1]
+	if last.S1() != NoSquare || last.S2() != D3 || last.Drop() != Pawn {
+		t.Fatalf("expected a pawn drop on d3 but got %s", last)
+	}
+	if s := (UCINotation{}).Encode(g.Positions()[len(g.Moves())-1], last); s != "P@d3" {
+		t.Fatalf("expected P@d3 but got %s", s)
+	}
+}
+
+func TestCrazyhouseDrops(t *testing.T) {
+	tests := []struct {
+		fen   string
+		drops int
+	}{
+		// pawns can't be dropped on the first or last rank
+		{"4k3/8/8/8/8/8/8/4K3[P] w - - 0 1", 48},
+		{"4k3/8/8/8/8/8/8/4K3[N] w - - 0 1", 62},
+		// drops can only block a check
+		{"4k3/8/8/8/8/8/8/r3K3[Q] w - - 0 1", 3},
+		// and not a knight's check
+		{"4k3/8/8/8/8/3n4/8/4K3[Q] w - - 0 1", 0},
+		// the other side's pocket can't be dropped
+		{"4k3/8/8/8/8/8/8/4K3[q] w - - 0 1", 0},
+	}
+	for _, test := range tests {
+		pos := unsafeCrazyhouseFEN(test.fen)
+		drops := 0
+		for _, m := range pos.ValidMoves() {
+			if m.Drop() != NoPieceType {
+				drops++
+			}
+		}
+		if drops != test.drops {
+			t.Fatalf("expected %d drops for %s but got %d", test.drops, test.fen, drops)
+		}
+	}
+	pos := unsafeCrazyhouseFEN("6rk/6pp/8/8/8/8/8/K7[N] w - - 0 1")
+	m, err := AlgebraicNotation{}.Decode(pos, "N@f7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := (AlgebraicNotation{}).Encode(pos, m); s != "N@f7#" {
+		t.Fatalf("expected N@f7# but got %s", s)
+	}
+	if pos.Update(m).Status() != Checkmate {
+		t.Fatal("expected a smothered mate")
+	}
+	// captured pieces come back so bare kings aren't a draw
+	if unsafeCrazyhouseFEN("4k3/8/8/8/8/8/8/4K3[] w - - 0 1").Status() != NoMethod {
+		t.Fatal("expected bare kings to play on")
+	}
+}
+
+func TestCrazyhousePromoted(t *testing.T) {
+	pos := unsafeCrazyhouseFEN("4k3/8/8/8/8/8/3q~4/4K3[] w - - 0 1")
+	if pos.String() != "4k3/8/8/8/8/8/3q~4/4K3[] w - - 0 1" {
+		t.Fatalf("expected the promoted queen to be marked but got %s", pos)
+	}
+	m, err := UCINotation{}.Decode(pos, "e1d2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pos = pos.Update(m)
+	if pos.Pocket(White).Count(Pawn) != 1 || pos.Pocket(White).Count(Queen) != 0 {
+		t.Fatalf("expected the promoted queen to return as a pawn but got %s", pos)
+	}
+	pos = unsafeCrazyhouseFEN("4k3/1P6/8/8/8/8/8/4K3[] w - - 0 1")
+	m, err = UCINotation{}.Decode(pos, "b7b8q")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pos = pos.Update(m)
+	if pos.String() != "1Q~2k3/8/8/8/8/8/8/4K3[] b - - 0 1" {
+		t.Fatalf("expected the new queen to be marked but got %s", pos)
+	}
+}
+
+func TestCrazyhouseFEN(t *testing.T) {
+	tests := []struct {
+		fen      string
+		expected string
+	}{
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR[] w KQkq - 0 1", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR[] w KQkq - 0 1"},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR[] w KQkq - 0 1"},
+		{"r1bqk2r/pppp1ppp/2n5/4p3/1bB1P3/5Q2/PPPP1PPP/RNB1K1NR/pnNP w KQkq - 0 6", "r1bqk2r/pppp1ppp/2n5/4p3/1bB1P3/5Q2/PPPP1PPP/RNB1K1NR[NPnp] w KQkq - 0 6"},
+		{"2R~2k2/8/8/8/8/8/8/4K3[QQrbp] b - - 0 40", "2R~2k2/8/8/8/8/8/8/4K3[QQrbp] b - - 0 40"},
+	}
+	for _, test := range tests {
+		pos := unsafeCrazyhouseFEN(test.fen)
+		if pos.String() != test.expected {
+			t.Fatalf("expected %s but got %s", test.expected, pos.String())
+		}
+	}
+	for _, fen := range []string{
+		"4k3/8/8/8/8/8/8/4K3[K] w - - 0 1",
+		"4k3/8/8/8/8/8/8/4K3[Q w - - 0 1",
+		"4k3/8/8/8/8/8/8/4K3~[] w - - 0 1/8/8",
+	} {
+		if _, err := (CrazyhouseVariant{}).DecodeFEN(fen); err == nil {
+			t.Fatalf("expected an error decoding %s", fen)
+		}
+	}
+	if (CrazyhouseVariant{}).StartingPosition().ZobristHash() != StartingPosition().ZobristHash() {
+		t.Fatal("expected empty pockets to leave the key unchanged")
+	}
+	h1 := unsafeCrazyhouseFEN("4k3/8/8/8/8/8/8/4K3[N] w - - 0 1").ZobristHash()
+	h2 := unsafeCrazyhouseFEN("4k3/8/8/8/8/8/8/4K3[n] w - - 0 1").ZobristHash()
+	if h1 == h2 {
+		t.Fatal("expected the pockets to change the key")
+	}
+}
+
+func TestCrazyhouseMakeUnmake(t *testing.T) {
+	var walk func(pos *Position, depth int)
+	walk = func(pos *Position, depth int) {
+		if depth == 0 {
+			return
+		}
+		for _, m := range pos.ValidMoves() {
+			before := pos.String()
+			u := pos.MakeMove(m)
+			if pos.hash != pos.zobrist() {
+				t.Fatalf("after move %s to %s expected key %x but got %x", m, pos, pos.zobrist(), pos.hash)
+			}
+			walk(pos, depth-1)
+			pos.UnmakeMove(m, u)
+			if pos.String() != before {
+				t.Fatalf("taking back %s expected %s but got %s", m, before, pos)
+			}
+		}
+	}
+	walk(unsafeCrazyhouseFEN("r1bqk2r/pppp1ppp/2n5/4p3/1bB1P3/5Q2/PPPP1PPP/RNB1K1NR[Nn] w KQkq - 0 6"), 2)
+	walk(unsafeCrazyhouseFEN("2r1k3/1P1q~4/8/8/8/8/8/R3K3[Bp] w Q - 0 1"), 2)
+}
+
+func TestCrazyhousePerft(t *testing.T) {
+	tests := []struct {
+		fen   string
+		depth int
+		nodes int
+	}{
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR[] w KQkq - 0 1", 4, 197281},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR[] w KQkq - 0 1", 5, 4888832},
+	}
+	for _, test := range tests {
+		if testing.Short() && test.depth > 4 {
+			continue
+		}
+		res, err := PerftDivide(test.fen, test.depth, PerftVariant(CrazyhouseVariant{}), PerftWorkers(4))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Total.Nodes != test.nodes {
+			t.Fatalf("perft %s depth %d expected %d but got %d", test.fen, test.depth, test.nodes, res.Total.Nodes)
+		}
+	}
+}
+
+func TestCrazyhousePGN(t *testing.T) {
+	pgn := `[Event "Crazyhouse arena"]
+[Variant "Crazyhouse"]
+
+1. e4 d5 2. exd5 Qxd5 3. Nc3 Qe6+ 4. P@e2 P@d3 5. cxd3 Qxe2+ 6. Qxe2 *`
+	g := NewGame()
+	if err := g.UnmarshalText([]byte(pgn)); err != nil {
+		t.Fatal(err)
+	}
+	expected := "rnb1kbnr/ppp1pppp/8/8/8/2NP4/PP1PQPPP/R1B1KBNR[QPp] b KQkq - 0 6"
+	if g.Position().String() != expected {
+		t.Fatalf("expected %s but got %s", expected, g.Position().String())
+	}
+	g2 := NewGame()
+	if err := g2.UnmarshalText([]byte(g.String())); err != nil {
+		t.Fatal(err)
+	}
+	if g2.Position().String() != expected {
+		t.Fatalf("expected %s but got %s", expected, g2.Position().String())
+	}
+}
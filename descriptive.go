@@ -0,0 +1,340 @@
+package chess
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DescriptiveNotation is the English descriptive notation of older
+// chess literature.  Files are named after the pieces standing on them
+// at the start, QR QN QB Q K KB KN KR, and ranks are counted from the
+// side to move.  Pieces and squares are written as briefly as the
+// position allows, Ex. P-K4, N-KB3, B-N5, PxP, QxQch, KR-Q1, N/KB3-Q4,
+// P-K8=Q and O-O.  Pieces on one side of the board are named after
+// it, KR being the rook on the king's side.
+//
+// Decoding also accepts longer forms, pieces qualified by their square
+// after a slash or in parentheses, Ex. N(KB3)-Q4 or R/1-Q1, and ch,
+// mate, +, # and e.p. suffixes.  It fails, listing the moves, if the
+// text fits more than one.
+type DescriptiveNotation struct{}
+
+// String implements the fmt.Stringer interface and returns
+// the notation's name.
+func (DescriptiveNotation) String() string {
+	return "Descriptive Notation"
+}
+
+// Encode implements the Encoder interface.
+func (DescriptiveNotation) Encode(pos *Position, m *Move) string {
+	check := ""
+	if m.HasTag(Check) {
+		check = "ch"
+		if pos.Update(m).Status() == Checkmate {
+			check = "mate"
+		}
+	}
+	if m.drop != NoPieceType {
+		return dropString(m) + check
+	} else if m.HasTag(KingSideCastle) {
+		return "O-O" + check
+	} else if m.HasTag(QueenSideCastle) {
+		return "O-O-O" + check
+	}
+	c := pos.Turn()
+	movers := descriptivePieceNames(pos.board.Piece(m.s1).Type(), m.s1, c)
+	sep, targets := "-", descriptiveSquareNames(m.s2, c)
+	if sq, ok := capturedSquare(m); ok {
+		sep, targets = "x", descriptivePieceNames(pos.board.Piece(sq).Type(), sq, c)
+	}
+	promo := ""
+	if m.promo != NoPieceType {
+		promo = "=" + charFromPieceType(m.promo)
+	}
+	// the shortest text only the move fits, the fully qualified
+	// one being last
+	best := movers[len(movers)-1] + sep + targets[len(targets)-1] + promo
+	for _, mover := range movers {
+		for _, target := range targets {
+			s := mover + sep + target + promo
+			if len(s) >= len(best) {
+				continue
+			}
+			d, err := parseDescriptive(s)
+			if err != nil {
+				continue
+			}
+			if candidates := d.candidates(pos); len(candidates) == 1 && candidates[0].s1 == m.s1 && candidates[0].s2 == m.s2 {
+				best = s
+			}
+		}
+	}
+	return best + check
+}
+
+// Decode implements the Decoder interface.
+func (DescriptiveNotation) Decode(pos *Position, s string) (*Move, error) {
+	text := strings.TrimSpace(s)
+	for trimmed := ""; trimmed != text; {
+		trimmed = text
+		for _, suffix := range descriptiveSuffixes {
+			if strings.HasSuffix(strings.ToLower(text), suffix) {
+				text = text[:len(text)-len(suffix)]
+			}
+		}
+	}
+	if tag, ok := descriptiveCastle(text); ok {
+		for _, m := range pos.ValidMoves() {
+			if m.HasTag(tag) {
+				return m, nil
+			}
+		}
+		return nil, fmt.Errorf("chess: could not decode descriptive notation %s for position %s", s, pos.String())
+	}
+	d, err := parseDescriptive(text)
+	if err != nil {
+		return nil, fmt.Errorf("chess: could not decode descriptive notation %s for position %s", s, pos.String())
+	}
+	candidates := d.candidates(pos)
+	switch len(candidates) {
+	case 0:
+		return nil, fmt.Errorf("chess: could not decode descriptive notation %s for position %s", s, pos.String())
+	case 1:
+		return candidates[0], nil
+	}
+	moves := make([]string, len(candidates))
+	for i, m := range candidates {
+		moves[i] = DescriptiveNotation{}.Encode(pos, m)
+	}
+	return nil, fmt.Errorf("chess: ambiguous descriptive notation %s for position %s could be %s", s, pos.String(), strings.Join(moves, ", "))
+}
+
+var (
+	descriptiveFiles    = [...]string{"QR", "QN", "QB", "Q", "K", "KB", "KN", "KR"}
+	descriptiveSuffixes = []string{"e.p.", "ep", "ch", "dis", "dbl", "mate", "+", "#", "!", "?", " "}
+	descriptivePieceRe  = regexp.MustCompile(`^([KQ]?[RNB]?)([KQRBNP])(?:/([KQRBN]*)([1-8]?)|\(([KQRBN]*)([1-8]?)\))?$`)
+	descriptiveSquareRe = regexp.MustCompile(`^([KQRBN]*)([1-8])$`)
+)
+
+// descriptiveSquare is a square as written, either of which may be
+// left out.  A file may be short for both sides', B for QB and KB.
+type descriptiveSquare struct {
+	file string
+	rank int
+}
+
+func (d descriptiveSquare) matches(sq Square, c Color) bool {
+	if d.rank != 0 && d.rank != relativeRank(sq, c) {
+		return false
+	}
+	name := descriptiveFiles[sq.File()]
+	return d.file == "" || d.file == name || len(name) == 2 && d.file == name[1:]
+}
+
+// descriptivePiece is a piece as written.  The prefix is the side of
+// the board of a piece or the file of a pawn.
+type descriptivePiece struct {
+	pt     PieceType
+	prefix string
+	square descriptiveSquare
+}
+
+func (d descriptivePiece) matches(sq Square, c Color) bool {
+	switch {
+	case d.pt == Pawn && !(descriptiveSquare{file: d.prefix}).matches(sq, c):
+		return false
+	case d.pt != Pawn && d.prefix == "K" && sq.File() < FileE:
+		return false
+	case d.pt != Pawn && d.prefix == "Q" && sq.File() > FileD:
+		return false
+	}
+	return d.square.matches(sq, c)
+}
+
+type descriptiveMove struct {
+	mover   descriptivePiece
+	capture bool
+	target  descriptivePiece
+	dest    descriptiveSquare
+	promo   PieceType
+}
+
+// candidates returns the valid moves fitting the move.
+func (d descriptiveMove) candidates(pos *Position) []*Move {
+	c := pos.Turn()
+	moves := []*Move{}
+	for _, m := range pos.ValidMoves() {
+		if m.drop != NoPieceType || m.HasTag(KingSideCastle) || m.HasTag(QueenSideCastle) {
+			continue
+		}
+		if pos.board.Piece(m.s1).Type() != d.mover.pt || !d.mover.matches(m.s1, c) {
+			continue
+		}
+		if d.promo != NoPieceType && m.promo != d.promo {
+			continue
+		}
+		sq, capture := capturedSquare(m)
+		switch {
+		case capture != d.capture:
+			continue
+		case capture && (pos.board.Piece(sq).Type() != d.target.pt || !d.target.matches(sq, c)):
+			continue
+		case !capture && !d.dest.matches(m.s2, c):
+			continue
+		}
+		moves = append(moves, m)
+	}
+	return moves
+}
+
+func parseDescriptive(s string) (descriptiveMove, error) {
+	d := descriptiveMove{}
+	s = strings.ToUpper(s)
+	i := strings.IndexAny(s, "-X")
+	if i < 0 {
+		return d, fmt.Errorf("chess: no - or x in %s", s)
+	}
+	mover, rest := s[:i], s[i+1:]
+	d.capture = s[i] == 'X'
+	// promotions are written P-K8=Q, P-K8(Q), P-K8/Q or P-K8Q
+	n := len(rest)
+	switch {
+	case n >= 3 && rest[n-3] == '(' && rest[n-1] == ')':
+		if d.promo = descriptivePromo(rest[n-2]); d.promo != NoPieceType {
+			rest = rest[:n-3]
+		}
+	case n >= 2 && (rest[n-2] == '=' || rest[n-2] == '/' || !d.capture && rest[n-2] >= '1' && rest[n-2] <= '8'):
+		if d.promo = descriptivePromo(rest[n-1]); d.promo != NoPieceType {
+			rest = strings.TrimRight(rest[:n-1], "=/")
+		}
+	}
+	var err error
+	if d.mover, err = parseDescriptivePiece(mover); err != nil {
+		return d, err
+	}
+	if d.capture {
+		d.target, err = parseDescriptivePiece(rest)
+		return d, err
+	}
+	matches := descriptiveSquareRe.FindStringSubmatch(rest)
+	if matches == nil || !descriptiveFileName(matches[1]) {
+		return d, fmt.Errorf("chess: invalid square %s", rest)
+	}
+	d.dest = descriptiveSquare{file: matches[1], rank: int(matches[2][0] - '0')}
+	return d, nil
+}
+
+func parseDescriptivePiece(s string) (descriptivePiece, error) {
+	matches := descriptivePieceRe.FindStringSubmatch(s)
+	if matches == nil {
+		return descriptivePiece{}, fmt.Errorf("chess: invalid piece %s", s)
+	}
+	d := descriptivePiece{pt: Pawn, prefix: matches[1]}
+	if matches[2] != "P" {
+		d.pt = pieceTypeFromChar(strings.ToLower(matches[2]))
+	}
+	file, rank := matches[3]+matches[5], matches[4]+matches[6]
+	if rank != "" {
+		d.square.rank = int(rank[0] - '0')
+	}
+	d.square.file = file
+	switch {
+	case !descriptiveFileName(file):
+		return d, fmt.Errorf("chess: invalid file %s", file)
+	case d.pt == Pawn && !descriptiveFileName(d.prefix):
+		return d, fmt.Errorf("chess: invalid pawn %s", s)
+	case d.pt != Pawn && d.prefix != "" && d.prefix != "K" && d.prefix != "Q":
+		return d, fmt.Errorf("chess: invalid piece %s", s)
+	case (d.pt == King || d.pt == Queen) && d.prefix != "":
+		return d, fmt.Errorf("chess: invalid piece %s", s)
+	}
+	return d, nil
+}
+
+// descriptiveFileName returns true if s names a file, a pair of files
+// or is empty.
+func descriptiveFileName(s string) bool {
+	switch s {
+	case "", "R", "N", "B":
+		return true
+	}
+	for _, name := range descriptiveFiles {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+func descriptivePromo(c byte) PieceType {
+	switch c {
+	case 'Q', 'R', 'B', 'N':
+		return pieceTypeFromChar(strings.ToLower(string(c)))
+	}
+	return NoPieceType
+}
+
+// descriptiveCastle returns the castle written as O-O, 0-0, O-O-O,
+// Castles or Castles QR.
+func descriptiveCastle(s string) (MoveTag, bool) {
+	switch strings.ToUpper(strings.ReplaceAll(s, "0", "O")) {
+	case "O-O", "CASTLES", "CASTLES K", "CASTLES KR":
+		return KingSideCastle, true
+	case "O-O-O", "CASTLES Q", "CASTLES QR":
+		return QueenSideCastle, true
+	}
+	return 0, false
+}
+
+// descriptivePieceNames returns the ways to write the piece on the
+// square from the shortest to the fully qualified.
+func descriptivePieceNames(pt PieceType, sq Square, c Color) []string {
+	letter := charFromPieceType(pt)
+	if pt == Pawn {
+		letter = "P"
+	}
+	file := descriptiveFiles[sq.File()]
+	qualified := fmt.Sprintf("%s/%s%d", letter, file, relativeRank(sq, c))
+	switch pt {
+	case Pawn:
+		names := []string{"P"}
+		if len(file) == 2 {
+			names = append(names, file[1:]+"P")
+		}
+		return append(names, file+"P", qualified)
+	case King, Queen:
+		return []string{letter, qualified}
+	}
+	return []string{letter, file[:1] + letter, qualified}
+}
+
+// descriptiveSquareNames returns the ways to write the square from the
+// shortest.
+func descriptiveSquareNames(sq Square, c Color) []string {
+	file, rank := descriptiveFiles[sq.File()], relativeRank(sq, c)
+	if len(file) == 2 {
+		return []string{fmt.Sprintf("%s%d", file[1:], rank), fmt.Sprintf("%s%d", file, rank)}
+	}
+	return []string{fmt.Sprintf("%s%d", file, rank)}
+}
+
+// relativeRank returns the rank of the square counted from the
+// color's side, from 1 to 8.
+func relativeRank(sq Square, c Color) int {
+	if c == Black {
+		return 8 - int(sq.Rank())
+	}
+	return int(sq.Rank()) + 1
+}
+
+// capturedSquare returns the square of the piece the move captures.
+func capturedSquare(m *Move) (Square, bool) {
+	switch {
+	case m.HasTag(EnPassant):
+		return NewSquare(m.s2.File(), m.s1.Rank()), true
+	case m.HasTag(Capture):
+		return m.s2, true
+	}
+	return NoSquare, false
+}
@@ -0,0 +1,116 @@
+package chess
+
+import (
+	"strings"
+	"testing"
+)
+
+var ruyLopez = []string{"e4", "e5", "Nf3", "Nc6", "Bb5", "a6", "Ba4", "Nf6", "O-O", "Be7", "Re1", "b5", "Bb3", "d6", "c3", "O-O", "h3", "Nb8", "d4", "Nbd7", "dxe5", "dxe5"}
+
+func TestDescriptiveNotation(t *testing.T) {
+	game := NewGame()
+	for _, m := range ruyLopez {
+		if err := game.MoveStr(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	game.SetNotation(DescriptiveNotation{})
+	expected := "1. P-K4 P-K4 2. N-KB3 N-QB3 3. B-N5 P-QR3 4. B-R4 N-B3 5. O-O B-K2 6. R-K1 P-QN4 7. B-N3 P-Q3 8. P-B3 O-O 9. P-KR3 N-N1 10. P-Q4 QN-Q2 11. PxP PxP *"
+	if s := strings.TrimSpace(game.String()); s != expected {
+		t.Fatalf("expected\n%s\nbut got\n%s", expected, s)
+	}
+	cp, err := decodePGN(UseNotation(DescriptiveNotation{}), expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp.Position().String() != game.Position().String() {
+		t.Fatalf("expected %s but got %s", game.Position(), cp.Position())
+	}
+}
+
+func TestDescriptiveDecoding(t *testing.T) {
+	tests := []struct {
+		fen   string
+		texts []string
+		move  string
+		desc  string
+	}{
+		{"4k3/8/8/8/8/8/8/R4RK1 w - - 0 1", []string{"QR-Q1", "R(QR1)-Q1", "R/QR1-Q1", "qr-q1"}, "Rad1", "QR-Q1"},
+		{"4k3/8/8/8/8/8/8/R4RK1 w - - 0 1", []string{"KR-Q1", "R/KB1-Q1", "R(B)-Q1"}, "Rfd1", "KR-Q1"},
+		{"4k3/8/8/8/8/8/8/3KN1N1 w - - 0 1", []string{"N/KN1-B3", "N(N1)-B3"}, "Ngf3", "N/KN1-B3"},
+		{"4k3/8/8/2p1p3/3P4/8/8/4K3 w - - 0 1", []string{"PxBP", "PxQBP", "PxP/QB5", "QPxBP"}, "dxc5", "PxBP"},
+		{"4k3/8/8/2p1p3/3P4/8/8/4K3 w - - 0 1", []string{"PxKP", "PxP(K5)"}, "dxe5", "PxKP"},
+		{"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", []string{"PxP", "PxP e.p.", "PxPep", "KPxP"}, "exf6", "PxP"},
+		{"8/4P3/8/8/8/k7/8/K7 w - - 0 1", []string{"P-K8=Q", "P-K8(Q)", "P-K8/Q", "P-K8Q"}, "e8=Q", "P-K8=Q"},
+		{"rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2", []string{"Q-R5", "Q-KR5"}, "Qh5", "Q-R5"},
+		{"r1bqkb1r/pppp1ppp/2n2n2/4p2Q/2B1P3/8/PPPP1PPP/RNB1K1NR w KQkq - 4 4", []string{"QxBPmate", "QxKBP#", "QxP/KB7 mate"}, "Qxf7#", "QxBPmate"},
+		{"r3k2r/8/8/8/8/8/8/4K3 b kq - 0 1", []string{"O-O-O", "0-0-0", "Castles QR"}, "O-O-O", "O-O-O"},
+	}
+	for _, test := range tests {
+		pos := unsafeFEN(test.fen)
+		for _, text := range test.texts {
+			m, err := DescriptiveNotation{}.Decode(pos, text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s := (AlgebraicNotation{}).Encode(pos, m); s != test.move {
+				t.Fatalf("expected %s to decode to %s but got %s", text, test.move, s)
+			}
+			if s := (DescriptiveNotation{}).Encode(pos, m); s != test.desc {
+				t.Fatalf("expected %s to encode as %s but got %s", test.move, test.desc, s)
+			}
+		}
+	}
+	ambiguous := []struct {
+		fen   string
+		text  string
+		moves string
+	}{
+		{INITIAL_FEN_POSITION, "N-B3", "N-QB3, N-KB3"},
+		{"4k3/8/8/8/8/8/8/R4RK1 w - - 0 1", "R-Q1", "QR-Q1, KR-Q1"},
+		{"8/4P3/8/8/8/k7/8/K7 w - - 0 1", "P-K8", "P-K8=Q, P-K8=R, P-K8=B, P-K8=N"},
+	}
+	for _, test := range ambiguous {
+		_, err := DescriptiveNotation{}.Decode(unsafeFEN(test.fen), test.text)
+		if err == nil || !strings.HasSuffix(err.Error(), "could be "+test.moves) {
+			t.Fatalf("expected %s to be ambiguous between %s but got %v", test.text, test.moves, err)
+		}
+	}
+	for _, text := range []string{"P-K5", "N-K4", "PxQ", "X-K4", "K4"} {
+		if _, err := (DescriptiveNotation{}).Decode(unsafeFEN(INITIAL_FEN_POSITION), text); err == nil {
+			t.Fatalf("expected %s to be invalid", text)
+		}
+	}
+}
+
+func TestDescriptivePGNVariations(t *testing.T) {
+	tests := []struct {
+		pgn      string
+		expected string
+	}{
+		{"1. P-K4 P-K4 (1... P-QB4) 2. N-KB3 *", "1. P-K4 P-K4 (1... P-QB4) 2. N-KB3 *"},
+		{"1. P-K4 (1. P-Q4) P-K4 2. N-KB3 N(QN1)-B3 (2... P-Q3) *", "1. P-K4 (1. P-Q4) 1... P-K4 2. N-KB3 N-QB3 (2... P-Q3) *"},
+		{"1. P-K4 P-Q4 2. PxP (2. P-K5) Q/Q1xP *", "1. P-K4 P-Q4 2. PxP (2. P-K5) 2... QxP *"},
+	}
+	for _, test := range tests {
+		game, err := decodePGN(UseNotation(DescriptiveNotation{}), test.pgn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := strings.TrimSpace(game.String()); s != test.expected {
+			t.Fatalf("expected\n%s\nbut got\n%s", test.expected, s)
+		}
+	}
+	fen := `[FEN "4k3/P7/8/8/8/8/8/4K3 w - - 0 1"]
+
+`
+	for _, promo := range []string{"P-R8(Q)", "P-R8=Q", "P-R8/Q"} {
+		game, err := decodePGN(UseNotation(DescriptiveNotation{}), fen+"1. "+promo+" (1. P-R8(N)) *")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := game.Node(1).Position().Board().Piece(A8); s != WhiteQueen || len(game.Root().Children()) != 2 {
+			t.Fatalf("expected %s to promote to a queen with a knight variation", promo)
+		}
+	}
+}
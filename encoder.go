@@ -0,0 +1,222 @@
+package chess
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// PGNEncoder writes games to an io.Writer one after another in the
+// PGN export format: the Seven Tag Roster first with missing tags
+// added, tag values escaped, move text wrapped at 80 columns and
+// Black's move numbered again after a comment or variation.  Games
+// are separated by a blank line.
+type PGNEncoder struct {
+	w       io.Writer
+	cfg     pgnEncoderConfig
+	written bool
+}
+
+// PGNEncoderOption configures a PGNEncoder.
+type PGNEncoderOption func(*pgnEncoderConfig)
+
+type pgnEncoderConfig struct {
+	compact bool
+	width   int
+}
+
+// PGNCompact returns an option that writes the import friendly style
+// Game's String method uses: the tags given in roster order without
+// adding missing ones and the move text on a single line.
+func PGNCompact() PGNEncoderOption {
+	return func(c *pgnEncoderConfig) {
+		c.compact = true
+		c.width = 0
+	}
+}
+
+// PGNLineWidth returns an option that wraps the move text at n
+// columns instead of 80, or not at all if n is zero.
+func PGNLineWidth(n int) PGNEncoderOption {
+	return func(c *pgnEncoderConfig) {
+		c.width = n
+	}
+}
+
+// NewPGNEncoder returns an encoder writing to w.
+func NewPGNEncoder(w io.Writer, options ...PGNEncoderOption) *PGNEncoder {
+	cfg := pgnEncoderConfig{width: 80}
+	for _, f := range options {
+		if f != nil {
+			f(&cfg)
+		}
+	}
+	return &PGNEncoder{w: w, cfg: cfg}
+}
+
+// Encode writes the game's PGN.
+func (e *PGNEncoder) Encode(g *Game) error {
+	s := e.cfg.encode(g)
+	if e.written {
+		s = "\n" + s
+	}
+	e.written = true
+	_, err := io.WriteString(e.w, s)
+	return err
+}
+
+// sevenTagRoster are the tags PGN exports first and the values of
+// missing ones.  Result is taken from the game.
+var sevenTagRoster = []TagPair{
+	{Key: "Event", Value: "?"},
+	{Key: "Site", Value: "?"},
+	{Key: "Date", Value: "????.??.??"},
+	{Key: "Round", Value: "?"},
+	{Key: "White", Value: "?"},
+	{Key: "Black", Value: "?"},
+	{Key: "Result"},
+}
+
+var tagValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+func (c pgnEncoderConfig) encode(g *Game) string {
+	sb := strings.Builder{}
+	for _, escape := range g.escapes {
+		sb.WriteString(escape)
+		sb.WriteString("\n")
+	}
+	for _, tag := range c.tags(g) {
+		fmt.Fprintf(&sb, "[%s \"%s\"]\n", tag.Key, tagValueEscaper.Replace(tag.Value))
+	}
+	sb.WriteString("\n")
+	w := &movetextWriter{g: g}
+	w.comments(g.root.comments)
+	w.line(g.root, true)
+	w.add(string(g.outcome))
+	sb.WriteString(wrapTokens(w.toks, c.width))
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// tags returns the game's tags with the roster first.
+func (c pgnEncoderConfig) tags(g *Game) []TagPair {
+	tags := []TagPair{}
+	roster := map[string]bool{}
+	for _, r := range sevenTagRoster {
+		roster[r.Key] = true
+		tag := g.GetTagPair(r.Key)
+		switch {
+		case r.Key == "Result" && !c.compact:
+			tags = append(tags, TagPair{Key: r.Key, Value: string(g.outcome)})
+		case tag != nil:
+			tags = append(tags, *tag)
+		case !c.compact:
+			tags = append(tags, r)
+		}
+	}
+	for _, tag := range g.tagPairs {
+		if !roster[tag.Key] {
+			tags = append(tags, *tag)
+		}
+	}
+	if !c.compact && g.GetTagPair("Termination") == nil {
+		for value, method := range terminationMethods {
+			if method == g.method {
+				tags = append(tags, TagPair{Key: "Termination", Value: value})
+			}
+		}
+	}
+	return tags
+}
+
+type pgnToken struct {
+	text string
+	// noBreak is set if a line can't break before the token
+	noBreak bool
+	// newline is set if the token starts a line of a comment
+	newline bool
+}
+
+// movetextWriter splits a game's move text into tokens.
+type movetextWriter struct {
+	g      *Game
+	toks   []pgnToken
+	prefix string
+}
+
+func (w *movetextWriter) add(s string) {
+	w.toks = append(w.toks, pgnToken{text: w.prefix + s})
+	w.prefix = ""
+}
+
+// line writes the line after the node along with its variations.
+func (w *movetextWriter) line(n *MoveNode, number bool) {
+	for len(n.children) > 0 {
+		next := n.children[0]
+		number = w.move(next, number)
+		for _, variation := range n.children[1:] {
+			w.prefix = "("
+			w.line(variation, w.move(variation, true))
+			w.toks[len(w.toks)-1].text += ")"
+			number = true
+		}
+		n = next
+	}
+}
+
+// move writes the move with its NAGs and comments and returns true
+// if a following Black move needs its number.
+func (w *movetextWriter) move(n *MoveNode, number bool) bool {
+	pos := n.parent.pos
+	w.comments(n.preComments)
+	if pos.turn == White {
+		w.add(fmt.Sprintf("%d.", pos.moveCount))
+	} else if number || len(n.preComments) > 0 {
+		w.add(fmt.Sprintf("%d...", pos.moveCount))
+	}
+	san := w.g.notation.Encode(pos, n.move) + encodeNAGs(n.nags, w.g.nagSymbols)
+	for _, s := range strings.Fields(san) {
+		w.add(s)
+	}
+	w.comments(n.comments)
+	return len(n.comments) > 0
+}
+
+func (w *movetextWriter) comments(comments []string) {
+	for _, c := range comments {
+		lines := strings.Split("{"+c+"}", "\n")
+		for i, line := range lines {
+			words := strings.Split(line, " ")
+			for j, word := range words {
+				w.add(word)
+				// comments wrap at spaces and keep their line breaks
+				tok := &w.toks[len(w.toks)-1]
+				tok.newline = i > 0 && j == 0
+				tok.noBreak = j > 0 && (word == "" || words[j-1] == "" || strings.HasPrefix(word, "%"))
+			}
+		}
+	}
+}
+
+// wrapTokens joins the tokens with spaces into lines of at most width
+// characters where possible.
+func wrapTokens(toks []pgnToken, width int) string {
+	sb := strings.Builder{}
+	col := 0
+	for i, t := range toks {
+		n := utf8.RuneCountInString(t.text)
+		if i > 0 {
+			if t.newline || width > 0 && !t.noBreak && col+1+n > width {
+				sb.WriteString("\n")
+				col = 0
+			} else {
+				sb.WriteString(" ")
+				col++
+			}
+		}
+		sb.WriteString(t.text)
+		col += n
+	}
+	return sb.String()
+}
@@ -0,0 +1,152 @@
+package chess
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestPGNEncoderExportFormat(t *testing.T) {
+	game := NewGame()
+	game.AddTagPair("Annotator", "lichess.org")
+	game.AddTagPair("White", `Magnus "DrNykterstein" Carlsen`)
+	game.AddTagPair("Event", `C:\games`)
+	for _, m := range []string{"e4", "e5", "Nf3"} {
+		if err := game.MoveStr(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	game.Node(2).SetComments([]string{"open game"})
+	if _, err := game.AddVariationStr(game.Node(1), "c5"); err != nil {
+		t.Fatal(err)
+	}
+	sb := strings.Builder{}
+	if err := NewPGNEncoder(&sb).Encode(game); err != nil {
+		t.Fatal(err)
+	}
+	expected := `[Event "C:\\games"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "Magnus \"DrNykterstein\" Carlsen"]
+[Black "?"]
+[Result "*"]
+[Annotator "lichess.org"]
+
+1. e4 e5 {open game} (1... c5) 2. Nf3 *
+`
+	if sb.String() != expected {
+		t.Fatalf("expected\n%s\nbut got\n%s", expected, sb.String())
+	}
+	cp, err := decodePGN(nil, sb.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag := cp.GetTagPair("White"); tag == nil || tag.Value != `Magnus "DrNykterstein" Carlsen` {
+		t.Fatalf("expected escaped tag to round trip but got %v", tag)
+	}
+	if tag := cp.GetTagPair("Event"); tag == nil || tag.Value != `C:\games` {
+		t.Fatalf("expected escaped tag to round trip but got %v", tag)
+	}
+}
+
+func TestPGNEncoderWrapping(t *testing.T) {
+	pgn, err := mustParsePGN()("fixtures/pgns/0005.pgn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	game, err := decodePGN(nil, pgn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, width := range []int{80, 40} {
+		sb := strings.Builder{}
+		if err := NewPGNEncoder(&sb, PGNLineWidth(width)).Encode(game); err != nil {
+			t.Fatal(err)
+		}
+		for _, line := range strings.Split(sb.String(), "\n") {
+			if !strings.HasPrefix(line, "[") && utf8.RuneCountInString(line) > width {
+				t.Fatalf("expected lines of at most %d characters but got %q", width, line)
+			}
+		}
+		cp, err := decodePGN(nil, sb.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		// comments read back with line breaks where they were wrapped
+		if s, expected := strings.Fields(unwrapped(t, cp)), strings.Fields(unwrapped(t, game)); !slices.Equal(s, expected) {
+			t.Fatalf("expected wrapped game to decode to\n%s\nbut got\n%s", unwrapped(t, game), unwrapped(t, cp))
+		}
+	}
+}
+
+func TestPGNEncoderWrapsComments(t *testing.T) {
+	game := NewGame()
+	if err := game.MoveStr("e4"); err != nil {
+		t.Fatal(err)
+	}
+	comment := strings.Repeat("The king's pawn opening is the most popular first move. ", 4) + "\nSee also 1. d4."
+	game.Node(1).SetComments([]string{comment})
+	sb := strings.Builder{}
+	if err := NewPGNEncoder(&sb).Encode(game); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(sb.String(), "\n")
+	for _, line := range lines {
+		if utf8.RuneCountInString(line) > 80 {
+			t.Fatalf("expected lines of at most 80 characters but got %q", line)
+		}
+	}
+	if !slices.Contains(lines, "See also 1. d4.} *") {
+		t.Fatalf("expected the comment's line break to be kept but got\n%s", sb.String())
+	}
+}
+
+func TestPGNEncoderStream(t *testing.T) {
+	sb := strings.Builder{}
+	enc := NewPGNEncoder(&sb, PGNCompact())
+	games := []*Game{}
+	for _, moves := range [][]string{{"e4", "e5"}, {"d4"}, {}} {
+		game := NewGame()
+		for _, m := range moves {
+			if err := game.MoveStr(m); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := enc.Encode(game); err != nil {
+			t.Fatal(err)
+		}
+		games = append(games, game)
+	}
+	expected := "\n1. e4 e5 *\n\n\n1. d4 *\n\n\n*\n"
+	if sb.String() != expected {
+		t.Fatalf("expected %q but got %q", expected, sb.String())
+	}
+	sb.Reset()
+	enc = NewPGNEncoder(&sb)
+	for _, game := range games {
+		if err := enc.Encode(game); err != nil {
+			t.Fatal(err)
+		}
+	}
+	scanner := NewScanner(strings.NewReader(sb.String()))
+	n := 0
+	for ; scanner.Scan(); n++ {
+		if unwrapped(t, scanner.Next()) != unwrapped(t, games[n]) {
+			t.Fatalf("expected game %d to be %s but got %s", n, games[n], scanner.Next())
+		}
+	}
+	if n != len(games) {
+		t.Fatalf("expected %d games but got %d", len(games), n)
+	}
+}
+
+func unwrapped(t *testing.T, g *Game) string {
+	t.Helper()
+	sb := strings.Builder{}
+	if err := NewPGNEncoder(&sb, PGNLineWidth(0)).Encode(g); err != nil {
+		t.Fatal(err)
+	}
+	return sb.String()
+}
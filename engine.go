@@ -3,10 +3,13 @@ package chess
 type engine struct{}
 
 func (engine) CalcMoves(pos *Position, first bool) []*Move {
-	// generate possible moves
-	moves := standardMoves(pos, first)
-	// return moves including castles
-	return append(moves, castleMoves(pos)...)
+	// generate legal moves including castles
+	ms := legalMoves(pos, make([]Move, 0, 48), first)
+	moves := make([]*Move, len(ms))
+	for i := range ms {
+		moves[i] = &ms[i]
+	}
+	return moves
 }
 
 func (engine) Status(pos *Position) Method {
@@ -14,9 +17,13 @@ func (engine) Status(pos *Position) Method {
 	if pos.validMoves != nil {
 		hasMove = len(pos.validMoves) > 0
 	} else {
-		hasMove = len(engine{}.CalcMoves(pos, true)) > 0
+		var buf [1]Move
+		hasMove = len(legalMoves(pos, buf[:0], true)) > 0
+	}
+	if method := pos.variantEnd(); method != NoMethod {
+		return method
 	}
-	if !pos.board.HasSufficientMaterial() {
+	if !pos.hasSufficientMaterial() {
 		return InsufficientMaterial
 	}
 	if !pos.inCheck && !hasMove {
@@ -30,301 +37,512 @@ func (engine) Status(pos *Position) Method {
 }
 
 var (
-	promoPieceTypes = []PieceType{Queen, Rook, Bishop, Knight}
+	promoPieceTypes          = []PieceType{Queen, Rook, Bishop, Knight}
+	antichessPromoPieceTypes = []PieceType{Queen, Rook, Bishop, Knight, King}
+	noPromo                  = []PieceType{NoPieceType}
 )
 
-func standardMoves(pos *Position, first bool) []*Move {
-	// compute allowed destination bitboard
-	bbAllowed := ^pos.board.whiteSqs
-	if pos.Turn() == Black {
-		bbAllowed = ^pos.board.blackSqs
+// moveGen holds the masks computed once per position that let
+// legalMoves produce legal moves directly instead of playing
+// every pseudo legal move to see if it leaves the king in check.
+type moveGen struct {
+	pos      *Position
+	b        *Board
+	us, them Color
+	ours     Bitboard
+	theirs   Bitboard
+	occupied Bitboard
+	kingSq   Square
+	// checkers are the pieces giving check
+	checkers Bitboard
+	// checkMask limits non king moves to capturing the checker or
+	// blocking the check
+	checkMask Bitboard
+	// pinned are our pieces that may only move along the line
+	// through their square and our king
+	pinned Bitboard
+	// enemyKingSq, checkSqs and discoverers are used to tag moves
+	// giving check without playing them
+	enemyKingSq Square
+	checkSqs    [7]Bitboard
+	discoverers Bitboard
+}
+
+func newMoveGen(pos *Position) *moveGen {
+	b := pos.board
+	g := &moveGen{
+		pos:       pos,
+		b:         b,
+		us:        pos.turn,
+		them:      pos.turn.Other(),
+		ours:      b.whiteSqs,
+		theirs:    b.blackSqs,
+		occupied:  ^b.emptySqs,
+		kingSq:    b.whiteKingSq,
+		checkMask: ^Bitboard(0),
 	}
-	moves := []*Move{}
-	// iterate through pieces to find possible moves
-	for _, p := range allPieces {
-		if pos.Turn() != p.Color() {
-			continue
+	g.enemyKingSq = b.blackKingSq
+	if g.us == Black {
+		g.ours, g.theirs = g.theirs, g.ours
+		g.kingSq, g.enemyKingSq = g.enemyKingSq, g.kingSq
+	}
+	if pos.antichess {
+		// kings are ordinary pieces, there are no checks or pins
+		g.kingSq, g.enemyKingSq = NoSquare, NoSquare
+	}
+	if g.kingSq != NoSquare {
+		g.checkers = b.attackersTo(g.kingSq, g.them, g.occupied)
+		switch g.checkers.count() {
+		case 0:
+		case 1:
+			g.checkMask = bbBetween[g.kingSq][g.checkers.firstSquare()] | g.checkers
+		default:
+			g.checkMask = 0
+		}
+		g.pinned = b.blockers(g.kingSq, g.them, g.occupied) & g.ours
+	}
+	if g.enemyKingSq != NoSquare {
+		sq := g.enemyKingSq
+		g.checkSqs[Pawn] = bbPawnAttacks[g.them][sq]
+		g.checkSqs[Knight] = bbKnightMoves[sq]
+		g.checkSqs[Bishop] = bishopAttacks(g.occupied, sq)
+		g.checkSqs[Rook] = rookAttacks(g.occupied, sq)
+		g.checkSqs[Queen] = g.checkSqs[Bishop] | g.checkSqs[Rook]
+		g.discoverers = b.blockers(sq, g.us, g.occupied) & g.ours
+	}
+	if pos.atomic {
+		// explosions defeat the masks, moves are played on the board
+		// to tell if they are legal
+		g.checkMask = ^Bitboard(0)
+		g.pinned = 0
+		if !b.atomicKingAttacked(g.us) {
+			g.checkers = 0
 		}
-		// iterate through possible starting squares for piece
-		s1BB := pos.board.bbForPiece(p)
-		if s1BB == 0 {
+	}
+	return g
+}
+
+// legalMoves appends the legal moves of the position to moves.  Moves
+// are generated by piece type, origin square and destination square
+// followed by castles and Crazyhouse drops.  If first is true only the first legal move
+// found is appended.
+func legalMoves(pos *Position, moves []Move, first bool) []Move {
+	if pos.variantEnd() != NoMethod {
+		return moves
+	}
+	if pos.antichess && first {
+		// a capture found later rules out the moves found before it
+		if moves = legalMoves(pos, moves, false); len(moves) > 1 {
+			moves = moves[:1]
+		}
+		return moves
+	}
+	g := newMoveGen(pos)
+	promoTypes := promoPieceTypes
+	if pos.antichess {
+		promoTypes = antichessPromoPieceTypes
+	}
+	for _, pt := range PieceTypes() {
+		if pt != King && g.checkMask == 0 {
 			continue
 		}
-		for s1 := 0; s1 < numOfSquaresInBoard; s1++ {
-			if s1BB&bbForSquare(Square(s1)) == 0 {
-				continue
-			}
-			// iterate through possible destination squares for piece
-			s2BB := bbForPossibleMoves(pos, p.Type(), Square(s1)) & bbAllowed
-			if s2BB == 0 {
-				continue
-			}
-			for s2 := 0; s2 < numOfSquaresInBoard; s2++ {
-				if s2BB&bbForSquare(Square(s2)) == 0 {
-					continue
-				}
+		s1BB := g.b.bbForPiece(NewPiece(pt, g.us))
+		for s1BB != 0 {
+			s1 := s1BB.firstSquare()
+			s1BB ^= bbForSquare(s1)
+			s2BB := g.targets(pt, s1)
+			for s2BB != 0 {
+				s2 := s2BB.firstSquare()
+				s2BB ^= bbForSquare(s2)
 				// add promotions if pawn on promo square
-				if (p == WhitePawn && Square(s2).Rank() == Rank8) || (p == BlackPawn && Square(s2).Rank() == Rank1) {
-					for _, pt := range promoPieceTypes {
-						m := &Move{s1: Square(s1), s2: Square(s2), promo: pt}
-						addTags(m, pos)
-						// filter out moves that put king into check
-						if !m.HasTag(inCheck) {
-							moves = append(moves, m)
-							if first {
-								return moves
-							}
-						}
+				promos := noPromo
+				if pt == Pawn && (s2.Rank() == Rank8 || s2.Rank() == Rank1) {
+					promos = promoTypes
+				}
+				for _, promo := range promos {
+					m := g.move(pt, s1, s2, promo)
+					if m.HasTag(inCheck) {
+						continue
 					}
-				} else {
-					m := &Move{s1: Square(s1), s2: Square(s2)}
-					addTags(m, pos)
-					// filter out moves that put king into check
-					if !m.HasTag(inCheck) {
-						moves = append(moves, m)
-						if first {
-							return moves
-						}
+					moves = append(moves, m)
+					if first {
+						return moves
 					}
 				}
 			}
 		}
 	}
+	moves = g.castles(moves)
+	if pos.crazyhouse {
+		moves = g.drops(moves)
+	}
+	if pos.antichess {
+		moves = forcedCaptures(moves)
+	}
+	if first && len(moves) > 1 {
+		moves = moves[:1]
+	}
 	return moves
 }
 
-func addTags(m *Move, pos *Position) {
-	p := pos.board.Piece(m.s1)
-	if pos.board.isOccupied(m.s2) {
+// targets returns the legal destination squares of the piece on s1.
+func (g *moveGen) targets(pt PieceType, s1 Square) Bitboard {
+	var bb Bitboard
+	switch pt {
+	case King:
+		if g.pos.antichess {
+			return bbKingMoves[s1] &^ g.ours
+		}
+		if g.pos.atomic {
+			// kings can't capture in Atomic
+			return bbKingMoves[s1] &^ g.ours &^ g.theirs
+		}
+		// the king can't hide behind itself from sliding checks
+		attacked := g.b.attackedSquares(g.them, g.occupied&^bbForSquare(s1))
+		return bbKingMoves[s1] &^ g.ours &^ attacked
+	case Queen:
+		bb = queenAttacks(g.occupied, s1) &^ g.ours
+	case Rook:
+		bb = rookAttacks(g.occupied, s1) &^ g.ours
+	case Bishop:
+		bb = bishopAttacks(g.occupied, s1) &^ g.ours
+	case Knight:
+		bb = bbKnightMoves[s1] &^ g.ours
+	case Pawn:
+		bb = g.pawnTargets(s1)
+	}
+	bb &= g.checkMask
+	if g.pinned&bbForSquare(s1) != 0 {
+		bb &= bbLine[g.kingSq][s1]
+	}
+	if pt == Pawn && g.enPassantIsLegal(s1) {
+		bb |= bbForSquare(g.pos.enPassantSquare)
+	}
+	return bb
+}
+
+func (g *moveGen) pawnTargets(s1 Square) Bitboard {
+	bb := bbForSquare(s1)
+	empty := ^g.occupied
+	var upOne, upTwo Bitboard
+	if g.us == White {
+		doubleStep := bbRank3
+		if g.pos.horde {
+			// the horde's pawns on the first rank may also double step
+			doubleStep |= bbRank2
+		}
+		upOne = (bb >> 8) & empty
+		upTwo = ((upOne & doubleStep) >> 8) & empty
+	} else {
+		upOne = (bb << 8) & empty
+		upTwo = ((upOne & bbRank6) << 8) & empty
+	}
+	return upOne | upTwo | (bbPawnAttacks[g.us][s1] & g.theirs)
+}
+
+// enPassantIsLegal reports if the pawn on s1 can capture en passant.
+// Both pawns leave the rank at once which can expose the king, so the
+// capture is played on the board to check it.
+func (g *moveGen) enPassantIsLegal(s1 Square) bool {
+	ep := g.pos.enPassantSquare
+	if ep == NoSquare || bbPawnAttacks[g.us][s1]&bbForSquare(ep) == 0 {
+		return false
+	}
+	capSq := ep - 8
+	if g.us == Black {
+		capSq = ep + 8
+	}
+	if g.b.bbForPiece(NewPiece(Pawn, g.them))&bbForSquare(capSq) == 0 {
+		return false
+	} else if g.pos.atomic || g.pos.antichess {
+		return true
+	}
+	m := &Move{s1: s1, s2: ep, tags: EnPassant}
+	captured := g.b.update(m)
+	legal := !g.b.isKingAttacked(g.us)
+	g.b.undo(m, captured)
+	return legal
+}
+
+func (g *moveGen) move(pt PieceType, s1, s2 Square, promo PieceType) Move {
+	m := Move{s1: s1, s2: s2, promo: promo}
+	if g.theirs&bbForSquare(s2) != 0 {
 		m.addTag(Capture)
-	} else if m.s2 == pos.enPassantSquare && p.Type() == Pawn {
+	} else if pt == Pawn && s2 == g.pos.enPassantSquare {
 		m.addTag(EnPassant)
 	}
-	// determine if in check after move (makes move invalid)
-	cp := pos.copy()
-	cp.board.update(m)
-	if isInCheck(cp) {
-		m.addTag(inCheck)
-	}
-	// determine if opponent in check after move
-	cp.turn = cp.turn.Other()
-	if isInCheck(cp) {
+	if g.pos.atomic {
+		g.atomicTags(&m)
+	} else if g.givesCheck(&m, pt) {
 		m.addTag(Check)
 	}
+	return m
 }
 
-func isInCheck(pos *Position) bool {
-	kingSq := pos.board.whiteKingSq
-	if pos.Turn() == Black {
-		kingSq = pos.board.blackKingSq
-	}
-	// king should only be missing in tests / examples
-	if kingSq == NoSquare {
+// givesCheck returns true if the move checks the enemy king.  Direct
+// and discovered checks are found with the precomputed masks, the
+// rare promotions, en passant captures and castles are played on the
+// board.
+func (g *moveGen) givesCheck(m *Move, pt PieceType) bool {
+	if g.enemyKingSq == NoSquare {
 		return false
 	}
-	return squaresAreAttacked(pos, kingSq)
+	if m.HasTag(KingSideCastle) || m.HasTag(QueenSideCastle) {
+		kingTo, rookFrom, rookTo := g.pos.castleSquares(m, g.us)
+		g.b.castle(g.us, m.s1, kingTo, rookFrom, rookTo)
+		check := g.b.isKingAttacked(g.them)
+		g.b.castle(g.us, kingTo, m.s1, rookTo, rookFrom)
+		return check
+	}
+	if m.promo != NoPieceType || m.HasTag(EnPassant) {
+		captured := g.b.update(m)
+		check := g.b.isKingAttacked(g.them)
+		g.b.undo(m, captured)
+		return check
+	}
+	s2BB := bbForSquare(m.s2)
+	if g.checkSqs[pt]&s2BB != 0 {
+		return true
+	}
+	return g.discoverers&bbForSquare(m.s1) != 0 && bbLine[g.enemyKingSq][m.s1]&s2BB == 0
 }
 
-func squaresAreAttacked(pos *Position, sqs ...Square) bool {
-	otherColor := pos.Turn().Other()
-	occ := ^pos.board.emptySqs
-	for _, sq := range sqs {
-		// hot path check to see if attack vector is possible
-		s2BB := pos.board.blackSqs
-		if pos.Turn() == Black {
-			s2BB = pos.board.whiteSqs
+func (g *moveGen) castles(moves []Move) []Move {
+	pos := g.pos
+	if g.checkers != 0 || g.kingSq == NoSquare || (!pos.chess960 && g.kingSq.File() != FileE) {
+		return moves
+	}
+	for _, side := range []Side{KingSide, QueenSide} {
+		if !pos.castleRights.CanCastle(g.us, side) {
+			continue
 		}
-		if ((diaAttack(occ, sq)|hvAttack(occ, sq))&s2BB)|(bbKnightMoves[sq]&s2BB) == 0 {
+		tag := KingSideCastle
+		if side == QueenSide {
+			tag = QueenSideCastle
+		}
+		m := Move{s1: g.kingSq, tags: tag}
+		kingTo, rookFrom, rookTo := pos.castleSquares(&m, g.us)
+		if g.kingSq.Rank() != rookFrom.Rank() || g.b.bbForPiece(NewPiece(Rook, g.us))&bbForSquare(rookFrom) == 0 {
 			continue
 		}
-		// check queen attack vector
-		queenBB := pos.board.bbForPiece(NewPiece(Queen, otherColor))
-		bb := (diaAttack(occ, sq) | hvAttack(occ, sq)) & queenBB
-		if bb != 0 {
-			return true
+		// the squares both pieces cross must be empty apart from the
+		// king and rook themselves
+		kingPath := bbBetween[g.kingSq][kingTo] | bbForSquare(kingTo)
+		rookPath := bbBetween[rookFrom][rookTo] | bbForSquare(rookTo)
+		movers := bbForSquare(g.kingSq) | bbForSquare(rookFrom)
+		if (kingPath|rookPath)&g.occupied&^movers != 0 {
+			continue
 		}
-		// check rook attack vector
-		rookBB := pos.board.bbForPiece(NewPiece(Rook, otherColor))
-		bb = hvAttack(occ, sq) & rookBB
-		if bb != 0 {
-			return true
+		// the king may not cross or land on an attacked square, the
+		// castling rook no longer blocks once it has moved
+		occ := g.occupied&^movers | bbForSquare(kingTo) | bbForSquare(rookTo)
+		if g.pathIsAttacked(kingPath, occ) {
+			continue
 		}
-		// check bishop attack vector
-		bishopBB := pos.board.bbForPiece(NewPiece(Bishop, otherColor))
-		bb = diaAttack(occ, sq) & bishopBB
-		if bb != 0 {
-			return true
+		m.s2 = kingTo
+		if pos.chess960 {
+			m.s2 = rookFrom
 		}
-		// check knight attack vector
-		knightBB := pos.board.bbForPiece(NewPiece(Knight, otherColor))
-		bb = bbKnightMoves[sq] & knightBB
-		if bb != 0 {
-			return true
+		if m = g.castle(m.s1, m.s2, tag); !m.HasTag(inCheck) {
+			moves = append(moves, m)
 		}
-		// check pawn attack vector
-		if pos.Turn() == White {
-			capRight := (pos.board.bbBlackPawn & ^bbFileH & ^bbRank1) << 7
-			capLeft := (pos.board.bbBlackPawn & ^bbFileA & ^bbRank1) << 9
-			bb = (capRight | capLeft) & bbForSquare(sq)
-			if bb != 0 {
-				return true
-			}
-		} else {
-			capRight := (pos.board.bbWhitePawn & ^bbFileH & ^bbRank8) >> 9
-			capLeft := (pos.board.bbWhitePawn & ^bbFileA & ^bbRank8) >> 7
-			bb = (capRight | capLeft) & bbForSquare(sq)
-			if bb != 0 {
-				return true
+	}
+	return moves
+}
+
+// drops appends the drops of the pieces in our pocket.  Drops can
+// only answer a check by blocking it and pawns can't be dropped on
+// the first or last rank.
+func (g *moveGen) drops(moves []Move) []Move {
+	pocket := g.pos.pockets[g.us]
+	empty := ^g.occupied & g.checkMask
+	for _, pt := range PieceTypes() {
+		if pt == King || pocket.counts[pt] == 0 {
+			continue
+		}
+		s2BB := empty
+		if pt == Pawn {
+			s2BB &^= bbRank1 | bbRank8
+		}
+		for s2BB != 0 {
+			s2 := s2BB.firstSquare()
+			s2BB ^= bbForSquare(s2)
+			m := Move{s1: NoSquare, s2: s2, drop: pt}
+			// a dropped piece can't uncover a check
+			if g.checkSqs[pt]&bbForSquare(s2) != 0 {
+				m.addTag(Check)
 			}
+			moves = append(moves, m)
 		}
-		// check king attack vector
-		kingBB := pos.board.bbForPiece(NewPiece(King, otherColor))
-		bb = bbKingMoves[sq] & kingBB
-		if bb != 0 {
+	}
+	return moves
+}
+
+// pathIsAttacked returns true if any square of the path is attacked
+// by the enemy given the occupancy.
+func (g *moveGen) pathIsAttacked(path, occupied Bitboard) bool {
+	if g.pos.atomic && g.enemyKingSq != NoSquare {
+		// squares next to the enemy king can't be attacked
+		path &^= bbKingMoves[g.enemyKingSq]
+	}
+	for ; path != 0; path &= path - 1 {
+		if g.b.attackersTo(path.lastSquare(), g.them, occupied) != 0 {
 			return true
 		}
 	}
 	return false
 }
 
-func bbForPossibleMoves(pos *Position, pt PieceType, sq Square) bitboard {
-	switch pt {
-	case King:
-		return bbKingMoves[sq]
-	case Queen:
-		return diaAttack(^pos.board.emptySqs, sq) | hvAttack(^pos.board.emptySqs, sq)
-	case Rook:
-		return hvAttack(^pos.board.emptySqs, sq)
-	case Bishop:
-		return diaAttack(^pos.board.emptySqs, sq)
-	case Knight:
-		return bbKnightMoves[sq]
-	case Pawn:
-		return pawnMoves(pos, sq)
+func (g *moveGen) castle(s1, s2 Square, tag MoveTag) Move {
+	m := Move{s1: s1, s2: s2, tags: tag}
+	if g.pos.atomic {
+		g.atomicTags(&m)
+	} else if g.givesCheck(&m, King) {
+		m.addTag(Check)
 	}
-	return bitboard(0)
+	return m
 }
 
-// TODO can calc isInCheck twice
-func castleMoves(pos *Position) []*Move {
-	moves := []*Move{}
-	kingSide := pos.castleRights.CanCastle(pos.Turn(), KingSide)
-	queenSide := pos.castleRights.CanCastle(pos.Turn(), QueenSide)
-	// white king side
-	if pos.turn == White && kingSide &&
-		(^pos.board.emptySqs&(bbForSquare(F1)|bbForSquare(G1))) == 0 &&
-		!squaresAreAttacked(pos, F1, G1) &&
-		!pos.inCheck {
-		m := &Move{s1: E1, s2: G1}
-		m.addTag(KingSideCastle)
-		addTags(m, pos)
-		moves = append(moves, m)
-	}
-	// white queen side
-	if pos.turn == White && queenSide &&
-		(^pos.board.emptySqs&(bbForSquare(B1)|bbForSquare(C1)|bbForSquare(D1))) == 0 &&
-		!squaresAreAttacked(pos, C1, D1) &&
-		!pos.inCheck {
-		m := &Move{s1: E1, s2: C1}
-		m.addTag(QueenSideCastle)
-		addTags(m, pos)
-		moves = append(moves, m)
-	}
-	// black king side
-	if pos.turn == Black && kingSide &&
-		(^pos.board.emptySqs&(bbForSquare(F8)|bbForSquare(G8))) == 0 &&
-		!squaresAreAttacked(pos, F8, G8) &&
-		!pos.inCheck {
-		m := &Move{s1: E8, s2: G8}
-		m.addTag(KingSideCastle)
-		addTags(m, pos)
-		moves = append(moves, m)
-	}
-	// black queen side
-	if pos.turn == Black && queenSide &&
-		(^pos.board.emptySqs&(bbForSquare(B8)|bbForSquare(C8)|bbForSquare(D8))) == 0 &&
-		!squaresAreAttacked(pos, C8, D8) &&
-		!pos.inCheck {
-		m := &Move{s1: E8, s2: C8}
-		m.addTag(QueenSideCastle)
-		addTags(m, pos)
-		moves = append(moves, m)
+// forcedCaptures keeps only the captures if there are any, as
+// capturing is compulsory in Antichess.
+func forcedCaptures(moves []Move) []Move {
+	captures := moves[:0]
+	for _, m := range moves {
+		if m.HasTag(Capture) || m.HasTag(EnPassant) {
+			captures = append(captures, m)
+		}
 	}
-	return moves
+	if len(captures) == 0 {
+		return moves
+	}
+	return captures
 }
 
-func pawnMoves(pos *Position, sq Square) bitboard {
-	bb := bbForSquare(sq)
-	var bbEnPassant bitboard
-	if pos.enPassantSquare != NoSquare {
-		bbEnPassant = bbForSquare(pos.enPassantSquare)
-	}
-	if pos.Turn() == White {
-		capRight := ((bb & ^bbFileH & ^bbRank8) >> 9) & (pos.board.blackSqs | bbEnPassant)
-		capLeft := ((bb & ^bbFileA & ^bbRank8) >> 7) & (pos.board.blackSqs | bbEnPassant)
-		upOne := ((bb & ^bbRank8) >> 8) & pos.board.emptySqs
-		upTwo := ((upOne & bbRank3) >> 8) & pos.board.emptySqs
-		return capRight | capLeft | upOne | upTwo
-	}
-	capRight := ((bb & ^bbFileH & ^bbRank1) << 7) & (pos.board.whiteSqs | bbEnPassant)
-	capLeft := ((bb & ^bbFileA & ^bbRank1) << 9) & (pos.board.whiteSqs | bbEnPassant)
-	upOne := ((bb & ^bbRank1) << 8) & pos.board.emptySqs
-	upTwo := ((upOne & bbRank6) << 8) & pos.board.emptySqs
-	return capRight | capLeft | upOne | upTwo
+func isInCheck(pos *Position) bool {
+	if pos.antichess {
+		return false
+	}
+	if pos.atomic {
+		return pos.board.atomicKingAttacked(pos.Turn())
+	}
+	return pos.board.isKingAttacked(pos.Turn())
 }
 
-func diaAttack(occupied bitboard, sq Square) bitboard {
-	pos := bbForSquare(sq)
-	dMask := bbDiagonals[sq]
-	adMask := bbAntiDiagonals[sq]
-	return linearAttack(occupied, pos, dMask) | linearAttack(occupied, pos, adMask)
+// isKingAttacked returns true if the king of the given color is attacked.
+func (b *Board) isKingAttacked(c Color) bool {
+	kingSq := b.whiteKingSq
+	if c == Black {
+		kingSq = b.blackKingSq
+	}
+	// king should only be missing in tests / examples
+	if kingSq == NoSquare {
+		return false
+	}
+	return b.attackersTo(kingSq, c.Other(), ^b.emptySqs) != 0
 }
 
-func hvAttack(occupied bitboard, sq Square) bitboard {
-	pos := bbForSquare(sq)
-	rankMask := bbRanks[Square(sq).Rank()]
-	fileMask := bbFiles[Square(sq).File()]
-	return linearAttack(occupied, pos, rankMask) | linearAttack(occupied, pos, fileMask)
+func squaresAreAttacked(pos *Position, sqs ...Square) bool {
+	return pos.board.squaresAreAttacked(pos.Turn().Other(), sqs...)
+}
+
+// squaresAreAttacked returns true if any of the squares is attacked by
+// the pieces of the given color.
+func (b *Board) squaresAreAttacked(by Color, sqs ...Square) bool {
+	occ := ^b.emptySqs
+	for _, sq := range sqs {
+		if b.attackersTo(sq, by, occ) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// attackersTo returns the pieces of the given color attacking the
+// square with the given occupancy.
+func (b *Board) attackersTo(sq Square, by Color, occupied Bitboard) Bitboard {
+	queens := b.bbForPiece(NewPiece(Queen, by))
+	rooks := b.bbForPiece(NewPiece(Rook, by)) | queens
+	bishops := b.bbForPiece(NewPiece(Bishop, by)) | queens
+	return (bbPawnAttacks[by.Other()][sq] & b.bbForPiece(NewPiece(Pawn, by))) |
+		(bbKnightMoves[sq] & b.bbForPiece(NewPiece(Knight, by))) |
+		(bbKingMoves[sq] & b.bbForPiece(NewPiece(King, by))) |
+		(rookAttacks(occupied, sq) & rooks) |
+		(bishopAttacks(occupied, sq) & bishops)
+}
+
+// attackedSquares returns the squares attacked by the pieces of the
+// given color with the given occupancy.
+func (b *Board) attackedSquares(by Color, occupied Bitboard) Bitboard {
+	pawns := b.bbForPiece(NewPiece(Pawn, by))
+	var bb Bitboard
+	if by == White {
+		bb = ((pawns & ^bbFileH) >> 9) | ((pawns & ^bbFileA) >> 7)
+	} else {
+		bb = ((pawns & ^bbFileH) << 7) | ((pawns & ^bbFileA) << 9)
+	}
+	for s := b.bbForPiece(NewPiece(Knight, by)); s != 0; s &= s - 1 {
+		bb |= bbKnightMoves[s.lastSquare()]
+	}
+	queens := b.bbForPiece(NewPiece(Queen, by))
+	for s := b.bbForPiece(NewPiece(Bishop, by)) | queens; s != 0; s &= s - 1 {
+		bb |= bishopAttacks(occupied, s.lastSquare())
+	}
+	for s := b.bbForPiece(NewPiece(Rook, by)) | queens; s != 0; s &= s - 1 {
+		bb |= rookAttacks(occupied, s.lastSquare())
+	}
+	for s := b.bbForPiece(NewPiece(King, by)); s != 0; s &= s - 1 {
+		bb |= bbKingMoves[s.lastSquare()]
+	}
+	return bb
 }
 
-func linearAttack(occupied, pos, mask bitboard) bitboard {
-	oInMask := occupied & mask
-	return ((oInMask - 2*pos) ^ (oInMask.Reverse() - 2*pos.Reverse()).Reverse()) & mask
+// blockers returns the pieces that are the only piece between the
+// square and a sliding piece of the given color aimed at it.
+func (b *Board) blockers(sq Square, by Color, occupied Bitboard) Bitboard {
+	queens := b.bbForPiece(NewPiece(Queen, by))
+	snipers := (rookAttacks(0, sq) & (b.bbForPiece(NewPiece(Rook, by)) | queens)) |
+		(bishopAttacks(0, sq) & (b.bbForPiece(NewPiece(Bishop, by)) | queens))
+	var bb Bitboard
+	for ; snipers != 0; snipers &= snipers - 1 {
+		between := bbBetween[sq][snipers.lastSquare()] & occupied
+		if between != 0 && between&(between-1) == 0 {
+			bb |= between
+		}
+	}
+	return bb
 }
 
 const (
-	bbFileA bitboard = 9259542123273814144
-	bbFileB bitboard = 4629771061636907072
-	bbFileC bitboard = 2314885530818453536
-	bbFileD bitboard = 1157442765409226768
-	bbFileE bitboard = 578721382704613384
-	bbFileF bitboard = 289360691352306692
-	bbFileG bitboard = 144680345676153346
-	bbFileH bitboard = 72340172838076673
-
-	bbRank1 bitboard = 18374686479671623680
-	bbRank2 bitboard = 71776119061217280
-	bbRank3 bitboard = 280375465082880
-	bbRank4 bitboard = 1095216660480
-	bbRank5 bitboard = 4278190080
-	bbRank6 bitboard = 16711680
-	bbRank7 bitboard = 65280
-	bbRank8 bitboard = 255
+	bbFileA Bitboard = 9259542123273814144
+	bbFileB Bitboard = 4629771061636907072
+	bbFileC Bitboard = 2314885530818453536
+	bbFileD Bitboard = 1157442765409226768
+	bbFileE Bitboard = 578721382704613384
+	bbFileF Bitboard = 289360691352306692
+	bbFileG Bitboard = 144680345676153346
+	bbFileH Bitboard = 72340172838076673
+
+	bbRank1 Bitboard = 18374686479671623680
+	bbRank2 Bitboard = 71776119061217280
+	bbRank3 Bitboard = 280375465082880
+	bbRank4 Bitboard = 1095216660480
+	bbRank5 Bitboard = 4278190080
+	bbRank6 Bitboard = 16711680
+	bbRank7 Bitboard = 65280
+	bbRank8 Bitboard = 255
+
+	bbDarkSquares Bitboard = 12273903644374837845
 )
 
-func bbForSquare(sq Square) bitboard {
+func bbForSquare(sq Square) Bitboard {
 	return bbSquares[sq]
 }
 
 var (
-	bbFiles = [8]bitboard{bbFileA, bbFileB, bbFileC, bbFileD, bbFileE, bbFileF, bbFileG, bbFileH}
-	bbRanks = [8]bitboard{bbRank1, bbRank2, bbRank3, bbRank4, bbRank5, bbRank6, bbRank7, bbRank8}
-
-	bbDiagonals = [64]bitboard{9241421688590303745, 4620710844295151872, 2310355422147575808, 1155177711073755136, 577588855528488960, 288794425616760832, 144396663052566528, 72057594037927936, 36099303471055874, 9241421688590303745, 4620710844295151872, 2310355422147575808, 1155177711073755136, 577588855528488960, 288794425616760832, 144396663052566528, 141012904183812, 36099303471055874, 9241421688590303745, 4620710844295151872, 2310355422147575808, 1155177711073755136, 577588855528488960, 288794425616760832, 550831656968, 141012904183812, 36099303471055874, 9241421688590303745, 4620710844295151872, 2310355422147575808, 1155177711073755136, 577588855528488960, 2151686160, 550831656968, 141012904183812, 36099303471055874, 9241421688590303745, 4620710844295151872, 2310355422147575808, 1155177711073755136, 8405024, 2151686160, 550831656968, 141012904183812, 36099303471055874, 9241421688590303745, 4620710844295151872, 2310355422147575808, 32832, 8405024, 2151686160, 550831656968, 141012904183812, 36099303471055874, 9241421688590303745, 4620710844295151872, 128, 32832, 8405024, 2151686160, 550831656968, 141012904183812, 36099303471055874, 9241421688590303745}
+	bbFiles = [8]Bitboard{bbFileA, bbFileB, bbFileC, bbFileD, bbFileE, bbFileF, bbFileG, bbFileH}
+	bbRanks = [8]Bitboard{bbRank1, bbRank2, bbRank3, bbRank4, bbRank5, bbRank6, bbRank7, bbRank8}
 
-	bbAntiDiagonals = [64]bitboard{9223372036854775808, 4647714815446351872, 2323998145211531264, 1161999622361579520, 580999813328273408, 290499906672525312, 145249953336295424, 72624976668147840, 4647714815446351872, 2323998145211531264, 1161999622361579520, 580999813328273408, 290499906672525312, 145249953336295424, 72624976668147840, 283691315109952, 2323998145211531264, 1161999622361579520, 580999813328273408, 290499906672525312, 145249953336295424, 72624976668147840, 283691315109952, 1108169199648, 1161999622361579520, 580999813328273408, 290499906672525312, 145249953336295424, 72624976668147840, 283691315109952, 1108169199648, 4328785936, 580999813328273408, 290499906672525312, 145249953336295424, 72624976668147840, 283691315109952, 1108169199648, 4328785936, 16909320, 290499906672525312, 145249953336295424, 72624976668147840, 283691315109952, 1108169199648, 4328785936, 16909320, 66052, 145249953336295424, 72624976668147840, 283691315109952, 1108169199648, 4328785936, 16909320, 66052, 258, 72624976668147840, 283691315109952, 1108169199648, 4328785936, 16909320, 66052, 258, 1}
-
-	bbKnightMoves = [64]bitboard{9077567998918656, 4679521487814656, 38368557762871296, 19184278881435648, 9592139440717824, 4796069720358912, 2257297371824128, 1128098930098176, 2305878468463689728, 1152939783987658752, 9799982666336960512, 4899991333168480256, 2449995666584240128, 1224997833292120064, 576469569871282176, 288234782788157440, 4620693356194824192, 11533718717099671552, 5802888705324613632, 2901444352662306816, 1450722176331153408, 725361088165576704, 362539804446949376, 145241105196122112, 18049583422636032, 45053588738670592, 22667534005174272, 11333767002587136, 5666883501293568, 2833441750646784, 1416171111120896, 567348067172352, 70506185244672, 175990581010432, 88545054707712, 44272527353856, 22136263676928, 11068131838464, 5531918402816, 2216203387392, 275414786112, 687463207072, 345879119952, 172939559976, 86469779988, 43234889994, 21609056261, 8657044482, 1075839008, 2685403152, 1351090312, 675545156, 337772578, 168886289, 84410376, 33816580, 4202496, 10489856, 5277696, 2638848, 1319424, 659712, 329728, 132096}
+	bbKnightMoves = [64]Bitboard{9077567998918656, 4679521487814656, 38368557762871296, 19184278881435648, 9592139440717824, 4796069720358912, 2257297371824128, 1128098930098176, 2305878468463689728, 1152939783987658752, 9799982666336960512, 4899991333168480256, 2449995666584240128, 1224997833292120064, 576469569871282176, 288234782788157440, 4620693356194824192, 11533718717099671552, 5802888705324613632, 2901444352662306816, 1450722176331153408, 725361088165576704, 362539804446949376, 145241105196122112, 18049583422636032, 45053588738670592, 22667534005174272, 11333767002587136, 5666883501293568, 2833441750646784, 1416171111120896, 567348067172352, 70506185244672, 175990581010432, 88545054707712, 44272527353856, 22136263676928, 11068131838464, 5531918402816, 2216203387392, 275414786112, 687463207072, 345879119952, 172939559976, 86469779988, 43234889994, 21609056261, 8657044482, 1075839008, 2685403152, 1351090312, 675545156, 337772578, 168886289, 84410376, 33816580, 4202496, 10489856, 5277696, 2638848, 1319424, 659712, 329728, 132096}
 
 	// bbBishopMoves = [64]bitboard{18049651735527937, 45053622886727936, 22667548931719168, 11334324221640704, 5667164249915392, 2833579985862656, 1416240237150208, 567382630219904, 4611756524879479810, 11529391036782871041, 5764696068147249408, 2882348036221108224, 1441174018118909952, 720587009051099136, 360293502378066048, 144117404414255168, 2323857683139004420, 1197958188344280066, 9822351133174399489, 4911175566595588352, 2455587783297826816, 1227793891648880768, 577868148797087808, 288793334762704928, 1161999073681608712, 581140276476643332, 326598935265674242, 9386671504487645697, 4693335752243822976, 2310639079102947392, 1155178802063085600, 577588851267340304, 580999811184992272, 290500455356698632, 145390965166737412, 108724279602332802, 9241705379636978241, 4620711952330133792, 2310355426409252880, 1155177711057110024, 290499906664153120, 145249955479592976, 72625527495610504, 424704217196612, 36100411639206946, 9241421692918565393, 4620710844311799048, 2310355422147510788, 145249953336262720, 72624976676520096, 283693466779728, 1659000848424, 141017232965652, 36099303487963146, 9241421688590368773, 4620710844295151618, 72624976668147712, 283691315142656, 1108177604608, 6480472064, 550848566272, 141012904249856, 36099303471056128, 9241421688590303744}
 
@@ -332,13 +550,48 @@ var (
 
 	// bbQueenMoves = [64]bitboard{9205534180971414145, 13826139127340482880, 16100553540994408480, 17237620560088797200, 17806153522019305480, 18090419998706369540, 18232552689433215490, 18303478847064064385, 13871017173176583298, 16194909420462031425, 8133343319517438240, 4102559721436811280, 2087167920257370120, 1079472019650937860, 575624067208594050, 287670746360127809, 11583398706901190788, 5827868887957914690, 12137446670713758241, 6068863523097809168, 3034571949281478664, 1517426162373248132, 722824471891812930, 361411684042608929, 10421541192660455560, 5210911883574396996, 2641485286422881314, 10544115227674579473, 5272058161445620104, 2600000831312176196, 1299860225776030242, 649930110732142865, 9840541934442029200, 4920271519124312136, 2460276499189639204, 1266167048752878738, 9820426766351346249, 4910072647826412836, 2455035776296487442, 1227517888139822345, 9550042029937901728, 4775021017124823120, 2387511058326581416, 1157867469641037908, 614821794359483434, 9530782384287059477, 4765391190004401930, 2382695595002168069, 9404792076610076608, 4702396038313459680, 2315169224285282160, 1157444424410132280, 578862399937640220, 325459994840333070, 9386102034266586375, 4693051017133293059, 9332167099941961855, 4630054752952049855, 2314886638996058335, 1157442771889699055, 578721933553179895, 289501704256556795, 180779649147209725, 9313761861428380670}
 
-	bbKingMoves = [64]bitboard{4665729213955833856, 11592265440851656704, 5796132720425828352, 2898066360212914176, 1449033180106457088, 724516590053228544, 362258295026614272, 144959613005987840, 13853283560024178688, 16186183351374184448, 8093091675687092224, 4046545837843546112, 2023272918921773056, 1011636459460886528, 505818229730443264, 216739030602088448, 54114388906344448, 63227278716305408, 31613639358152704, 15806819679076352, 7903409839538176, 3951704919769088, 1975852459884544, 846636838289408, 211384331665408, 246981557485568, 123490778742784, 61745389371392, 30872694685696, 15436347342848, 7718173671424, 3307175149568, 825720045568, 964771708928, 482385854464, 241192927232, 120596463616, 60298231808, 30149115904, 12918652928, 3225468928, 3768639488, 1884319744, 942159872, 471079936, 235539968, 117769984, 50463488, 12599488, 14721248, 7360624, 3680312, 1840156, 920078, 460039, 197123, 49216, 57504, 28752, 14376, 7188, 3594, 1797, 770}
+	bbKingMoves = [64]Bitboard{4665729213955833856, 11592265440851656704, 5796132720425828352, 2898066360212914176, 1449033180106457088, 724516590053228544, 362258295026614272, 144959613005987840, 13853283560024178688, 16186183351374184448, 8093091675687092224, 4046545837843546112, 2023272918921773056, 1011636459460886528, 505818229730443264, 216739030602088448, 54114388906344448, 63227278716305408, 31613639358152704, 15806819679076352, 7903409839538176, 3951704919769088, 1975852459884544, 846636838289408, 211384331665408, 246981557485568, 123490778742784, 61745389371392, 30872694685696, 15436347342848, 7718173671424, 3307175149568, 825720045568, 964771708928, 482385854464, 241192927232, 120596463616, 60298231808, 30149115904, 12918652928, 3225468928, 3768639488, 1884319744, 942159872, 471079936, 235539968, 117769984, 50463488, 12599488, 14721248, 7360624, 3680312, 1840156, 920078, 460039, 197123, 49216, 57504, 28752, 14376, 7188, 3594, 1797, 770}
+
+	bbSquares = [64]Bitboard{}
 
-	bbSquares = [64]bitboard{}
+	// bbPawnAttacks are the squares a pawn of the color attacks from a square
+	bbPawnAttacks [3][64]Bitboard
+	// bbLine is the whole rank, file or diagonal through two squares
+	bbLine [64][64]Bitboard
+	// bbBetween are the squares strictly between two squares on a line
+	bbBetween [64][64]Bitboard
+	// bbRays are the squares from a square to the edge in a direction
+	bbRays [8][64]Bitboard
 )
 
 func init() {
 	for sq := 0; sq < 64; sq++ {
-		bbSquares[sq] = bitboard(uint64(1) << (uint8(63) - uint8(sq)))
+		bbSquares[sq] = Bitboard(uint64(1) << (uint8(63) - uint8(sq)))
+	}
+	initMagics(&rookMagics, bbRookMagics, rookDirections)
+	initMagics(&bishopMagics, bbBishopMagics, bishopDirections)
+	for sq := 0; sq < 64; sq++ {
+		bb := bbSquares[sq]
+		bbPawnAttacks[White][sq] = ((bb & ^bbFileH) >> 9) | ((bb & ^bbFileA) >> 7)
+		bbPawnAttacks[Black][sq] = ((bb & ^bbFileH) << 7) | ((bb & ^bbFileA) << 9)
+		for d := North; d <= NorthWest; d++ {
+			for s := bb.Shift(d); s != 0; s = s.Shift(d) {
+				bbRays[d][sq] |= s
+			}
+		}
+	}
+	for s1 := Square(0); s1 < numOfSquaresInBoard; s1++ {
+		for s2 := Square(0); s2 < numOfSquaresInBoard; s2++ {
+			bb1, bb2 := bbForSquare(s1), bbForSquare(s2)
+			if s1 == s2 {
+				continue
+			} else if rookAttacks(0, s1)&bb2 != 0 {
+				bbLine[s1][s2] = (rookAttacks(0, s1) & rookAttacks(0, s2)) | bb1 | bb2
+				bbBetween[s1][s2] = rookAttacks(bb2, s1) & rookAttacks(bb1, s2)
+			} else if bishopAttacks(0, s1)&bb2 != 0 {
+				bbLine[s1][s2] = (bishopAttacks(0, s1) & bishopAttacks(0, s2)) | bb1 | bb2
+				bbBetween[s1][s2] = bishopAttacks(bb2, s1) & bishopAttacks(bb1, s2)
+			}
+		}
 	}
 }
@@ -4,28 +4,114 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"unicode"
 )
 
+// FENMode selects how strictly a FEN is read.
+type FENMode int
+
+const (
+	// FENDefault reads well formed six field FENs without checking
+	// the position is legal.  It is the mode of the FEN option.
+	FENDefault FENMode = iota
+	// FENStrict also rejects adjacent digits in a rank, missing or
+	// extra kings, pawns on the first or last rank, the side not to
+	// move being in check, castle rights that don't match the king
+	// and rooks, en passant squares no pawn just crossed and half
+	// move clocks above the number of half moves played.
+	FENStrict
+	// FENLenient also accepts EPD style FENs of four fields, and any
+	// EPD operations after them, filling in the clocks as 0 1.  Fields
+	// may be separated by any amount of white space.
+	FENLenient
+	// FENChess960 reads the FEN as a Chess960 position whose KQkq
+	// castle rights are the outermost rooks as in X-FEN.  Other modes
+	// only read Chess960 positions from file letter castle rights.
+	FENChess960
+)
+
+// FENField names a field of a FEN.
+type FENField int
+
+const (
+	// FENRecord is the FEN as a whole, ex. for a wrong number of fields.
+	FENRecord FENField = iota
+	// FENPlacement is the piece placement field.
+	FENPlacement
+	// FENTurn is the side to move field.
+	FENTurn
+	// FENCastling is the castle rights field.
+	FENCastling
+	// FENEnPassant is the en passant square field.
+	FENEnPassant
+	// FENHalfMove is the half move clock field.
+	FENHalfMove
+	// FENFullMove is the full move number field.
+	FENFullMove
+)
+
+var fenFieldNames = [...]string{"record", "placement", "turn", "castling", "en passant", "halfmove clock", "fullmove number"}
+
+// String implements the fmt.Stringer interface.
+func (f FENField) String() string {
+	if f < FENRecord || int(f) >= len(fenFieldNames) {
+		return "FENField(" + strconv.Itoa(int(f)) + ")"
+	}
+	return fenFieldNames[f]
+}
+
+// FENError is returned for FENs that can't be read.  It names the
+// field, the offending token and the reason.
+type FENError struct {
+	Field  FENField
+	Token  string
+	Reason string
+}
+
+// Error implements the error interface.
+func (e *FENError) Error() string {
+	return fmt.Sprintf("chess: fen invalid %s %q: %s", e.Field, e.Token, e.Reason)
+}
+
+// DecodeFEN returns the position of the FEN read in the given mode.
+// Errors are of type *FENError.
+func DecodeFEN(fen string, mode FENMode) (*Position, error) {
+	pos, err := decodeFENMode(fen, mode)
+	if err != nil {
+		return nil, err
+	}
+	pos.inCheck = isInCheck(pos)
+	return pos, nil
+}
+
 // Decodes FEN notation into a GameState.  An error is returned
 // if there is a parsing error.  FEN notation format:
 // rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1
 func decodeFEN(fen string) (*Position, error) {
+	return decodeFENMode(fen, FENDefault)
+}
+
+func decodeFENMode(fen string, mode FENMode) (*Position, error) {
 	fen = strings.TrimSpace(fen)
 	parts := strings.Split(fen, " ")
+	if mode == FENLenient {
+		parts = lenientFENFields(fen)
+	}
 	if len(parts) != 6 {
-		return nil, fmt.Errorf("chess: fen invalid notation %s must have 6 sections", fen)
+		return nil, &FENError{Field: FENRecord, Token: fen, Reason: "must have 6 fields"}
 	}
-	b, err := fenBoard(parts[0])
+	strict := mode == FENStrict
+	b, err := fenBoard(parts[0], strict)
 	if err != nil {
 		return nil, err
 	}
 	turn, ok := fenTurnMap[parts[1]]
 	if !ok {
-		return nil, fmt.Errorf("chess: fen invalid turn %s", parts[1])
+		return nil, &FENError{Field: FENTurn, Token: parts[1], Reason: "must be w or b"}
 	}
-	rights, err := formCastleRights(parts[2])
+	rights, rooks, chess960, err := formCastleRights(parts[2], b, mode == FENChess960)
 	if err != nil {
-		return nil, err
+		return nil, &FENError{Field: FENCastling, Token: parts[2], Reason: "must be -, KQkq or file letters without repeats"}
 	}
 	sq, err := formEnPassant(parts[3])
 	if err != nil {
@@ -33,32 +119,98 @@ func decodeFEN(fen string) (*Position, error) {
 	}
 	halfMoveClock, err := strconv.Atoi(parts[4])
 	if err != nil || halfMoveClock < 0 {
-		return nil, fmt.Errorf("chess: fen invalid half move clock %s", parts[4])
+		return nil, &FENError{Field: FENHalfMove, Token: parts[4], Reason: "must be a number of at least 0"}
 	}
 	moveCount, err := strconv.Atoi(parts[5])
 	if err != nil || moveCount < 1 {
-		return nil, fmt.Errorf("chess: fen invalid move count %s", parts[5])
+		return nil, &FENError{Field: FENFullMove, Token: parts[5], Reason: "must be a number of at least 1"}
+	}
+	if strict {
+		if err := strictFENChecks(parts, b, turn, rights, rooks, chess960, sq, halfMoveClock, moveCount); err != nil {
+			return nil, err
+		}
 	}
-	return &Position{
+	pos := &Position{
 		board:           b,
 		turn:            turn,
 		castleRights:    rights,
 		enPassantSquare: sq,
 		halfMoveClock:   halfMoveClock,
 		moveCount:       moveCount,
-	}, nil
+		chess960:        chess960,
+		castleRooks:     rooks,
+	}
+	pos.hash = pos.zobrist()
+	return pos, nil
+}
+
+// lenientFENFields splits the FEN on white space and fills in the
+// clocks of EPD style FENs, dropping any EPD operations.
+func lenientFENFields(fen string) []string {
+	fields := strings.Fields(fen)
+	if len(fields) < 4 {
+		return fields
+	}
+	isNumber := func(i int) bool {
+		if i >= len(fields) {
+			return false
+		}
+		_, err := strconv.Atoi(fields[i])
+		return err == nil
+	}
+	switch {
+	case !isNumber(4):
+		return append(fields[:4:4], "0", "1")
+	case !isNumber(5):
+		return append(fields[:5:5], "1")
+	}
+	return fields
+}
+
+// strictFENChecks returns an error if the position read from the
+// FEN's fields can't arise in a game.
+func strictFENChecks(parts []string, b *Board, turn Color, rights CastleRights, rooks [4]Square, chess960 bool, ep Square, halfMoveClock, moveCount int) error {
+	for _, c := range []Color{White, Black} {
+		switch b.bbForPiece(NewPiece(King, c)).count() {
+		case 0:
+			return &FENError{Field: FENPlacement, Token: parts[0], Reason: "missing " + strings.ToLower(c.Name()) + " king"}
+		case 1:
+		default:
+			return &FENError{Field: FENPlacement, Token: parts[0], Reason: "more than one " + strings.ToLower(c.Name()) + " king"}
+		}
+	}
+	if pawns := (b.bbWhitePawn | b.bbBlackPawn) & (bbRank1 | bbRank8); pawns != 0 {
+		return &FENError{Field: FENPlacement, Token: parts[0], Reason: "pawn on " + pawns.Squares()[0].String()}
+	}
+	if b.isKingAttacked(turn.Other()) {
+		return &FENError{Field: FENPlacement, Token: parts[0], Reason: "side not to move is in check"}
+	}
+	if !castleRightsMatch(b, rights, rooks, chess960) {
+		return &FENError{Field: FENCastling, Token: parts[2], Reason: "king or rook not on its castling square"}
+	}
+	if !enPassantPossible(b, turn, ep) {
+		return &FENError{Field: FENEnPassant, Token: parts[3], Reason: "no pawn just crossed the square"}
+	}
+	plies := 2 * (moveCount - 1)
+	if turn == Black {
+		plies++
+	}
+	if halfMoveClock > plies {
+		return &FENError{Field: FENHalfMove, Token: parts[4], Reason: "more than the " + strconv.Itoa(plies) + " half moves played"}
+	}
+	return nil
 }
 
 // generates board from fen format: rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR
-func fenBoard(boardStr string) (*Board, error) {
+func fenBoard(boardStr string, strict bool) (*Board, error) {
 	rankStrs := strings.Split(boardStr, "/")
 	if len(rankStrs) != 8 {
-		return nil, fmt.Errorf("chess: fen invalid board %s", boardStr)
+		return nil, &FENError{Field: FENPlacement, Token: boardStr, Reason: "must have 8 ranks"}
 	}
 	m := map[Square]Piece{}
 	for i, rankStr := range rankStrs {
 		rank := Rank(7 - i)
-		fileMap, err := fenFormRank(rankStr)
+		fileMap, err := fenFormRank(rankStr, strict)
 		if err != nil {
 			return nil, err
 		}
@@ -69,55 +221,117 @@ func fenBoard(boardStr string) (*Board, error) {
 	return NewBoard(m), nil
 }
 
-func fenFormRank(rankStr string) (map[File]Piece, error) {
+func fenFormRank(rankStr string, strict bool) (map[File]Piece, error) {
 	count := 0
 	m := map[File]Piece{}
-	err := fmt.Errorf("chess: fen invalid rank %s", rankStr)
+	digit := false
 	for _, r := range rankStr {
-		c := fmt.Sprintf("%c", r)
-		piece := fenPieceMap[c]
-		if piece == NoPiece {
-			skip, err := strconv.Atoi(c)
-			if err != nil {
-				return nil, err
+		piece := fenPieceMap[string(r)]
+		switch {
+		case piece != NoPiece:
+			m[File(count)] = piece
+			count++
+			digit = false
+		case r >= '1' && r <= '8':
+			if strict && digit {
+				return nil, &FENError{Field: FENPlacement, Token: rankStr, Reason: "adjacent digits in rank"}
 			}
-			count += skip
-			continue
+			count += int(r - '0')
+			digit = true
+		default:
+			return nil, &FENError{Field: FENPlacement, Token: rankStr, Reason: fmt.Sprintf("invalid character %q", r)}
+		}
+		if count > numOfSquaresInRow {
+			return nil, &FENError{Field: FENPlacement, Token: rankStr, Reason: "rank longer than 8 squares"}
 		}
-		m[File(count)] = piece
-		count++
 	}
-	if count != 8 {
-		return nil, err
+	if count != numOfSquaresInRow {
+		return nil, &FENError{Field: FENPlacement, Token: rankStr, Reason: "rank shorter than 8 squares"}
 	}
 	return m, nil
 }
 
-func formCastleRights(castleStr string) (CastleRights, error) {
-	// check for duplicates aka. KKkq right now is valid
-	for _, s := range []string{"K", "Q", "k", "q", "-"} {
-		if strings.Count(castleStr, s) > 1 {
-			return "-", fmt.Errorf("chess: fen invalid castle rights %s", castleStr)
-		}
+// formCastleRights reads standard, X-FEN and Shredder-FEN castle
+// rights and returns them as KQkq together with the rook squares
+// of the rights.  The position is a Chess960 position if a right
+// refers to a rook or king off the standard squares or if the
+// rights use Shredder-FEN file letters.
+func formCastleRights(castleStr string, b *Board, chess960 bool) (CastleRights, [4]Square, bool, error) {
+	rooks := standardCastleRooks
+	err := fmt.Errorf("chess: fen invalid castle rights %s", castleStr)
+	if castleStr == "-" {
+		return "-", rooks, chess960, nil
 	}
+	// file letters are only written for Chess960 positions
+	chess960 = chess960 || strings.ContainsAny(castleStr, "ABCDEFGHabcdefgh")
+	var rights uint8
 	for _, r := range castleStr {
-		c := fmt.Sprintf("%c", r)
-		switch c {
-		case "K", "Q", "k", "q", "-":
+		c := White
+		if unicode.IsLower(r) {
+			c = Black
+		}
+		rank, kingSq := Rank1, b.whiteKingSq
+		if c == Black {
+			rank, kingSq = Rank8, b.blackKingSq
+		}
+		rookBB := b.bbForPiece(NewPiece(Rook, c)) & bbRanks[rank]
+		var i int
+		var sq Square
+		switch unicode.ToUpper(r) {
+		case 'K':
+			i, sq = 0, NewSquare(FileH, rank)
+			if chess960 && kingSq.Rank() == rank {
+				// X-FEN: the outermost rook on the king side
+				for f := FileH; f > kingSq.File(); f-- {
+					if rookBB&bbForSquare(NewSquare(f, rank)) != 0 {
+						sq = NewSquare(f, rank)
+						break
+					}
+				}
+			}
+		case 'Q':
+			i, sq = 1, NewSquare(FileA, rank)
+			if chess960 && kingSq.Rank() == rank {
+				// X-FEN: the outermost rook on the queen side
+				for f := FileA; f < kingSq.File(); f++ {
+					if rookBB&bbForSquare(NewSquare(f, rank)) != 0 {
+						sq = NewSquare(f, rank)
+						break
+					}
+				}
+			}
+		case 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H':
+			// Shredder-FEN and X-FEN: the rook on the file
+			f := File(unicode.ToUpper(r) - 'A')
+			if kingSq.Rank() != rank || f == kingSq.File() {
+				return "-", rooks, false, err
+			}
+			i, sq = 1, NewSquare(f, rank)
+			if f > kingSq.File() {
+				i = 0
+			}
 		default:
-			return "-", fmt.Errorf("chess: fen invalid castle rights %s", castleStr)
+			return "-", rooks, false, err
+		}
+		if c == Black {
+			i += 2
 		}
+		if rights&(1<<i) != 0 {
+			return "-", rooks, false, err
+		}
+		rights |= 1 << i
+		rooks[i] = sq
 	}
-	return CastleRights(castleStr), nil
+	return castleRightsStrs[rights], rooks, chess960, nil
 }
 
 func formEnPassant(enPassant string) (Square, error) {
 	if enPassant == "-" {
 		return NoSquare, nil
 	}
-	sq := strToSquareMap[enPassant]
-	if sq == NoSquare || !(sq.Rank() == Rank3 || sq.Rank() == Rank6) {
-		return NoSquare, fmt.Errorf("chess: fen invalid En Passant square %s", enPassant)
+	sq, ok := strToSquareMap[enPassant]
+	if !ok || !(sq.Rank() == Rank3 || sq.Rank() == Rank6) {
+		return NoSquare, &FENError{Field: FENEnPassant, Token: enPassant, Reason: "must be - or a square on the third or sixth rank"}
 	}
 	return sq, nil
 }
@@ -143,3 +357,36 @@ var (
 		"b": Black,
 	}
 )
+
+// xfenCastleRights returns the castle rights in X-FEN: K and Q
+// stand for the outermost rook on either side of the king and a
+// file letter is used for any other rook.
+func (pos *Position) xfenCastleRights() string {
+	if !pos.chess960 || pos.castleRights == "-" {
+		return pos.castleRights.String()
+	}
+	sb := strings.Builder{}
+	for _, r := range pos.castleRights {
+		i := strings.IndexRune("KQkq", r)
+		c, rank, kingSq := White, Rank1, pos.board.whiteKingSq
+		if i >= 2 {
+			c, rank, kingSq = Black, Rank8, pos.board.blackKingSq
+		}
+		sq := pos.castleRooks[i]
+		outer := bbForSquare(NewSquare(FileH, rank)) | bbBetween[NewSquare(FileH, rank)][sq]
+		if i%2 == 1 {
+			outer = bbForSquare(NewSquare(FileA, rank)) | bbBetween[NewSquare(FileA, rank)][sq]
+		}
+		outer &^= bbForSquare(sq)
+		if kingSq.Rank() != rank || pos.board.bbForPiece(NewPiece(Rook, c))&outer == 0 {
+			sb.WriteRune(r)
+			continue
+		}
+		letter := rune('A' + sq.File())
+		if c == Black {
+			letter = unicode.ToLower(letter)
+		}
+		sb.WriteRune(letter)
+	}
+	return sb.String()
+}
@@ -84,3 +84,83 @@ func TestInvalidFENs(t *testing.T) {
 		}
 	}
 }
+
+func TestStrictFENs(t *testing.T) {
+	for _, f := range validFENs {
+		if _, err := DecodeFEN(f, FENStrict); err != nil {
+			t.Fatalf("expected %s to be strictly valid but got %v", f, err)
+		}
+	}
+	tests := []struct {
+		fen   string
+		field FENField
+		token string
+	}{
+		{"rnbqkbnr/pppppppp/71/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", FENPlacement, "71"},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQQBNR w KQkq - 0 1", FENPlacement, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQQBNR"},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNP w kq - 0 1", FENPlacement, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNP"},
+		{"4k3/8/8/8/8/8/4R3/4K3 w - - 0 1", FENPlacement, "4k3/8/8/8/8/8/4R3/4K3"},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBN1 w KQkq - 0 1", FENCastling, "KQkq"},
+		{"4k3/8/8/8/8/8/8/R2K3R w KQ - 0 1", FENCastling, "KQ"},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq e6 0 1", FENEnPassant, "e6"},
+		{"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e3 0 1", FENEnPassant, "e3"},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 5 2", FENHalfMove, "5"},
+	}
+	for _, test := range tests {
+		_, err := DecodeFEN(test.fen, FENStrict)
+		fenErr, ok := err.(*FENError)
+		if !ok {
+			t.Fatalf("expected a *FENError for %s but got %v", test.fen, err)
+		}
+		if fenErr.Field != test.field || fenErr.Token != test.token {
+			t.Fatalf("expected the %s field %q to be at fault in %s but got %v", test.field, test.token, test.fen, err)
+		}
+		if _, err := DecodeFEN(test.fen, FENDefault); err != nil && test.field != FENPlacement {
+			t.Fatalf("expected %s to be read by default but got %v", test.fen, err)
+		}
+	}
+}
+
+func TestFENErrors(t *testing.T) {
+	_, err := DecodeFEN("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KKkq - 0 1", FENDefault)
+	expected := `chess: fen invalid castling "KKkq": must be -, KQkq or file letters without repeats`
+	if err == nil || err.Error() != expected {
+		t.Fatalf("expected %s but got %v", expected, err)
+	}
+	if _, err := FEN("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR x KQkq - 0 1"); err == nil {
+		t.Fatal("expected an error for the turn")
+	} else if fenErr, ok := err.(*FENError); !ok || fenErr.Field != FENTurn {
+		t.Fatalf("expected a turn *FENError but got %v", err)
+	}
+}
+
+func TestLenientFENs(t *testing.T) {
+	tests := []struct {
+		fen      string
+		expected string
+	}{
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -", INITIAL_FEN_POSITION},
+		{"  rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR   w KQkq - bm e4; id \"start\";", INITIAL_FEN_POSITION},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 3", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 3 1"},
+		{"7k/8/8/8/8/8/8/R6K  b - - 4 20", "7k/8/8/8/8/8/8/R6K b - - 4 20"},
+	}
+	for _, test := range tests {
+		pos, err := DecodeFEN(test.fen, FENLenient)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pos.String() != test.expected {
+			t.Fatalf("expected %s but got %s", test.expected, pos.String())
+		}
+		if _, err := DecodeFEN(test.fen, FENDefault); err == nil {
+			t.Fatalf("expected %s to be rejected by default", test.fen)
+		}
+	}
+	opt, err := FENWithMode("8/8/8/8/8/8/8/R3K2k w - -", FENLenient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g := NewGame(opt); g.Position().String() != "8/8/8/8/8/8/8/R3K2k w - - 0 1" {
+		t.Fatalf("expected the clocks filled in but got %s", g.Position())
+	}
+}
@@ -60,6 +60,25 @@ const (
 	InsufficientMaterial
 	// Check indicates that the current position in the game is in check.
 	InCheck
+	// ThreeChecks indicates that the game was won by giving the third
+	// check in Three-check.
+	ThreeChecks
+	// KingOfTheHill indicates that the game was won by moving the king
+	// to the centre in King of the Hill.
+	KingOfTheHill
+	// Explosion indicates that the game was won by exploding the enemy
+	// king in Atomic.
+	Explosion
+	// AllPiecesCaptured indicates that the side to move has no pieces
+	// left, which wins in Antichess and loses in Horde.
+	AllPiecesCaptured
+	// Timeout indicates that a player ran out of time.
+	Timeout
+	// Abandoned indicates that a player abandoned the game.
+	Abandoned
+	// RulesInfraction indicates that a player was penalized for
+	// breaking the rules.
+	RulesInfraction
 )
 
 // TagPair represents metadata in a key value pairing used in the PGN format.
@@ -72,13 +91,16 @@ type TagPair struct {
 type Game struct {
 	notation             Notation
 	tagPairs             []*TagPair
-	moves                []*Move
-	comments             [][]string
-	positions            []*Position
+	root                 *MoveNode
+	nodes                []*MoveNode
 	pos                  *Position
 	outcome              Outcome
 	method               Method
 	ignoreAutomaticDraws bool
+	nagSymbols           bool
+	// escapes are the PGN escape lines read with the game
+	escapes     []string
+	timeControl TimeControl
 }
 
 type Input struct {
@@ -119,19 +141,59 @@ func PGN(input *Input) (func(*Game), error) {
 // prior moves, the move list will be empty.  The returned
 // function is designed to be used in the NewGame constructor.
 // An error is returned if there is a problem parsing the FEN data.
+//
+// The FEN must be a standard FEN.  It is decoded with the rules of the
+// game's variant if an earlier WithVariant option set one that can read
+// it, and played by the standard rules otherwise.  Use VariantFEN for
+// FENs with a variant's extensions, Ex. pockets or check counts.
 func FEN(fen string) (func(*Game), error) {
-	pos, err := decodeFEN(fen)
+	return FENWithMode(fen, FENDefault)
+}
+
+// FENWithMode is like FEN but reads the FEN in the given mode.  Errors
+// are of type *FENError.
+func FENWithMode(fen string, mode FENMode) (func(*Game), error) {
+	pos, err := decodeFENMode(fen, mode)
+	if err != nil {
+		return nil, err
+	}
+	return func(g *Game) {
+		if v := g.pos.variant; v != nil {
+			if vpos, err := v.DecodeFEN(fen); err == nil {
+				vpos.setVariant(v)
+				g.setPosition(vpos)
+				return
+			}
+		}
+		g.setPosition(pos.copy())
+	}, nil
+}
+
+// VariantFEN is like FEN but decodes the FEN with the variant's rules
+// and plays the game by them, as WithVariant followed by FEN does.
+// An error is returned if the FEN isn't valid for the variant.
+func VariantFEN(v Variant, fen string) (func(*Game), error) {
+	pos, err := v.DecodeFEN(fen)
 	if err != nil {
 		return nil, err
 	}
+	pos.setVariant(v)
 	return func(g *Game) {
-		pos.inCheck = isInCheck(pos)
-		g.pos = pos
-		g.positions = []*Position{pos}
-		g.updatePosition()
+		g.setPosition(pos.copy())
 	}, nil
 }
 
+// setPosition starts the game from the position and sets the Variant
+// tag of positions that aren't standard.
+func (g *Game) setPosition(pos *Position) {
+	pos.inCheck = isInCheck(pos)
+	g.setRoot(&MoveNode{pos: pos})
+	if v := pos.Variant(); !isStandard(v) {
+		g.AddTagPair("Variant", v.String())
+	}
+	g.updatePosition()
+}
+
 // TagPairs returns a function that sets the tag pairs
 // to the given value.  The returned function is designed
 // to be used in the NewGame constructor.
@@ -166,15 +228,12 @@ func (g *Game) SetNotation(n Notation) {
 // opening position.  Options can be given to configure
 // the game's initial state.
 func NewGame(options ...func(*Game)) *Game {
-	pos := StartingPosition()
 	game := &Game{
-		notation:  AlgebraicNotation{},
-		moves:     []*Move{},
-		pos:       pos,
-		positions: []*Position{pos},
-		outcome:   NoOutcome,
-		method:    NoMethod,
+		notation: AlgebraicNotation{},
+		outcome:  NoOutcome,
+		method:   NoMethod,
 	}
+	game.setRoot(&MoveNode{pos: StartingPosition()})
 	for _, f := range options {
 		if f != nil {
 			f(game)
@@ -183,39 +242,40 @@ func NewGame(options ...func(*Game)) *Game {
 	return game
 }
 
+// Perft counts the leaf nodes of the move generation tree of the
+// given depth for the FEN.  Moves are made and taken back in place
+// on a single position and each depth reuses its move buffer so
+// counting doesn't allocate per move.
 func Perft(fen string, depth int) (int, time.Duration, error) {
 	start := time.Now()
-	options, err := FEN(fen)
+	pos, err := decodeFEN(fen)
 	if err != nil {
 		return 0, time.Millisecond, fmt.Errorf("wrong fen notation %s", fen)
 	}
-	g := NewGame(options)
-	result, err := g.perft0(depth)
-	return result, time.Since(start), err
+	pos.inCheck = isInCheck(pos)
+	if depth < 0 {
+		return 0, time.Since(start), fmt.Errorf("chess: invalid perft depth %d", depth)
+	}
+	bufs := make([][]Move, depth+1)
+	return perft(pos, depth, bufs), time.Since(start), nil
 }
 
-func (g *Game) perft0(depth int) (int, error) {
-	moves := g.ValidMoves()
+func perft(pos *Position, depth int, bufs [][]Move) int {
+	if depth == 0 {
+		return 1
+	}
+	moves := legalMoves(pos, bufs[depth][:0], false)
+	bufs[depth] = moves
 	if depth == 1 {
-		return len(moves), nil
+		return len(moves)
 	}
 	sum := 0
-	for _, move := range moves {
-		err := g.Move(move)
-		if err != nil {
-			return 0, err
-		}
-		result, err := g.perft0(depth - 1)
-		if err != nil {
-			return 0, err
-		}
-		sum += result
-		err = g.UndoMove()
-		if err != nil {
-			return 0, err
-		}
+	for i := range moves {
+		u := pos.MakeMove(&moves[i])
+		sum += perft(pos, depth-1, bufs)
+		pos.UnmakeMove(&moves[i], u)
 	}
-	return sum, nil
+	return sum
 }
 
 //	public default long perft(int depth) {
@@ -235,16 +295,8 @@ func (g *Game) perft0(depth int) (int, error) {
 // Move updates the game with the given move.  An error is returned
 // if the move is invalid or the game has already been completed.
 func (g *Game) Move(m *Move) error {
-	valid := MoveSlice(g.ValidMoves()).find(m)
-	if valid == nil {
-		return fmt.Errorf("chess: invalid move %s", m)
-	}
-	g.moves = append(g.moves, valid)
-	g.pos = g.pos.Update(valid)
-	g.comments = append(g.comments, []string{})
-	g.positions = append(g.positions, g.pos)
-	g.updatePosition()
-	return nil
+	_, err := g.addMove(g.nodes[len(g.nodes)-1], m)
+	return err
 }
 
 func (g *Game) Notation() Notation {
@@ -269,19 +321,32 @@ func (g *Game) ValidMoves() []*Move {
 	return moves
 }
 
-// Positions returns the position history of the game.
+// Positions returns the position history of the game's main line.
 func (g *Game) Positions() []*Position {
-	return append([]*Position(nil), g.positions...)
+	positions := make([]*Position, len(g.nodes))
+	for i, n := range g.nodes {
+		positions[i] = n.pos
+	}
+	return positions
 }
 
-// Moves returns the move history of the game.
+// Moves returns the move history of the game's main line.
 func (g *Game) Moves() []*Move {
-	return append([]*Move(nil), g.moves...)
+	moves := make([]*Move, len(g.nodes)-1)
+	for i, n := range g.nodes[1:] {
+		moves[i] = n.move
+	}
+	return moves
 }
 
-// Comments returns the comments for the game indexed by moves.
+// Comments returns the comments for the game's main line indexed
+// by moves.
 func (g *Game) Comments() [][]string {
-	return append([][]string(nil), g.comments...)
+	comments := make([][]string, len(g.nodes)-1)
+	for i, n := range g.nodes[1:] {
+		comments[i] = n.comments
+	}
+	return comments
 }
 
 // TagPairs returns the game's tag pairs.
@@ -303,6 +368,11 @@ func (g *Game) Evaluate() int {
 	return g.pos.Evaluate()
 }
 
+// Variant returns the variant whose rules the game is played by.
+func (g *Game) Variant() Variant {
+	return g.pos.Variant()
+}
+
 // Method returns the method in which the outcome occurred.
 func (g *Game) Method() Method {
 	return g.method
@@ -425,61 +495,52 @@ func (g *Game) RemoveTagPair(k string) bool {
 }
 
 // MoveHistory is a move's result from Game's MoveHistory method.
-// It contains the move itself, any comments, and the pre and post
-// positions.
+// It contains the move itself, any comments and NAGs, and the pre
+// and post positions.
 type MoveHistory struct {
 	PrePosition  *Position
 	PostPosition *Position
 	Move         *Move
 	Comments     []string
+	NAGs         []NAG
 }
 
-// MoveHistory returns the moves in order along with the pre and post
-// positions and any comments.
+// MoveHistory returns the moves of the main line in order along with
+// the pre and post positions, any comments and NAGs.
 func (g *Game) MoveHistory() []*MoveHistory {
 	h := []*MoveHistory{}
-	for i, p := range g.positions {
-		if i == 0 {
-			continue
-		}
-		m := g.moves[i-1]
-		c := g.comments[i-1]
+	for _, n := range g.nodes[1:] {
 		mh := &MoveHistory{
-			PrePosition:  g.positions[i-1],
-			PostPosition: p,
-			Move:         m,
-			Comments:     c,
+			PrePosition:  n.parent.pos,
+			PostPosition: n.pos,
+			Move:         n.move,
+			Comments:     n.comments,
+			NAGs:         n.nags,
 		}
 		h = append(h, mh)
 	}
 	return h
 }
 
+// UndoMove takes back the last move of the main line along with
+// the variations replacing it.
 func (g *Game) UndoMove() error {
-	if len(g.moves) <= 0 {
+	if len(g.nodes) <= 1 {
 		return fmt.Errorf("game has no moves to undo")
 	}
-	length := len(g.moves)
-	g.moves = g.moves[:length-1]
-	g.positions = g.positions[:length]
-	g.pos = g.positions[len(g.positions)-1]
-	g.comments = g.comments[:length-1]
-	g.updatePosition()
-	return nil
+	return g.UndoMoves(1)
 }
 
-// UndoMoves undos the last n moves
+// UndoMoves undos the last n moves of the main line along with
+// the variations replacing the first of them.
 func (g *Game) UndoMoves(n int) error {
-	if len(g.moves) < n {
+	if len(g.nodes)-1 < n {
 		return fmt.Errorf("cannot undo %d moves", n)
 	}
-
-	if len(g.comments) == len(g.moves) {
-		g.comments = g.comments[:len(g.comments)-n]
-	}
-	g.moves = g.moves[:len(g.moves)-n]
-	g.positions = g.positions[:len(g.positions)-n]
-	g.pos = g.positions[len(g.positions)-1]
+	g.nodes = g.nodes[:len(g.nodes)-n]
+	last := g.nodes[len(g.nodes)-1]
+	last.children = nil
+	g.pos = last.pos
 	g.updatePosition()
 	return nil
 }
@@ -487,14 +548,11 @@ func (g *Game) UndoMoves(n int) error {
 func (g *Game) updatePosition() {
 	method := g.pos.Status()
 	switch method {
-	case Stalemate:
-		g.method = Stalemate
-		g.outcome = Draw
-	case Checkmate:
-		g.method = Checkmate
-		g.outcome = WhiteWon
-		if g.pos.Turn() == White {
-			g.outcome = BlackWon
+	case NoMethod, InCheck, InsufficientMaterial:
+	default:
+		if outcome := g.pos.Variant().Outcome(g.pos, method); outcome != NoOutcome {
+			g.method = method
+			g.outcome = outcome
 		}
 	}
 	if g.outcome != NoOutcome {
@@ -514,7 +572,7 @@ func (g *Game) updatePosition() {
 	}
 
 	// insufficient material creates automatic draw
-	if !g.ignoreAutomaticDraws && !g.pos.board.HasSufficientMaterial() {
+	if !g.ignoreAutomaticDraws && method == InsufficientMaterial {
 		g.outcome = Draw
 		g.method = InsufficientMaterial
 	}
@@ -522,31 +580,37 @@ func (g *Game) updatePosition() {
 
 func (g *Game) copy(game *Game) {
 	g.tagPairs = game.TagPairs()
-	g.moves = game.Moves()
-	g.positions = game.Positions()
-	g.pos = game.pos
+	g.setRoot(game.root.clone(nil))
 	g.outcome = game.outcome
 	g.method = game.method
-	g.comments = game.Comments()
+	g.escapes = append([]string(nil), game.escapes...)
+	g.timeControl = game.timeControl
 }
 
 func (g *Game) Clone() *Game {
-	return &Game{
-		tagPairs:  g.TagPairs(),
-		notation:  g.notation,
-		moves:     g.Moves(),
-		positions: g.Positions(),
-		//		comments:  g.Comments(),
-		pos:     g.pos,
-		outcome: g.outcome,
-		method:  g.method,
+	cp := &Game{
+		tagPairs:    g.TagPairs(),
+		notation:    g.notation,
+		outcome:     g.outcome,
+		method:      g.method,
+		nagSymbols:  g.nagSymbols,
+		escapes:     append([]string(nil), g.escapes...),
+		timeControl: g.timeControl,
 	}
+	cp.setRoot(g.root.clone(nil))
+	return cp
 }
 
 func (g *Game) numOfRepetitions() int {
+	// positions before the last capture or pawn move can't repeat
+	// unless captured pieces return as Crazyhouse drops
+	first := len(g.nodes) - 1 - g.pos.halfMoveClock
+	if g.pos.crazyhouse {
+		first = 0
+	}
 	count := 0
-	for _, pos := range g.Positions() {
-		if g.pos.samePosition(pos) {
+	for i := len(g.nodes) - 1; i >= 0 && i >= first; i-- {
+		if g.nodes[i].pos.hash == g.pos.hash {
 			count++
 		}
 	}
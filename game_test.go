@@ -540,6 +540,11 @@ func TestPerft(t *testing.T) {
 		{fen: INITIAL_FEN_POSITION, depth: 3, expected: 8902},
 		{fen: INITIAL_FEN_POSITION, depth: 4, expected: 197281},
 		{fen: INITIAL_FEN_POSITION, depth: 5, expected: 4865609},
+		{fen: "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", depth: 4, expected: 4085603},
+		{fen: "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", depth: 6, expected: 11030083},
+		{fen: "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1", depth: 4, expected: 422333},
+		{fen: "rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8", depth: 4, expected: 2103487},
+		{fen: "r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10", depth: 4, expected: 3894594},
 	}
 
 	for _, perfTest := range mateTests {
@@ -549,7 +554,7 @@ func TestPerft(t *testing.T) {
 			t.Fatalf("Perft failed for depth %d with err %s", perfTest.depth, err)
 		}
 		if result != perfTest.expected {
-			t.Fatalf("Perft failed for depth %d: expected %d, got %d", 4, perfTest.expected, result)
+			t.Fatalf("Perft failed for %s depth %d: expected %d, got %d", perfTest.fen, perfTest.depth, perfTest.expected, result)
 		}
 		t.Logf("Time spend on perf %s with depth %d: %f\n", perfTest.fen, perfTest.depth, duration.Seconds())
 	}
@@ -0,0 +1,54 @@
+package chess
+
+// hordeFEN is the starting position of Horde.
+const hordeFEN = "rnbqkbnr/pppppppp/8/1PP2PP1/PPPPPPPP/PPPPPPPP/PPPPPPPP/PPPPPPPP w kq - 0 1"
+
+// HordeVariant is Horde: White has 36 pawns and no king against
+// Black's standard army.  White's pawns on the first rank may move
+// two squares, though they can't be captured en passant when they
+// do.  Black wins by capturing all of White's pieces and White by
+// checkmating Black.
+type HordeVariant struct{}
+
+// String implements the Variant interface.
+func (HordeVariant) String() string {
+	return "Horde"
+}
+
+// StartingPosition implements the Variant interface.
+func (HordeVariant) StartingPosition() *Position {
+	pos, _ := decodeFEN(hordeFEN)
+	pos.setVariant(HordeVariant{})
+	return pos
+}
+
+// DecodeFEN implements the Variant interface.
+func (HordeVariant) DecodeFEN(fen string) (*Position, error) {
+	pos, err := decodeFEN(fen)
+	if err != nil {
+		return nil, err
+	}
+	pos.setVariant(HordeVariant{})
+	return pos, nil
+}
+
+// EncodeFEN implements the Variant interface.
+func (HordeVariant) EncodeFEN(pos *Position) string {
+	return pos.fen()
+}
+
+// ValidMoves implements the Variant interface.
+func (HordeVariant) ValidMoves(pos *Position) []*Move {
+	return engine{}.CalcMoves(pos, false)
+}
+
+// Status implements the Variant interface.  AllPiecesCaptured is
+// returned once White has no pieces left.
+func (HordeVariant) Status(pos *Position) Method {
+	return engine{}.Status(pos)
+}
+
+// Outcome implements the Variant interface.
+func (HordeVariant) Outcome(pos *Position, method Method) Outcome {
+	return variantOutcome(pos, method)
+}
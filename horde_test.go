@@ -0,0 +1,62 @@
+package chess
+
+import "testing"
+
+func TestHorde(t *testing.T) {
+	g := NewGame(WithVariant(HordeVariant{}))
+	for _, s := range []string{"e5", "d6", "exd6", "Qxd6"} {
+		if err := g.MoveStr(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := "rnb1kbnr/ppp1pppp/3q4/1PP2PP1/PPPP1PPP/PPPPPPPP/PPPPPPPP/PPPPPPPP w kq - 0 3"
+	if g.Position().String() != expected {
+		t.Fatalf("expected %s but got %s", expected, g.Position().String())
+	}
+	g2 := NewGame()
+	if err := g2.UnmarshalText([]byte(g.String())); err != nil {
+		t.Fatal(err)
+	}
+	if g2.Position().String() != expected || g2.Variant().String() != "Horde" {
+		t.Fatalf("expected %s but got %s", expected, g2.Position().String())
+	}
+}
+
+func TestHordeDoubleStep(t *testing.T) {
+	pos := unsafeVariantFEN(HordeVariant{}, "4k3/8/8/8/8/8/8/P7 w - - 0 1")
+	moves := pos.ValidMoves()
+	if len(moves) != 2 {
+		t.Fatalf("expected a1a2 and a1a3 but got %v", moves)
+	}
+	pos = pos.Update(moves[1])
+	if pos.EnPassantSquare() != NoSquare {
+		t.Fatalf("expected no en passant square after %s but got %s", moves[1], pos.EnPassantSquare())
+	}
+}
+
+func TestHordeOutcome(t *testing.T) {
+	fen, err := FEN("4k3/8/8/8/8/8/8/Pr6 b - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGame(WithVariant(HordeVariant{}), fen)
+	if g.Outcome() != NoOutcome {
+		t.Fatalf("expected the game to go on but got %s by %s", g.Outcome(), g.Method())
+	}
+	if err := g.MoveStr("Rxa1"); err != nil {
+		t.Fatal(err)
+	}
+	if g.Outcome() != BlackWon || g.Method() != AllPiecesCaptured {
+		t.Fatalf("expected black to win by capturing all pieces but got %s by %s", g.Outcome(), g.Method())
+	}
+}
+
+func TestHordePerft(t *testing.T) {
+	res, err := PerftDivide(hordeFEN, 4, PerftVariant(HordeVariant{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total.Nodes != 23310 {
+		t.Fatalf("expected 23310 nodes but got %d", res.Total.Nodes)
+	}
+}
@@ -0,0 +1,51 @@
+package chess
+
+// bbHill are the centre squares d4, e4, d5 and e5.
+var bbHill = (bbFileD | bbFileE) & (bbRank4 | bbRank5)
+
+// KingOfTheHillVariant is King of the Hill: standard chess that is
+// also won by moving the king to one of the four centre squares.
+type KingOfTheHillVariant struct{}
+
+// String implements the Variant interface.
+func (KingOfTheHillVariant) String() string {
+	return "King of the Hill"
+}
+
+// StartingPosition implements the Variant interface.
+func (KingOfTheHillVariant) StartingPosition() *Position {
+	pos := StartingPosition()
+	pos.setVariant(KingOfTheHillVariant{})
+	return pos
+}
+
+// DecodeFEN implements the Variant interface.
+func (KingOfTheHillVariant) DecodeFEN(fen string) (*Position, error) {
+	pos, err := decodeFEN(fen)
+	if err != nil {
+		return nil, err
+	}
+	pos.setVariant(KingOfTheHillVariant{})
+	return pos, nil
+}
+
+// EncodeFEN implements the Variant interface.
+func (KingOfTheHillVariant) EncodeFEN(pos *Position) string {
+	return pos.fen()
+}
+
+// ValidMoves implements the Variant interface.
+func (KingOfTheHillVariant) ValidMoves(pos *Position) []*Move {
+	return engine{}.CalcMoves(pos, false)
+}
+
+// Status implements the Variant interface.  KingOfTheHill is
+// returned once a king stands on the hill.
+func (KingOfTheHillVariant) Status(pos *Position) Method {
+	return engine{}.Status(pos)
+}
+
+// Outcome implements the Variant interface.
+func (KingOfTheHillVariant) Outcome(pos *Position, method Method) Outcome {
+	return variantOutcome(pos, method)
+}
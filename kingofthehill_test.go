@@ -0,0 +1,36 @@
+package chess
+
+import "testing"
+
+func TestKingOfTheHill(t *testing.T) {
+	fen, err := FEN("4k3/8/8/8/8/4K3/8/8 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGame(WithVariant(KingOfTheHillVariant{}), fen)
+	if g.Outcome() != NoOutcome {
+		t.Fatalf("expected bare kings to play on but got %s by %s", g.Outcome(), g.Method())
+	}
+	if err := g.MoveStr("Ke4"); err != nil {
+		t.Fatal(err)
+	}
+	if g.Outcome() != WhiteWon || g.Method() != KingOfTheHill {
+		t.Fatalf("expected white to win by king of the hill but got %s by %s", g.Outcome(), g.Method())
+	}
+	if len(g.ValidMoves()) != 0 {
+		t.Fatal("expected no moves once a king reached the hill")
+	}
+}
+
+func TestKingOfTheHillPGN(t *testing.T) {
+	pgn := `[Variant "King of the Hill"]
+
+1. e3 e6 2. Ke2 Ke7 3. Kd3 Kd6 4. Ke4 1-0`
+	g := NewGame()
+	if err := g.UnmarshalText([]byte(pgn)); err != nil {
+		t.Fatal(err)
+	}
+	if g.Variant().String() != "King of the Hill" || g.Method() != KingOfTheHill {
+		t.Fatalf("expected a king of the hill win but got %s by %s", g.Variant(), g.Method())
+	}
+}
@@ -0,0 +1,189 @@
+package chess
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// PieceLetters are the letters a language writes the pieces with in
+// algebraic notation.  Pawns have none.
+type PieceLetters struct {
+	King   string
+	Queen  string
+	Rook   string
+	Bishop string
+	Knight string
+}
+
+func (l PieceLetters) letters() []string {
+	return []string{l.King, l.Queen, l.Rook, l.Bishop, l.Knight}
+}
+
+// decoding returns the English letters of the language's letters.
+func (l PieceLetters) decoding() map[string]string {
+	m := map[string]string{}
+	for i, letter := range l.letters() {
+		m[letter] = englishPieceLetters.letters()[i]
+	}
+	return m
+}
+
+var englishPieceLetters = PieceLetters{King: "K", Queen: "Q", Rook: "R", Bishop: "B", Knight: "N"}
+
+var (
+	pieceLettersMu sync.RWMutex
+	pieceLetters   = map[string]PieceLetters{
+		"en": englishPieceLetters,
+		"de": {King: "K", Queen: "D", Rook: "T", Bishop: "L", Knight: "S"},
+		"fr": {King: "R", Queen: "D", Rook: "T", Bishop: "F", Knight: "C"},
+		"es": {King: "R", Queen: "D", Rook: "T", Bishop: "A", Knight: "C"},
+		"ru": {King: "Кр", Queen: "Ф", Rook: "Л", Bishop: "С", Knight: "К"},
+	}
+)
+
+// RegisterPieceLetters adds or replaces the piece letters of a language
+// for LocalizedNotation.  The letters must be distinct and can't be
+// empty or contain the files, ranks or symbols of algebraic notation.
+//
+//	chess.RegisterPieceLetters("nl", chess.PieceLetters{
+//		King: "K", Queen: "D", Rook: "T", Bishop: "L", Knight: "P",
+//	})
+func RegisterPieceLetters(language string, letters PieceLetters) error {
+	seen := map[string]bool{}
+	for _, letter := range letters.letters() {
+		if letter == "" || strings.ContainsAny(letter, "abcdefgh12345678xO0-=+#@ ") {
+			return fmt.Errorf("chess: invalid piece letter %q for language %s", letter, language)
+		}
+		if seen[letter] {
+			return fmt.Errorf("chess: duplicate piece letter %q for language %s", letter, language)
+		}
+		seen[letter] = true
+	}
+	pieceLettersMu.Lock()
+	defer pieceLettersMu.Unlock()
+	pieceLetters[language] = letters
+	return nil
+}
+
+func lookupPieceLetters(language string) (PieceLetters, bool) {
+	pieceLettersMu.RLock()
+	defer pieceLettersMu.RUnlock()
+	letters, ok := pieceLetters[language]
+	return letters, ok
+}
+
+// LocalizedNotation is algebraic notation with the piece letters of a
+// language, given as its ISO 639-1 code.  German (de), French (fr),
+// Spanish (es), Russian (ru) and English (en) are built in and others
+// can be added with RegisterPieceLetters.  Moves of an unknown language
+// are encoded in English and can't be decoded.
+// Examples in German: Sf3, Dxd7+, e8=D, O-O
+type LocalizedNotation struct {
+	Language string
+}
+
+// String implements the fmt.Stringer interface and returns
+// the notation's name.
+func (n LocalizedNotation) String() string {
+	return fmt.Sprintf("Algebraic Notation (%s)", n.Language)
+}
+
+// Encode implements the Encoder interface.
+func (n LocalizedNotation) Encode(pos *Position, m *Move) string {
+	letters, ok := lookupPieceLetters(n.Language)
+	if !ok {
+		letters = englishPieceLetters
+	}
+	return encodePieceLetters(AlgebraicNotation{}.Encode(pos, m), letters)
+}
+
+// Decode implements the Decoder interface.
+func (n LocalizedNotation) Decode(pos *Position, s string) (*Move, error) {
+	letters, ok := lookupPieceLetters(n.Language)
+	if !ok {
+		return nil, fmt.Errorf("chess: unknown notation language %s", n.Language)
+	}
+	return AlgebraicNotation{}.Decode(pos, decodePieceLetters(s, letters.decoding()))
+}
+
+var (
+	figurines        = PieceLetters{King: "♔", Queen: "♕", Rook: "♖", Bishop: "♗", Knight: "♘"}
+	figurineDecoding = map[string]string{
+		"♔": "K", "♕": "Q", "♖": "R", "♗": "B", "♘": "N", "♙": "",
+		"♚": "K", "♛": "Q", "♜": "R", "♝": "B", "♞": "N", "♟": "",
+	}
+)
+
+// FigurineNotation is algebraic notation with the pieces drawn as
+// figurines.  Both colors' figurines and pawn figurines are decoded.
+// Examples: ♘f3, ♕xd7+, e8=♕, O-O
+type FigurineNotation struct{}
+
+// String implements the fmt.Stringer interface and returns
+// the notation's name.
+func (FigurineNotation) String() string {
+	return "Figurine Algebraic Notation"
+}
+
+// Encode implements the Encoder interface.
+func (FigurineNotation) Encode(pos *Position, m *Move) string {
+	return encodePieceLetters(AlgebraicNotation{}.Encode(pos, m), figurines)
+}
+
+// Decode implements the Decoder interface.
+func (FigurineNotation) Decode(pos *Position, s string) (*Move, error) {
+	return AlgebraicNotation{}.Decode(pos, decodePieceLetters(s, figurineDecoding))
+}
+
+// encodePieceLetters replaces the English piece letters of a move in
+// algebraic notation, which are its only capitals besides castling's.
+func encodePieceLetters(san string, letters PieceLetters) string {
+	if strings.HasPrefix(san, "O-O") {
+		return san
+	}
+	sb := strings.Builder{}
+	for i := 0; i < len(san); i++ {
+		switch san[i] {
+		case 'K':
+			sb.WriteString(letters.King)
+		case 'Q':
+			sb.WriteString(letters.Queen)
+		case 'R':
+			sb.WriteString(letters.Rook)
+		case 'B':
+			sb.WriteString(letters.Bishop)
+		case 'N':
+			sb.WriteString(letters.Knight)
+		default:
+			sb.WriteByte(san[i])
+		}
+	}
+	return sb.String()
+}
+
+// decodePieceLetters replaces the letters of a move with the English
+// ones they map to, preferring the longest letter where they overlap
+// like the Russian К and Кр.
+func decodePieceLetters(s string, letters map[string]string) string {
+	if strings.HasPrefix(strings.ReplaceAll(s, "0", "O"), "O-O") {
+		return s
+	}
+	sb := strings.Builder{}
+	for i := 0; i < len(s); {
+		best := ""
+		for letter := range letters {
+			if len(letter) > len(best) && strings.HasPrefix(s[i:], letter) {
+				best = letter
+			}
+		}
+		if best == "" {
+			sb.WriteByte(s[i])
+			i++
+			continue
+		}
+		sb.WriteString(letters[best])
+		i += len(best)
+	}
+	return sb.String()
+}
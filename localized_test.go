@@ -0,0 +1,104 @@
+package chess
+
+import (
+	"strings"
+	"testing"
+)
+
+// localizedMoves reach a position with every piece type moving, a
+// capture, a check, both castles and a promotion.
+var localizedMoves = []string{"e4", "d5", "exd5", "Qxd5", "Nc3", "Qa5", "Nf3", "Bg4", "Be2", "Nc6",
+	"O-O", "O-O-O", "d4", "Bxf3", "Bxf3", "Rxd4", "Qxd4", "Nxd4", "Kh1", "Nxf3", "gxf3", "Qh5", "Rg1", "Qxf3+"}
+
+func TestLocalizedNotation(t *testing.T) {
+	tests := []struct {
+		n        Notation
+		expected string
+	}{
+		{LocalizedNotation{Language: "de"}, "1. e4 d5 2. exd5 Dxd5 3. Sc3 Da5 4. Sf3 Lg4 5. Le2 Sc6 6. O-O O-O-O 7. d4 Lxf3 8. Lxf3 Txd4 9. Dxd4 Sxd4 10. Kh1 Sxf3 11. gxf3 Dh5 12. Tg1 Dxf3+ *"},
+		{LocalizedNotation{Language: "fr"}, "1. e4 d5 2. exd5 Dxd5 3. Cc3 Da5 4. Cf3 Fg4 5. Fe2 Cc6 6. O-O O-O-O 7. d4 Fxf3 8. Fxf3 Txd4 9. Dxd4 Cxd4 10. Rh1 Cxf3 11. gxf3 Dh5 12. Tg1 Dxf3+ *"},
+		{LocalizedNotation{Language: "es"}, "1. e4 d5 2. exd5 Dxd5 3. Cc3 Da5 4. Cf3 Ag4 5. Ae2 Cc6 6. O-O O-O-O 7. d4 Axf3 8. Axf3 Txd4 9. Dxd4 Cxd4 10. Rh1 Cxf3 11. gxf3 Dh5 12. Tg1 Dxf3+ *"},
+		{LocalizedNotation{Language: "ru"}, "1. e4 d5 2. exd5 Фxd5 3. Кc3 Фa5 4. Кf3 Сg4 5. Сe2 Кc6 6. O-O O-O-O 7. d4 Сxf3 8. Сxf3 Лxd4 9. Фxd4 Кxd4 10. Крh1 Кxf3 11. gxf3 Фh5 12. Лg1 Фxf3+ *"},
+		{FigurineNotation{}, "1. e4 d5 2. exd5 ♕xd5 3. ♘c3 ♕a5 4. ♘f3 ♗g4 5. ♗e2 ♘c6 6. O-O O-O-O 7. d4 ♗xf3 8. ♗xf3 ♖xd4 9. ♕xd4 ♘xd4 10. ♔h1 ♘xf3 11. gxf3 ♕h5 12. ♖g1 ♕xf3+ *"},
+	}
+	for _, test := range tests {
+		game := NewGame()
+		for _, m := range localizedMoves {
+			if err := game.MoveStr(m); err != nil {
+				t.Fatal(err)
+			}
+		}
+		game.SetNotation(test.n)
+		s := strings.TrimSpace(game.String())
+		if s != test.expected {
+			t.Fatalf("expected %s\n%s\nbut got\n%s", test.n, test.expected, s)
+		}
+		cp, err := decodePGN(UseNotation(test.n), s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cp.Position().String() != game.Position().String() {
+			t.Fatalf("expected %s to decode to %s but got %s", test.n, game.Position(), cp.Position())
+		}
+		moves := strings.Fields(s)
+		played := NewGame(UseNotation(test.n))
+		for _, m := range moves[:len(moves)-1] {
+			if strings.HasSuffix(m, ".") {
+				continue
+			}
+			if err := played.MoveStr(m); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if played.Position().String() != game.Position().String() {
+			t.Fatalf("expected %s moves to reach %s but got %s", test.n, game.Position(), played.Position())
+		}
+	}
+}
+
+func TestFigurineNotationDecoding(t *testing.T) {
+	pos := unsafeFEN("8/4P3/8/8/8/k7/8/K5N1 w - - 0 1")
+	for text, expected := range map[string]string{"♞f3": "Nf3", "♘f3": "Nf3", "♙e8=♛": "e8=Q", "e8=♕": "e8=Q", "Nf3": "Nf3"} {
+		m, err := FigurineNotation{}.Decode(pos, text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := (AlgebraicNotation{}).Encode(pos, m); s != expected {
+			t.Fatalf("expected %s to decode to %s but got %s", text, expected, s)
+		}
+	}
+	m, err := LocalizedNotation{Language: "de"}.Decode(pos, "e8=D")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Promo() != Queen {
+		t.Fatalf("expected a queen promotion but got %s", m)
+	}
+}
+
+func TestRegisterPieceLetters(t *testing.T) {
+	if _, err := (LocalizedNotation{Language: "nl"}).Decode(unsafeFEN(INITIAL_FEN_POSITION), "Pf3"); err == nil {
+		t.Fatal("expected an unknown language to fail")
+	}
+	if err := RegisterPieceLetters("nl", PieceLetters{King: "K", Queen: "D", Rook: "T", Bishop: "L", Knight: "P"}); err != nil {
+		t.Fatal(err)
+	}
+	n := LocalizedNotation{Language: "nl"}
+	pos := unsafeFEN(INITIAL_FEN_POSITION)
+	m, err := n.Decode(pos, "Pf3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := n.Encode(pos, m); s != "Pf3" || m.S1() != G1 {
+		t.Fatalf("expected Pf3 from g1 but got %s from %s", s, m.S1())
+	}
+	for _, letters := range []PieceLetters{
+		{King: "K", Queen: "D", Rook: "T", Bishop: "L", Knight: "L"},
+		{King: "K", Queen: "D", Rook: "T", Bishop: "L", Knight: ""},
+		{King: "K", Queen: "D", Rook: "T", Bishop: "b", Knight: "P"},
+	} {
+		if err := RegisterPieceLetters("xx", letters); err == nil {
+			t.Fatalf("expected %v to be invalid", letters)
+		}
+	}
+}
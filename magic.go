@@ -0,0 +1,121 @@
+package chess
+
+// Sliding piece attacks are looked up in tables indexed by magic
+// multiplication of the relevant occupancy.  The magic numbers below
+// were found by trial for this package's bit order (A1 is the most
+// significant bit).  See https://www.chessprogramming.org/Magic_Bitboards
+
+type magic struct {
+	mask    Bitboard
+	magic   uint64
+	shift   uint8
+	attacks []Bitboard
+}
+
+func (m *magic) index(occupied Bitboard) uint64 {
+	return (uint64(occupied&m.mask) * m.magic) >> m.shift
+}
+
+func rookAttacks(occupied Bitboard, sq Square) Bitboard {
+	m := &rookMagics[sq]
+	return m.attacks[m.index(occupied)]
+}
+
+func bishopAttacks(occupied Bitboard, sq Square) Bitboard {
+	m := &bishopMagics[sq]
+	return m.attacks[m.index(occupied)]
+}
+
+func queenAttacks(occupied Bitboard, sq Square) Bitboard {
+	return rookAttacks(occupied, sq) | bishopAttacks(occupied, sq)
+}
+
+var (
+	rookDirections   = [4][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}
+	bishopDirections = [4][2]int{{1, 1}, {-1, 1}, {1, -1}, {-1, -1}}
+
+	rookMagics   [64]magic
+	bishopMagics [64]magic
+
+	bbRookMagics = [64]uint64{
+		0x00001044028100a2, 0x0002008102100804, 0x1012000408900102, 0x0023000800105205,
+		0x8000210008041001, 0x08041a00801240a2, 0x8001802300b44001, 0x0000402100800011,
+		0x24000c0c80490600, 0x0042020801100400, 0x6400040080020080, 0x2008000851450100,
+		0x0012100009042100, 0x4040200080100280, 0x0004482504820200, 0x8082064100882a00,
+		0x110000409d060004, 0x0091121890040041, 0x6004000200048080, 0x0208008004008008,
+		0x0001012010030008, 0x0020001000208080, 0x10a00080c0018060, 0x0a0040008020800d,
+		0x0000004102000094, 0x1000800100800200, 0x1080020080800400, 0x0004000800808004,
+		0x0000080082801001, 0x0d08802008801000, 0xf001401001402002, 0x0080004000402000,
+		0x20010001000d6082, 0x0000480400900a29, 0x5224000202001008, 0x0004008080080004,
+		0x0040cb0100100160, 0x0410040120080120, 0x0040008080200040, 0x0000802080004010,
+		0x0004020000440081, 0x0008040082411008, 0x0500080140041020, 0x0038010011000408,
+		0x0040808008001004, 0x0020004010004800, 0x3020044020401000, 0x0800308000400080,
+		0x0082000100408204, 0x0081000200010004, 0x0211000401005812, 0x0003000800110104,
+		0x4002801000880086, 0x0001002000110046, 0x0008400448201000, 0x0804800040008020,
+		0x0200108200402104, 0x2080008001000200, 0x0080020080040001, 0x0480080042800401,
+		0x0500100021000408, 0x2a00188042002190, 0x00c0100020014000, 0x2180009040012480,
+	}
+	bbBishopMagics = [64]uint64{
+		0x0041440088810100, 0x0004045002080102, 0x9002002004013200, 0xd8a08001d1021200,
+		0x2002200400840400, 0x0001408024020800, 0x2411008608110400, 0x030280805002a000,
+		0x01100200a9020002, 0x00101111411400a0, 0x0080280a48221002, 0x0820008520820008,
+		0x1614010020a80040, 0x1400820500c80160, 0x00008c0421040120, 0x2081210120209009,
+		0x0011042902029040, 0x0010102a40800050, 0x01400c0404080090, 0x400004010c009a00,
+		0x8068160202000420, 0x2002002201044800, 0x0020808820c93828, 0x0801100820480400,
+		0x82810410800d0048, 0x0402420040240410, 0x0410084080411000, 0x2020008400048202,
+		0x02001808000a0a00, 0x0032080200040820, 0x6c00842040100248, 0x20141420802420a0,
+		0x04005a0405010111, 0x1192060000491005, 0x200a008008080145, 0x0c01010000104001,
+		0x2010040006401020, 0x4020220044080208, 0x2010020010241100, 0x0008090004200800,
+		0x0000200300821090, 0x0004240080880880, 0x4821020201010100, 0x0804000880a05005,
+		0x2508092220204002, 0x0050800104088200, 0x2014013001062408, 0x0804000930048800,
+		0x0020004228010804, 0x0040008430021000, 0x1100020805240008, 0x0001040420804000,
+		0x0016040420800000, 0x00020401420200c0, 0x00a8100102042040, 0x04081004a8080040,
+		0x1000420804010400, 0x00050808024804c0, 0x0102080446201101, 0x00420210a40c1010,
+		0x10109082000a1001, 0x1008481040880000, 0x00081044006440a0, 0x0020040100451200,
+	}
+)
+
+// slidingAttack computes attacks by walking the directions from the
+// square until a piece or the edge is reached.  If inner is true the
+// last square before the edge is excluded, which gives the relevant
+// occupancy mask.  It is only used to fill the magic tables.
+func slidingAttack(sq Square, occupied Bitboard, directions [4][2]int, inner bool) Bitboard {
+	var bb Bitboard
+	for _, d := range directions {
+		f, r := int(sq.File())+d[0], int(sq.Rank())+d[1]
+		for f >= 0 && f < 8 && r >= 0 && r < 8 {
+			nf, nr := f+d[0], r+d[1]
+			if inner && (nf < 0 || nf > 7 || nr < 0 || nr > 7) {
+				break
+			}
+			s := NewSquare(File(f), Rank(r))
+			bb |= bbForSquare(s)
+			if occupied&bbForSquare(s) != 0 {
+				break
+			}
+			f, r = nf, nr
+		}
+	}
+	return bb
+}
+
+func initMagics(magics *[64]magic, numbers [64]uint64, directions [4][2]int) {
+	for sq := 0; sq < numOfSquaresInBoard; sq++ {
+		mask := slidingAttack(Square(sq), 0, directions, true)
+		n := mask.count()
+		m := &magics[sq]
+		m.mask = mask
+		m.magic = numbers[sq]
+		m.shift = uint8(64 - n)
+		m.attacks = make([]Bitboard, 1<<n)
+		// enumerate all subsets of the mask (Carry-Rippler)
+		occupied := Bitboard(0)
+		for {
+			m.attacks[m.index(occupied)] = slidingAttack(Square(sq), occupied, directions, false)
+			occupied = (occupied - mask) & mask
+			if occupied == 0 {
+				break
+			}
+		}
+	}
+}
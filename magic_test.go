@@ -0,0 +1,51 @@
+package chess
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestMagicAttacks(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	for i := 0; i < 1000; i++ {
+		occupied := Bitboard(r.Uint64() & r.Uint64())
+		for sq := Square(0); sq < numOfSquaresInBoard; sq++ {
+			expected := slidingAttack(sq, occupied, rookDirections, false)
+			if actual := rookAttacks(occupied, sq); actual != expected {
+				t.Fatalf("rook attacks from %s expected %s but got %s", sq, expected.Draw(), actual.Draw())
+			}
+			expected = slidingAttack(sq, occupied, bishopDirections, false)
+			if actual := bishopAttacks(occupied, sq); actual != expected {
+				t.Fatalf("bishop attacks from %s expected %s but got %s", sq, expected.Draw(), actual.Draw())
+			}
+		}
+	}
+}
+
+func TestBetweenAndLine(t *testing.T) {
+	tests := []struct {
+		s1, s2  Square
+		between []Square
+		line    []Square
+	}{
+		{A1, H8, []Square{B2, C3, D4, E5, F6, G7}, []Square{A1, B2, C3, D4, E5, F6, G7, H8}},
+		{E1, E4, []Square{E2, E3}, []Square{E1, E2, E3, E4, E5, E6, E7, E8}},
+		{C3, D3, nil, []Square{A3, B3, C3, D3, E3, F3, G3, H3}},
+		{B1, C3, nil, nil},
+	}
+	for _, test := range tests {
+		between, line := Bitboard(0), Bitboard(0)
+		for _, sq := range test.between {
+			between |= bbForSquare(sq)
+		}
+		for _, sq := range test.line {
+			line |= bbForSquare(sq)
+		}
+		if bbBetween[test.s1][test.s2] != between || bbBetween[test.s2][test.s1] != between {
+			t.Fatalf("expected squares between %s and %s to be %s", test.s1, test.s2, between.Draw())
+		}
+		if bbLine[test.s1][test.s2] != line || bbLine[test.s2][test.s1] != line {
+			t.Fatalf("expected line through %s and %s to be %s", test.s1, test.s2, line.Draw())
+		}
+	}
+}
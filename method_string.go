@@ -19,11 +19,18 @@ func _() {
 	_ = x[SeventyFiveMoveRule-8]
 	_ = x[InsufficientMaterial-9]
 	_ = x[InCheck-10]
+	_ = x[ThreeChecks-11]
+	_ = x[KingOfTheHill-12]
+	_ = x[Explosion-13]
+	_ = x[AllPiecesCaptured-14]
+	_ = x[Timeout-15]
+	_ = x[Abandoned-16]
+	_ = x[RulesInfraction-17]
 }
 
-const _Method_name = "NoMethodCheckmateResignationDrawOfferStalemateThreefoldRepetitionFivefoldRepetitionFiftyMoveRuleSeventyFiveMoveRuleInsufficientMaterialInCheck"
+const _Method_name = "NoMethodCheckmateResignationDrawOfferStalemateThreefoldRepetitionFivefoldRepetitionFiftyMoveRuleSeventyFiveMoveRuleInsufficientMaterialInCheckThreeChecksKingOfTheHillExplosionAllPiecesCapturedTimeoutAbandonedRulesInfraction"
 
-var _Method_index = [...]uint8{0, 8, 17, 28, 37, 46, 65, 83, 96, 115, 135, 142}
+var _Method_index = [...]uint8{0, 8, 17, 28, 37, 46, 65, 83, 96, 115, 135, 142, 153, 166, 175, 192, 199, 208, 223}
 
 func (i Method) String() string {
 	if i >= Method(len(_Method_index)-1) {
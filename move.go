@@ -29,20 +29,26 @@ const (
 )
 
 // A Move is the movement of a piece from one square to another.
+// In Crazyhouse a move may instead drop a piece from the pocket,
+// such moves have no origin square.
 type Move struct {
 	s1    Square
 	s2    Square
 	promo PieceType
+	drop  PieceType
 	tags  MoveTag
 }
 
 // String returns a string useful for debugging.  String doesn't return
 // algebraic notation.
 func (m *Move) String() string {
+	if m.drop != NoPieceType {
+		return dropString(m)
+	}
 	return m.S1().String() + m.S2().String() + m.Promo().String()
 }
 
-// S1 returns the origin square of the move.
+// S1 returns the origin square of the move or NoSquare for drops.
 func (m *Move) S1() Square {
 	return m.s1
 }
@@ -57,6 +63,12 @@ func (m *Move) Promo() PieceType {
 	return m.promo
 }
 
+// Drop returns the piece type dropped by the move or NoPieceType
+// if the move isn't a drop.
+func (m *Move) Drop() PieceType {
+	return m.drop
+}
+
 // HasTag returns true if the move contains the MoveTag given.
 func (m *Move) HasTag(tag MoveTag) bool {
 	return (tag & m.tags) > 0
@@ -73,7 +85,7 @@ func (a MoveSlice) find(m *Move) *Move {
 		return nil
 	}
 	for _, move := range a {
-		if move.String() == m.String() {
+		if move.s1 == m.s1 && move.s2 == m.s2 && move.promo == m.promo && move.drop == m.drop {
 			return move
 		}
 	}
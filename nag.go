@@ -0,0 +1,215 @@
+package chess
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A NAG is a Numeric Annotation Glyph, the PGN annotation of a move
+// or the position after it written as $ and a number.  The constants
+// below are the ones of the standard table most often used, any other
+// value from 0 to 255 can be stored as well.
+type NAG uint8
+
+const (
+	// NullAnnotation is the null annotation.
+	NullAnnotation NAG = 0
+	// GoodMove is a good move, symbolically !
+	GoodMove NAG = 1
+	// Mistake is a poor move, symbolically ?
+	Mistake NAG = 2
+	// BrilliantMove is a very good move, symbolically !!
+	BrilliantMove NAG = 3
+	// Blunder is a very poor move, symbolically ??
+	Blunder NAG = 4
+	// SpeculativeMove is a speculative move, symbolically !?
+	SpeculativeMove NAG = 5
+	// DubiousMove is a questionable move, symbolically ?!
+	DubiousMove NAG = 6
+	// ForcedMove is a forced move, all others lose quickly.
+	ForcedMove NAG = 7
+	// SingularMove is a move with no reasonable alternatives.
+	SingularMove NAG = 8
+	// WorstMove is the worst move.
+	WorstMove NAG = 9
+	// DrawishPosition is a drawish position, symbolically =
+	DrawishPosition NAG = 10
+	// QuietPosition is a quiet position with equal chances.
+	QuietPosition NAG = 11
+	// ActivePosition is an active position with equal chances.
+	ActivePosition NAG = 12
+	// UnclearPosition is an unclear position, symbolically ∞
+	UnclearPosition NAG = 13
+	// WhiteSlightAdvantage means White has a slight advantage,
+	// symbolically ⩲
+	WhiteSlightAdvantage NAG = 14
+	// BlackSlightAdvantage means Black has a slight advantage,
+	// symbolically ⩱
+	BlackSlightAdvantage NAG = 15
+	// WhiteModerateAdvantage means White has a moderate advantage,
+	// symbolically ±
+	WhiteModerateAdvantage NAG = 16
+	// BlackModerateAdvantage means Black has a moderate advantage,
+	// symbolically ∓
+	BlackModerateAdvantage NAG = 17
+	// WhiteDecisiveAdvantage means White has a decisive advantage,
+	// symbolically +-
+	WhiteDecisiveAdvantage NAG = 18
+	// BlackDecisiveAdvantage means Black has a decisive advantage,
+	// symbolically -+
+	BlackDecisiveAdvantage NAG = 19
+	// WhiteCrushingAdvantage means Black should resign.
+	WhiteCrushingAdvantage NAG = 20
+	// BlackCrushingAdvantage means White should resign.
+	BlackCrushingAdvantage NAG = 21
+	// WhiteZugzwang means White is in zugzwang.
+	WhiteZugzwang NAG = 22
+	// BlackZugzwang means Black is in zugzwang.
+	BlackZugzwang NAG = 23
+	// WhiteInitiative means White has the initiative.
+	WhiteInitiative NAG = 36
+	// BlackInitiative means Black has the initiative.
+	BlackInitiative NAG = 37
+	// WhiteLastingInitiative means White has a lasting initiative.
+	WhiteLastingInitiative NAG = 38
+	// BlackLastingInitiative means Black has a lasting initiative.
+	BlackLastingInitiative NAG = 39
+	// WhiteAttack means White has the attack.
+	WhiteAttack NAG = 40
+	// BlackAttack means Black has the attack.
+	BlackAttack NAG = 41
+	// WhiteInsufficientCompensation means White has insufficient
+	// compensation for a material deficit.
+	WhiteInsufficientCompensation NAG = 42
+	// BlackInsufficientCompensation means Black has insufficient
+	// compensation for a material deficit.
+	BlackInsufficientCompensation NAG = 43
+	// WhiteCompensation means White has sufficient compensation for
+	// a material deficit.
+	WhiteCompensation NAG = 44
+	// BlackCompensation means Black has sufficient compensation for
+	// a material deficit.
+	BlackCompensation NAG = 45
+	// WhiteModerateCounterplay means White has moderate counterplay.
+	WhiteModerateCounterplay NAG = 132
+	// BlackModerateCounterplay means Black has moderate counterplay.
+	BlackModerateCounterplay NAG = 133
+	// WhiteTimePressure means White is in moderate time control
+	// pressure.
+	WhiteTimePressure NAG = 136
+	// BlackTimePressure means Black is in moderate time control
+	// pressure.
+	BlackTimePressure NAG = 137
+	// WhiteSevereTimePressure means White is in severe time control
+	// pressure.
+	WhiteSevereTimePressure NAG = 138
+	// BlackSevereTimePressure means Black is in severe time control
+	// pressure.
+	BlackSevereTimePressure NAG = 139
+)
+
+var nagSymbols = map[NAG]string{
+	GoodMove:               "!",
+	Mistake:                "?",
+	BrilliantMove:          "!!",
+	Blunder:                "??",
+	SpeculativeMove:        "!?",
+	DubiousMove:            "?!",
+	DrawishPosition:        "=",
+	UnclearPosition:        "∞",
+	WhiteSlightAdvantage:   "⩲",
+	BlackSlightAdvantage:   "⩱",
+	WhiteModerateAdvantage: "±",
+	BlackModerateAdvantage: "∓",
+	WhiteDecisiveAdvantage: "+-",
+	BlackDecisiveAdvantage: "-+",
+}
+
+// String implements the fmt.Stringer interface and returns the
+// numeric form.  Ex. $1
+func (n NAG) String() string {
+	return "$" + strconv.Itoa(int(n))
+}
+
+// Symbol returns the symbolic form of the NAG or "" if it has none.
+// Ex. !
+func (n NAG) Symbol() string {
+	return nagSymbols[n]
+}
+
+// IsMoveSuffix returns true for the NAGs from GoodMove to DubiousMove
+// that PGN allows as suffixes of the move.
+func (n NAG) IsMoveSuffix() bool {
+	return n >= GoodMove && n <= DubiousMove
+}
+
+// ParseNAG returns the NAG in numeric or symbolic form.  Ex. $1 or !
+func ParseNAG(s string) (NAG, error) {
+	if strings.HasPrefix(s, "$") {
+		n, err := strconv.ParseUint(s[1:], 10, 8)
+		if err != nil {
+			return 0, fmt.Errorf("chess: invalid NAG %q", s)
+		}
+		return NAG(n), nil
+	}
+	for n, sym := range nagSymbols {
+		if sym == s {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("chess: invalid NAG %q", s)
+}
+
+// UseNAGSymbols returns a function that sets whether the game's PGN
+// writes NAGs in symbolic form where they have one rather than in
+// numeric form, the default.  Move suffixes are then attached to the
+// move as in e4!  The returned function is designed to be used in the
+// NewGame constructor.
+func UseNAGSymbols(symbols bool) func(*Game) {
+	return func(g *Game) {
+		g.nagSymbols = symbols
+	}
+}
+
+// SetNAGSymbols sets whether the game's PGN writes NAGs in symbolic
+// form.
+func (g *Game) SetNAGSymbols(symbols bool) {
+	g.nagSymbols = symbols
+}
+
+// NAGs returns the NAGs for the game's main line indexed by moves.
+func (g *Game) NAGs() [][]NAG {
+	nags := make([][]NAG, len(g.nodes)-1)
+	for i, n := range g.nodes[1:] {
+		nags[i] = n.nags
+	}
+	return nags
+}
+
+// NAGs returns the NAGs of the move.
+func (n *MoveNode) NAGs() []NAG {
+	return append([]NAG(nil), n.nags...)
+}
+
+// SetNAGs replaces the NAGs of the move.
+func (n *MoveNode) SetNAGs(nags []NAG) {
+	n.nags = append([]NAG(nil), nags...)
+}
+
+// encodeNAGs encodes the NAGs following the move text.
+func encodeNAGs(nags []NAG, symbols bool) string {
+	s := ""
+	for i, n := range nags {
+		sym := n.Symbol()
+		switch {
+		case symbols && i == 0 && n.IsMoveSuffix():
+			s += sym
+		case symbols && sym != "":
+			s += " " + sym
+		default:
+			s += " " + n.String()
+		}
+	}
+	return s
+}
@@ -0,0 +1,101 @@
+package chess
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseNAG(t *testing.T) {
+	tests := []struct {
+		s   string
+		nag NAG
+	}{
+		{"$0", NullAnnotation},
+		{"$1", GoodMove},
+		{"!", GoodMove},
+		{"?", Mistake},
+		{"!!", BrilliantMove},
+		{"??", Blunder},
+		{"!?", SpeculativeMove},
+		{"?!", DubiousMove},
+		{"±", WhiteModerateAdvantage},
+		{"-+", BlackDecisiveAdvantage},
+		{"$138", WhiteSevereTimePressure},
+		{"$255", NAG(255)},
+	}
+	for _, test := range tests {
+		nag, err := ParseNAG(test.s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if nag != test.nag {
+			t.Fatalf("expected %s to be %s but got %s", test.s, test.nag, nag)
+		}
+	}
+	for _, s := range []string{"$256", "$", "!!!", "x"} {
+		if _, err := ParseNAG(s); err == nil {
+			t.Fatalf("expected error parsing %s", s)
+		}
+	}
+}
+
+func TestNAGSymbol(t *testing.T) {
+	if DubiousMove.Symbol() != "?!" || DubiousMove.String() != "$6" {
+		t.Fatalf("expected ?! and $6 but got %s and %s", DubiousMove.Symbol(), DubiousMove)
+	}
+	if WhiteInitiative.Symbol() != "" {
+		t.Fatalf("expected no symbol for %s", WhiteInitiative)
+	}
+}
+
+func TestDecodeNAGs(t *testing.T) {
+	pgn, err := mustParsePGN()("fixtures/pgns/0002.pgn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	game, err := decodePGN(nil, pgn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nags := game.NAGs()
+	// 38... Kg7?! 39. g4 Kh6?! 44... Rd1??
+	for ply, nag := range map[int]NAG{75: DubiousMove, 77: DubiousMove, 87: Blunder} {
+		if len(nags[ply]) != 1 || nags[ply][0] != nag {
+			t.Fatalf("expected %s for ply %d but got %v", nag, ply+1, nags[ply])
+		}
+	}
+	if len(nags[0]) != 0 {
+		t.Fatalf("expected no NAGs for the first move but got %v", nags[0])
+	}
+	if h := game.MoveHistory()[75]; len(h.NAGs) != 1 || len(h.Comments) != 1 {
+		t.Fatalf("expected the NAG and comment in the move history")
+	}
+}
+
+func TestEncodeNAGs(t *testing.T) {
+	pgn := "1. e4! $14 e5 $2 $36 (1... c5!? { sicilian }) 2. Nf3 *"
+	game, err := decodePGN(nil, pgn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := game.String()
+	if !strings.Contains(s, "1. e4 $1 $14 e5 $2 $36 (1... c5 $5 {sicilian}) 2. Nf3") {
+		t.Fatalf("unexpected numeric NAGs in %s", s)
+	}
+	game.SetNAGSymbols(true)
+	s = game.String()
+	if !strings.Contains(s, "1. e4! ⩲ e5? $36 (1... c5!? {sicilian}) 2. Nf3") {
+		t.Fatalf("unexpected symbolic NAGs in %s", s)
+	}
+	cp, err := decodePGN(UseNAGSymbols(true), s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp.String() != s {
+		t.Fatalf("expected round trip %s but got %s", s, cp.String())
+	}
+	cp.Node(1).SetNAGs([]NAG{BrilliantMove})
+	if !strings.Contains(cp.String(), "1. e4!! e5?") {
+		t.Fatalf("unexpected NAGs after SetNAGs in %s", cp.String())
+	}
+}
@@ -31,7 +31,15 @@ type Notation interface {
 // UCINotation is a more computer friendly alternative to algebraic
 // notation.  This notation uses the same format as the UCI (Universal Chess
 // Interface).  Examples: e2e4, e7e5, e1g1 (white short castling), e7e8q (for promotion)
-type UCINotation struct{}
+//
+// Castles in Chess960 positions are encoded as the king taking its own
+// rook.  Setting Chess960, as engines with the UCI_Chess960 option
+// expect, encodes castles in standard positions the same way (e1h1).
+// Decoding accepts both forms.  Crazyhouse drops are written with
+// the piece letter and an @, Ex. N@f3 or P@e6.
+type UCINotation struct {
+	Chess960 bool
+}
 
 // String implements the fmt.Stringer interface and returns
 // the notation's name.
@@ -40,8 +48,15 @@ func (UCINotation) String() string {
 }
 
 // Encode implements the Encoder interface.
-func (UCINotation) Encode(pos *Position, m *Move) string {
-	return m.S1().String() + m.S2().String() + m.Promo().String()
+func (n UCINotation) Encode(pos *Position, m *Move) string {
+	if m.drop != NoPieceType {
+		return dropString(m)
+	}
+	s2 := m.S2()
+	if n.Chess960 && pos != nil && !pos.chess960 && (m.HasTag(KingSideCastle) || m.HasTag(QueenSideCastle)) {
+		_, s2, _ = pos.castleSquares(m, pos.Turn())
+	}
+	return m.S1().String() + s2.String() + m.Promo().String()
 }
 
 // Decode implements the Decoder interface.
@@ -51,6 +66,14 @@ func (UCINotation) Decode(pos *Position, s string) (*Move, error) {
 	if l < 4 || l > 5 {
 		return nil, err
 	}
+	if l == 4 && s[1] == '@' {
+		pt := dropPieceType(s[0])
+		s2, ok := strToSquareMap[s[2:4]]
+		if pt == NoPieceType || !ok {
+			return nil, err
+		}
+		return &Move{s1: NoSquare, s2: s2, drop: pt}, nil
+	}
 	s1, ok := strToSquareMap[s[0:2]]
 	if !ok {
 		return nil, err
@@ -71,7 +94,19 @@ func (UCINotation) Decode(pos *Position, s string) (*Move, error) {
 		return m, nil
 	}
 	p := pos.Board().Piece(s1)
-	if p.Type() == King {
+	if p.Type() == King && pos.Board().Piece(s2) == NewPiece(Rook, p.Color()) {
+		// the king taking its own rook castles
+		tag := QueenSideCastle
+		if s2.File() > s1.File() {
+			tag = KingSideCastle
+		}
+		m.addTag(tag)
+		if !pos.chess960 {
+			m.s2, _, _ = pos.castleSquares(m, p.Color())
+		}
+		return m, nil
+	}
+	if p.Type() == King && !pos.chess960 {
 		if (s1 == E1 && s2 == G1) || (s1 == E8 && s2 == G8) {
 			m.addTag(KingSideCastle)
 		} else if (s1 == E1 && s2 == C1) || (s1 == E8 && s2 == C8) {
@@ -89,10 +124,98 @@ func (UCINotation) Decode(pos *Position, s string) (*Move, error) {
 	return m, nil
 }
 
+// ICCFNotation is the numeric notation of correspondence chess.  Moves
+// are written as the origin and destination squares with the files
+// numbered from 1 to 8 like the ranks.  Castles are written as the
+// king's move and promotions add 1 for a queen, 2 for a rook, 3 for a
+// bishop or 4 for a knight.  Examples: 5254 (e2e4), 7163 (g1f3), 5171
+// (white short castling), 57581 (e7e8=Q)
+type ICCFNotation struct{}
+
+// String implements the fmt.Stringer interface and returns
+// the notation's name.
+func (ICCFNotation) String() string {
+	return "ICCF Numeric Notation"
+}
+
+// Encode implements the Encoder interface.  Crazyhouse drops, which
+// ICCF notation lacks, are written as in UCI notation.
+func (ICCFNotation) Encode(pos *Position, m *Move) string {
+	if m.drop != NoPieceType {
+		return dropString(m)
+	}
+	s := iccfSquare(m.s1) + iccfSquare(iccfDestination(pos, m))
+	switch m.promo {
+	case Queen:
+		s += "1"
+	case Rook:
+		s += "2"
+	case Bishop:
+		s += "3"
+	case Knight:
+		s += "4"
+	}
+	return s
+}
+
+// Decode implements the Decoder interface.
+func (ICCFNotation) Decode(pos *Position, s string) (*Move, error) {
+	err := fmt.Errorf("chess: failed to decode ICCF notation text %s for position %s", s, pos)
+	if len(s) != 4 && len(s) != 5 {
+		return nil, err
+	}
+	for _, c := range s {
+		if c < '1' || c > '8' {
+			return nil, err
+		}
+	}
+	s1 := NewSquare(File(s[0]-'1'), Rank(s[1]-'1'))
+	s2 := NewSquare(File(s[2]-'1'), Rank(s[3]-'1'))
+	promo := NoPieceType
+	if len(s) == 5 {
+		promos := []PieceType{Queen, Rook, Bishop, Knight}
+		if s[4] > '4' {
+			return nil, err
+		}
+		promo = promos[s[4]-'1']
+	}
+	for _, m := range pos.ValidMoves() {
+		if m.drop == NoPieceType && m.s1 == s1 && iccfDestination(pos, m) == s2 && m.promo == promo {
+			return m, nil
+		}
+	}
+	return nil, err
+}
+
+// iccfDestination returns the move's destination, which is the king's
+// for castles.
+func iccfDestination(pos *Position, m *Move) Square {
+	if pos != nil && (m.HasTag(KingSideCastle) || m.HasTag(QueenSideCastle)) {
+		s2, _, _ := pos.castleSquares(m, pos.Turn())
+		return s2
+	}
+	return m.s2
+}
+
+func iccfSquare(sq Square) string {
+	return fmt.Sprintf("%d%d", sq.File()+1, sq.Rank()+1)
+}
+
 // AlgebraicNotation (or Standard Algebraic Notation) is the
 // official chess notation used by FIDE. Examples: e4, e5,
-// O-O (short castling), e8=Q (promotion)
-type AlgebraicNotation struct{}
+// O-O (short castling), e8=Q (promotion), N@f3 (Crazyhouse drop)
+//
+// Setting Lenient decodes moves the way people type them as well:
+// castling with zeros (0-0), e.p. after en passant captures, captures
+// written with : or without x, moves with more of the origin square
+// than needed (Ng1f3, g1f3) or separated by a dash (e2-e4), lowercase piece
+// letters (nf3, and bc4 where no pawn captures), annotations such as
+// !? and promotions without = (e8Q).  Decoding fails, listing the
+// moves, if the text fits more than one and isn't standard algebraic
+// notation for one of them.
+type AlgebraicNotation struct {
+	Lenient bool
+}
 
 // String implements the fmt.Stringer interface and returns
 // the notation's name.
@@ -103,7 +226,9 @@ func (AlgebraicNotation) String() string {
 // Encode implements the Encoder interface.
 func (AlgebraicNotation) Encode(pos *Position, m *Move) string {
 	checkChar := getCheckChar(pos, m)
-	if m.HasTag(KingSideCastle) {
+	if m.drop != NoPieceType {
+		return dropString(m) + checkChar
+	} else if m.HasTag(KingSideCastle) {
 		return "O-O" + checkChar
 	} else if m.HasTag(QueenSideCastle) {
 		return "O-O-O" + checkChar
@@ -122,7 +247,9 @@ func (AlgebraicNotation) Encode(pos *Position, m *Move) string {
 	return pChar + s1Str + capChar + m.s2.String() + promoText + checkChar
 }
 
-var pgnRegex = regexp.MustCompile(`^(?:([RNBQKP]?)([abcdefgh]?)(\d?)(x?)([abcdefgh])(\d)(=[QRBN])?|(O-O(?:-O)?))([+#!?]|e\.p\.)*$`)
+var dropRegex = regexp.MustCompile(`^([PNBRQ]?)@([abcdefgh][12345678])([+#!?])*$`)
+
+var pgnRegex = regexp.MustCompile(`^(?:([RNBQKP]?)([abcdefgh]?)(\d?)(x?)([abcdefgh])(\d)(=[QRBNK])?|(O-O(?:-O)?))([+#!?]|e\.p\.)*$`)
 
 func algebraicNotationParts(s string) (string, string, string, string, string, string, string, string, error) {
 	submatches := pgnRegex.FindStringSubmatch(s)
@@ -134,13 +261,29 @@ func algebraicNotationParts(s string) (string, string, string, string, string, s
 }
 
 // Decode implements the Decoder interface.
-func (AlgebraicNotation) Decode(pos *Position, s string) (*Move, error) {
+func (n AlgebraicNotation) Decode(pos *Position, s string) (*Move, error) {
+	m, err := decodeAlgebraic(pos, s)
+	if n.Lenient {
+		// the strict decoding drops disambiguation it doesn't fit, so
+		// it only settles readings the lenient one allows
+		return decodeLenient(pos, s, m)
+	}
+	return m, err
+}
+
+func decodeAlgebraic(pos *Position, s string) (*Move, error) {
+	if strings.Contains(s, "@") {
+		return decodeDrop(pos, s)
+	}
 	piece, originFile, originRank, capture, file, rank, promotes, castles, err := algebraicNotationParts(s)
 	if err != nil {
 		return nil, fmt.Errorf("chess: %+v for position %s", err, pos.String())
 	}
 
 	for _, m := range pos.ValidMoves() {
+		if m.drop != NoPieceType {
+			continue
+		}
 		moveStr := AlgebraicNotation{}.Encode(pos, m)
 		moveSubmatches := pgnRegex.FindStringSubmatch(moveStr)
 		moveCleaned := strings.Join(moveSubmatches[1:9], "")
@@ -178,6 +321,167 @@ func (AlgebraicNotation) Decode(pos *Position, s string) (*Move, error) {
 	return nil, fmt.Errorf("chess: could not decode algebraic notation %s for position %s", s, pos.String())
 }
 
+// decodeDrop decodes a Crazyhouse drop written as the piece letter,
+// which pawns may leave out, an @ and the square.  Ex. N@f3 or @e6
+func decodeDrop(pos *Position, s string) (*Move, error) {
+	submatches := dropRegex.FindStringSubmatch(s)
+	if len(submatches) == 0 {
+		return nil, fmt.Errorf("chess: could not decode algebraic notation %s for position %s", s, pos.String())
+	}
+	pt := Pawn
+	if submatches[1] != "" {
+		pt = dropPieceType(submatches[1][0])
+	}
+	for _, m := range pos.ValidMoves() {
+		if m.drop == pt && m.s2 == strToSquareMap[submatches[2]] {
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("chess: could not decode algebraic notation %s for position %s", s, pos.String())
+}
+
+var (
+	lenientSuffixes = []string{"!", "?", "+", "#", " ", "e.p."}
+	lenientStrip    = strings.NewReplacer("x", "", ":", "", "-", "", "=", "", "/", "", "(", "", ")", "")
+)
+
+// lenientOrigin is a reading of the text before a move's destination
+// square: the piece type, or NoPieceType for any, and the origin file
+// and rank given, if any.
+type lenientOrigin struct {
+	pt   PieceType
+	file string
+	rank string
+}
+
+// decodeLenient decodes the moves AlgebraicNotation accepts when
+// Lenient is set.  The strict decoding, if any, is chosen when the
+// text could be more than one move, as with bxc3.
+func decodeLenient(pos *Position, s string, strict *Move) (*Move, error) {
+	text := strings.TrimSpace(s)
+	for trimmed := ""; trimmed != text; {
+		trimmed = text
+		for _, suffix := range lenientSuffixes {
+			text = strings.TrimSuffix(text, suffix)
+		}
+	}
+	errUnknown := fmt.Errorf("chess: could not decode algebraic notation %s for position %s", s, pos.String())
+	if strings.Contains(text, "@") {
+		return decodeDrop(pos, text)
+	}
+	if castle := strings.ToUpper(strings.ReplaceAll(text, "0", "O")); castle == "O-O" || castle == "O-O-O" {
+		tag := KingSideCastle
+		if castle == "O-O-O" {
+			tag = QueenSideCastle
+		}
+		for _, m := range pos.ValidMoves() {
+			if m.HasTag(tag) {
+				return m, nil
+			}
+		}
+		return nil, errUnknown
+	}
+	text = lenientStrip.Replace(text)
+	// the destination is the last square
+	i := len(text) - 2
+	for ; i >= 0; i-- {
+		if _, ok := strToSquareMap[text[i:i+2]]; ok {
+			break
+		}
+	}
+	if i < 0 {
+		return nil, errUnknown
+	}
+	s2 := strToSquareMap[text[i:i+2]]
+	promo := NoPieceType
+	switch suffix := text[i+2:]; {
+	case len(suffix) == 1:
+		promo = pieceTypeFromChar(strings.ToLower(suffix))
+		if promo == NoPieceType {
+			return nil, errUnknown
+		}
+	case suffix != "":
+		return nil, errUnknown
+	}
+	origins := lenientOrigins(text[:i])
+	candidates := []*Move{}
+	for _, m := range pos.ValidMoves() {
+		// a promotion without the piece fits all of them
+		if m.drop != NoPieceType || m.s2 != s2 || promo != NoPieceType && m.promo != promo {
+			continue
+		}
+		pt := pos.board.Piece(m.s1).Type()
+		for _, o := range origins {
+			if (o.pt == NoPieceType || o.pt == pt) &&
+				(o.file == "" || o.file == m.s1.File().String()) &&
+				(o.rank == "" || o.rank == m.s1.Rank().String()) {
+				candidates = append(candidates, m)
+				break
+			}
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		return nil, errUnknown
+	case 1:
+		return candidates[0], nil
+	}
+	for _, m := range candidates {
+		if strict != nil && m.s1 == strict.s1 && m.s2 == strict.s2 && m.promo == strict.promo {
+			return m, nil
+		}
+	}
+	moves := make([]string, len(candidates))
+	for i, m := range candidates {
+		moves[i] = AlgebraicNotation{}.Encode(pos, m)
+	}
+	return nil, fmt.Errorf("chess: ambiguous algebraic notation %s for position %s could be %s", s, pos.String(), strings.Join(moves, ", "))
+}
+
+// lenientOrigins returns the readings of the text before a move's
+// destination square.  A lowercase b is both a bishop and a file
+// unless the whole origin square is given.
+func lenientOrigins(prefix string) []lenientOrigin {
+	origins := []lenientOrigin{}
+	if o, ok := parseLenientOrigin(prefix); ok {
+		// without a piece letter it's a pawn unless the whole
+		// origin square is given
+		o.pt = Pawn
+		if o.rank != "" {
+			o.pt = NoPieceType
+		}
+		origins = append(origins, o)
+	}
+	if prefix == "" || len(origins) > 0 && origins[0].file != "" && origins[0].rank != "" {
+		// a whole origin square isn't read as a piece letter
+		return origins
+	}
+	c := strings.ToLower(prefix[:1])
+	pt := pieceTypeFromChar(c)
+	if c == "p" {
+		pt = Pawn
+	}
+	if pt == NoPieceType {
+		return origins
+	}
+	if o, ok := parseLenientOrigin(prefix[1:]); ok {
+		o.pt = pt
+		origins = append(origins, o)
+	}
+	return origins
+}
+
+func parseLenientOrigin(s string) (lenientOrigin, bool) {
+	o := lenientOrigin{}
+	if s != "" && s[0] >= 'a' && s[0] <= 'h' {
+		o.file, s = s[:1], s[1:]
+	}
+	if s != "" && s[0] >= '1' && s[0] <= '8' {
+		o.rank, s = s[:1], s[1:]
+	}
+	return o, s == ""
+}
+
 // LongAlgebraicNotation is a fully expanded version of
 // algebraic notation in which the starting and ending
 // squares are specified.
@@ -193,7 +497,9 @@ func (LongAlgebraicNotation) String() string {
 // Encode implements the Encoder interface.
 func (LongAlgebraicNotation) Encode(pos *Position, m *Move) string {
 	checkChar := getCheckChar(pos, m)
-	if m.HasTag(KingSideCastle) {
+	if m.drop != NoPieceType {
+		return dropString(m) + checkChar
+	} else if m.HasTag(KingSideCastle) {
 		return "O-O" + checkChar
 	} else if m.HasTag(QueenSideCastle) {
 		return "O-O-O" + checkChar
@@ -298,6 +604,8 @@ func pieceTypeFromChar(c string) PieceType {
 		return Bishop
 	case "n":
 		return Knight
+	case "k":
+		return King
 	}
 	return NoPieceType
 }
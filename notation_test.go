@@ -117,3 +117,106 @@ func TestInvalidDecoding(t *testing.T) {
 		}
 	}
 }
+
+func TestLenientDecoding(t *testing.T) {
+	n := AlgebraicNotation{Lenient: true}
+	tests := []struct {
+		fen   string
+		texts []string
+		move  string
+	}{
+		{INITIAL_FEN_POSITION, []string{"nf3", "Ng1f3", "Ng1-f3", "Nf3!?", "nf3 !"}, "Nf3"},
+		{INITIAL_FEN_POSITION, []string{"e2-e4", "e2e4", "e4!!"}, "e4"},
+		// an origin square without a piece letter isn't dropped
+		{INITIAL_FEN_POSITION, []string{"g1f3", "g1-f3"}, "Nf3"},
+		{INITIAL_FEN_POSITION, []string{"b1c3"}, "Nc3"},
+		{"r1bqk1nr/pppp1ppp/2n5/2b1p3/2B1P3/5N2/PPPP1PPP/RNBQK2R w KQkq - 4 4", []string{"0-0", "o-o", "O-O+"}, "O-O"},
+		{"r3k2r/8/8/8/8/8/8/4K3 b kq - 0 1", []string{"0-0-0", "O-O-O"}, "O-O-O"},
+		{"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", []string{"exf6 e.p.", "exf6e.p.", "e5:f6", "ef6"}, "exf6"},
+		{"8/4P3/8/8/8/k7/8/K7 w - - 0 1", []string{"e8Q", "e8=q", "e8(Q)", "e8/Q", "e7e8q"}, "e8=Q"},
+		{"4k3/8/8/8/8/2n5/1P1B4/4K3 w - - 0 1", []string{"bxc3", "b2:c3"}, "bxc3"},
+		{"4k3/8/8/8/8/2n5/1P1B4/4K3 w - - 0 1", []string{"bc1", "bd2c1"}, "Bc1"},
+	}
+	for _, test := range tests {
+		pos := unsafeFEN(test.fen)
+		for _, text := range test.texts {
+			m, err := n.Decode(pos, text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s := n.Encode(pos, m); s != test.move {
+				t.Fatalf("expected %s to decode to %s but got %s", text, test.move, s)
+			}
+		}
+	}
+	if _, err := (AlgebraicNotation{}).Decode(unsafeFEN(INITIAL_FEN_POSITION), "Ng1-f3"); err == nil {
+		t.Fatal("expected strict decoding to fail")
+	}
+	ambiguous := []struct {
+		fen   string
+		text  string
+		moves string
+	}{
+		{"8/4P3/8/8/8/k7/8/K7 w - - 0 1", "e8", "e8=Q, e8=R, e8=B, e8=N"},
+		{"4k3/8/8/8/8/2n5/1P1B4/4K3 w - - 0 1", "bc3", "Bxc3, bxc3"},
+	}
+	for _, test := range ambiguous {
+		_, err := n.Decode(unsafeFEN(test.fen), test.text)
+		if err == nil || !strings.HasSuffix(err.Error(), "could be "+test.moves) {
+			t.Fatalf("expected %s to be ambiguous between %s but got %v", test.text, test.moves, err)
+		}
+	}
+}
+
+func TestLenientPGN(t *testing.T) {
+	pgn := "1. e2-e4 e7:e5?! 2. ng1f3 Nb8c6 3. Bb5 a6 4. 0-0 *"
+	if _, err := decodePGN(nil, pgn); err == nil {
+		t.Fatal("expected strict decoding to fail")
+	}
+	game, err := decodePGN(UseNotation(AlgebraicNotation{Lenient: true}), pgn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := game.String(); s != "\n1. e4 e5 $6 2. Nf3 Nc6 3. Bb5 a6 4. O-O *" {
+		t.Fatalf("unexpected game %s", s)
+	}
+}
+
+func TestICCFNotation(t *testing.T) {
+	game := NewGame()
+	for _, m := range ruyLopez {
+		if err := game.MoveStr(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	game.SetNotation(ICCFNotation{})
+	expected := "1. 5254 5755 2. 7163 2836 3. 6125 1716 4. 2514 7866 5. 5171 6857 6. 6151 2725 7. 1423 4746 8. 3233 5878 9. 8283 3628 10. 4244 2847 11. 4455 4655 *"
+	if s := strings.TrimSpace(game.String()); s != expected {
+		t.Fatalf("expected\n%s\nbut got\n%s", expected, s)
+	}
+	cp, err := decodePGN(UseNotation(ICCFNotation{}), expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp.Position().String() != game.Position().String() {
+		t.Fatalf("expected %s but got %s", game.Position(), cp.Position())
+	}
+	pos := unsafeFEN("3n4/4P3/8/8/8/k7/8/K7 w - - 0 1")
+	for text, san := range map[string]string{"57581": "e8=Q", "57584": "e8=N", "57482": "exd8=R"} {
+		m, err := ICCFNotation{}.Decode(pos, text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := (AlgebraicNotation{}).Encode(pos, m); s != san {
+			t.Fatalf("expected %s to decode to %s but got %s", text, san, s)
+		}
+		if s := (ICCFNotation{}).Encode(pos, m); s != text {
+			t.Fatalf("expected %s to encode as %s but got %s", san, text, s)
+		}
+	}
+	for _, text := range []string{"5758", "57585", "5759", "e7e8", "575"} {
+		if _, err := (ICCFNotation{}).Decode(pos, text); err == nil {
+			t.Fatalf("expected %s to be invalid", text)
+		}
+	}
+}
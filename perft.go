@@ -0,0 +1,363 @@
+package chess
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PerftCounts holds the leaf node counts of a perft run.  Apart
+// from Nodes, the counters are only filled in when the run was
+// configured with PerftBreakdown.
+type PerftCounts struct {
+	Nodes      int
+	Captures   int
+	EnPassants int
+	Castles    int
+	Promotions int
+	Checks     int
+	Checkmates int
+}
+
+func (c *PerftCounts) add(o PerftCounts) {
+	c.Nodes += o.Nodes
+	c.Captures += o.Captures
+	c.EnPassants += o.EnPassants
+	c.Castles += o.Castles
+	c.Promotions += o.Promotions
+	c.Checks += o.Checks
+	c.Checkmates += o.Checkmates
+}
+
+// PerftDivision holds the counts below a single root move.
+type PerftDivision struct {
+	Move *Move
+	PerftCounts
+}
+
+// PerftResult is the result of PerftDivide.
+type PerftResult struct {
+	FEN       string
+	Depth     int
+	Divisions []PerftDivision
+	Total     PerftCounts
+	Duration  time.Duration
+}
+
+// String implements the fmt.Stringer interface and returns the
+// divide output: a line per root move in UCI notation followed
+// by the total.  Ex. e2e4: 9771
+func (r *PerftResult) String() string {
+	sb := strings.Builder{}
+	for _, d := range r.Divisions {
+		fmt.Fprintf(&sb, "%s: %d\n", d.Move, d.Nodes)
+	}
+	fmt.Fprintf(&sb, "\nNodes searched: %d\n", r.Total.Nodes)
+	return sb.String()
+}
+
+// PerftOption configures PerftDivide and PerftSuite.
+type PerftOption func(*perftConfig)
+
+type perftConfig struct {
+	breakdown   bool
+	workers     int
+	hashEntries int
+	variant     Variant
+}
+
+// PerftBreakdown returns an option that counts the captures, en
+// passant captures, castles, promotions, checks and checkmates
+// among the leaf nodes.  Mates are found by generating the replies
+// to every checking leaf move which slows counting down.
+func PerftBreakdown() PerftOption {
+	return func(c *perftConfig) {
+		c.breakdown = true
+	}
+}
+
+// PerftWorkers returns an option that splits the root moves across
+// n goroutines.
+func PerftWorkers(n int) PerftOption {
+	return func(c *perftConfig) {
+		c.workers = n
+	}
+}
+
+// PerftHashTable returns an option that caches subtree counts keyed
+// on the position's Zobrist key.  The table holds entries rounded
+// down to a power of two and is shared between the workers.
+func PerftHashTable(entries int) PerftOption {
+	return func(c *perftConfig) {
+		c.hashEntries = entries
+	}
+}
+
+// PerftVariant returns an option that decodes the FEN with the
+// variant's rules.  Moves are generated and made by the rules of the
+// built in variant it is or embeds.
+func PerftVariant(v Variant) PerftOption {
+	return func(c *perftConfig) {
+		c.variant = v
+	}
+}
+
+// PerftDivide counts the leaf nodes of the move generation tree of
+// the given depth for the FEN and reports them per root move.  Root
+// moves are listed in the same order as ValidMoves.
+func PerftDivide(fen string, depth int, options ...PerftOption) (*PerftResult, error) {
+	start := time.Now()
+	cfg := perftConfig{workers: 1, variant: StandardVariant{}}
+	for _, f := range options {
+		if f != nil {
+			f(&cfg)
+		}
+	}
+	pos, err := cfg.variant.DecodeFEN(fen)
+	if err != nil {
+		return nil, err
+	}
+	pos.setVariant(cfg.variant)
+	if depth < 1 {
+		return nil, fmt.Errorf("chess: invalid perft depth %d", depth)
+	}
+	pos.inCheck = isInCheck(pos)
+	var table *perftTable
+	if cfg.hashEntries > 0 {
+		table = newPerftTable(cfg.hashEntries)
+	}
+	moves := pos.ValidMoves()
+	divisions := make([]PerftDivision, len(moves))
+	jobs := make(chan int, len(moves))
+	for i, m := range moves {
+		divisions[i].Move = m
+		jobs <- i
+	}
+	close(jobs)
+	workers := cfg.workers
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(moves) {
+		workers = len(moves)
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p := &perfter{
+				breakdown: cfg.breakdown,
+				table:     table,
+				bufs:      make([][]Move, depth),
+			}
+			cp := pos.copy()
+			for i := range jobs {
+				divisions[i].PerftCounts = p.root(cp, divisions[i].Move, depth)
+			}
+		}()
+	}
+	wg.Wait()
+	result := &PerftResult{
+		FEN:       fen,
+		Depth:     depth,
+		Divisions: divisions,
+	}
+	for _, d := range divisions {
+		result.Total.add(d.PerftCounts)
+	}
+	result.Duration = time.Since(start)
+	return result, nil
+}
+
+// perfter counts a subtree on a single goroutine.  bufs holds a move
+// buffer per depth that is reused between siblings.
+type perfter struct {
+	breakdown bool
+	table     *perftTable
+	bufs      [][]Move
+}
+
+func (p *perfter) root(pos *Position, m *Move, depth int) PerftCounts {
+	if depth == 1 {
+		return p.leaf(pos, m)
+	}
+	u := pos.MakeMove(m)
+	c := p.count(pos, depth-1)
+	pos.UnmakeMove(m, u)
+	return c
+}
+
+func (p *perfter) count(pos *Position, depth int) PerftCounts {
+	if p.table != nil && depth > 1 {
+		if c, ok := p.table.get(pos.hash, depth); ok {
+			return c
+		}
+	}
+	moves := legalMoves(pos, p.bufs[depth][:0], false)
+	p.bufs[depth] = moves
+	var c PerftCounts
+	if depth == 1 && !p.breakdown {
+		c.Nodes = len(moves)
+		return c
+	}
+	for i := range moves {
+		c.add(p.root(pos, &moves[i], depth))
+	}
+	if p.table != nil && depth > 1 {
+		p.table.put(pos.hash, depth, c)
+	}
+	return c
+}
+
+// leaf returns the counts of the leaf reached by m.
+func (p *perfter) leaf(pos *Position, m *Move) PerftCounts {
+	c := PerftCounts{Nodes: 1}
+	if !p.breakdown {
+		return c
+	}
+	if m.HasTag(Capture) || m.HasTag(EnPassant) {
+		c.Captures++
+	}
+	if m.HasTag(EnPassant) {
+		c.EnPassants++
+	}
+	if m.HasTag(KingSideCastle) || m.HasTag(QueenSideCastle) {
+		c.Castles++
+	}
+	if m.promo != NoPieceType {
+		c.Promotions++
+	}
+	if m.HasTag(Check) {
+		c.Checks++
+		u := pos.MakeMove(m)
+		p.bufs[0] = legalMoves(pos, p.bufs[0][:0], true)
+		if len(p.bufs[0]) == 0 {
+			c.Checkmates++
+		}
+		pos.UnmakeMove(m, u)
+	}
+	return c
+}
+
+type perftEntry struct {
+	key    uint64
+	depth  int
+	counts PerftCounts
+}
+
+// perftTable is an always replace hash table of subtree counts.
+// Entries are guarded by striped locks so workers can share it.
+type perftTable struct {
+	entries []perftEntry
+	mask    uint64
+	locks   [256]sync.Mutex
+}
+
+func newPerftTable(entries int) *perftTable {
+	size := 1
+	for size*2 <= entries {
+		size *= 2
+	}
+	return &perftTable{
+		entries: make([]perftEntry, size),
+		mask:    uint64(size - 1),
+	}
+}
+
+func (t *perftTable) get(key uint64, depth int) (PerftCounts, bool) {
+	i := key & t.mask
+	l := &t.locks[i%uint64(len(t.locks))]
+	l.Lock()
+	e := t.entries[i]
+	l.Unlock()
+	if e.key != key || e.depth != depth {
+		return PerftCounts{}, false
+	}
+	return e.counts, true
+}
+
+func (t *perftTable) put(key uint64, depth int, c PerftCounts) {
+	i := key & t.mask
+	l := &t.locks[i%uint64(len(t.locks))]
+	l.Lock()
+	t.entries[i] = perftEntry{key: key, depth: depth, counts: c}
+	l.Unlock()
+}
+
+// PerftSuiteResult is the result of a single depth of a perft
+// suite position.
+type PerftSuiteResult struct {
+	FEN      string
+	Depth    int
+	Expected int
+	Nodes    int
+	Duration time.Duration
+}
+
+// Passed returns true if the node count matches the expected count.
+func (r PerftSuiteResult) Passed() bool {
+	return r.Nodes == r.Expected
+}
+
+// PerftSuite runs the perft suite read from r.  Each line holds an
+// EPD position followed by the expected counts as depth operations:
+//
+//	rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - ;D1 20 ;D2 400
+//
+// Blank lines and lines starting with # are skipped.  Depths above
+// maxDepth aren't run unless maxDepth is zero.  An error is returned
+// if the suite can't be parsed, failed counts are reported in the
+// results.
+func PerftSuite(r io.Reader, maxDepth int, options ...PerftOption) ([]PerftSuiteResult, error) {
+	var results []PerftSuiteResult
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.Split(line, ";")
+		fields := strings.Fields(parts[0])
+		if len(fields) == 4 {
+			fields = append(fields, "0", "1")
+		}
+		fen := strings.Join(fields, " ")
+		for _, op := range parts[1:] {
+			args := strings.Fields(op)
+			if len(args) != 2 || !strings.HasPrefix(args[0], "D") {
+				return nil, fmt.Errorf("chess: perft suite line %d invalid operation %q", n, op)
+			}
+			depth, err := strconv.Atoi(args[0][1:])
+			if err != nil {
+				return nil, fmt.Errorf("chess: perft suite line %d invalid depth %q", n, args[0])
+			}
+			expected, err := strconv.Atoi(args[1])
+			if err != nil {
+				return nil, fmt.Errorf("chess: perft suite line %d invalid count %q", n, args[1])
+			}
+			if maxDepth > 0 && depth > maxDepth {
+				continue
+			}
+			res, err := PerftDivide(fen, depth, options...)
+			if err != nil {
+				return nil, fmt.Errorf("chess: perft suite line %d: %w", n, err)
+			}
+			results = append(results, PerftSuiteResult{
+				FEN:      fen,
+				Depth:    depth,
+				Expected: expected,
+				Nodes:    res.Total.Nodes,
+				Duration: res.Duration,
+			})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,136 @@
+package chess
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPerftDivide(t *testing.T) {
+	fen := "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1"
+	expected, _, err := Perft(fen, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := PerftDivide(fen, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Divisions) != 48 {
+		t.Fatalf("expected 48 root moves but got %d", len(res.Divisions))
+	}
+	sum := 0
+	for _, d := range res.Divisions {
+		pos := unsafeFEN(fen).Update(d.Move)
+		n, _, err := Perft(pos.String(), 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Nodes != n {
+			t.Fatalf("expected %d nodes below %s but got %d", n, d.Move, d.Nodes)
+		}
+		sum += d.Nodes
+	}
+	if sum != expected || res.Total.Nodes != expected {
+		t.Fatalf("expected %d nodes but got %d and a total of %d", expected, sum, res.Total.Nodes)
+	}
+	if !strings.HasSuffix(res.String(), "\nNodes searched: 97862\n") {
+		t.Fatalf("unexpected divide output %s", res.String())
+	}
+}
+
+func TestPerftBreakdown(t *testing.T) {
+	tests := []struct {
+		fen      string
+		depth    int
+		expected PerftCounts
+	}{
+		{
+			fen:      INITIAL_FEN_POSITION,
+			depth:    4,
+			expected: PerftCounts{Nodes: 197281, Captures: 1576, Checks: 469, Checkmates: 8},
+		},
+		{
+			fen:      "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+			depth:    3,
+			expected: PerftCounts{Nodes: 97862, Captures: 17102, EnPassants: 45, Castles: 3162, Checks: 993, Checkmates: 1},
+		},
+		{
+			fen:      "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+			depth:    4,
+			expected: PerftCounts{Nodes: 43238, Captures: 3348, EnPassants: 123, Checks: 1680, Checkmates: 17},
+		},
+	}
+	for _, test := range tests {
+		res, err := PerftDivide(test.fen, test.depth, PerftBreakdown())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Total != test.expected {
+			t.Fatalf("perft %s depth %d expected %+v but got %+v", test.fen, test.depth, test.expected, res.Total)
+		}
+	}
+}
+
+func TestPerftWorkersAndHashTable(t *testing.T) {
+	fen := "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1"
+	options := [][]PerftOption{
+		{PerftWorkers(4)},
+		{PerftHashTable(1 << 16)},
+		{PerftWorkers(4), PerftHashTable(1 << 16), PerftBreakdown()},
+	}
+	expected, err := PerftDivide(fen, 4, PerftBreakdown())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, opts := range options {
+		res, err := PerftDivide(fen, 4, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Total.Nodes != expected.Total.Nodes {
+			t.Fatalf("expected %d nodes but got %d", expected.Total.Nodes, res.Total.Nodes)
+		}
+		for i, d := range res.Divisions {
+			if d.Nodes != expected.Divisions[i].Nodes {
+				t.Fatalf("expected %d nodes below %s but got %d", expected.Divisions[i].Nodes, d.Move, d.Nodes)
+			}
+		}
+	}
+	res, err := PerftDivide(fen, 4, options[2]...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != expected.Total {
+		t.Fatalf("expected %+v but got %+v", expected.Total, res.Total)
+	}
+}
+
+func TestPerftSuite(t *testing.T) {
+	f, err := os.Open("fixtures/perft/standard.epd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	results, err := PerftSuite(f, 3, PerftWorkers(4), PerftHashTable(1<<16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 21 {
+		t.Fatalf("expected 21 results but got %d", len(results))
+	}
+	for _, r := range results {
+		if !r.Passed() {
+			t.Fatalf("perft %s depth %d expected %d but got %d", r.FEN, r.Depth, r.Expected, r.Nodes)
+		}
+	}
+}
+
+func TestPerftSuiteInvalid(t *testing.T) {
+	if _, err := PerftSuite(strings.NewReader("8/8/8/8/8/8/8/8 w - - ;X1 20"), 0); err == nil {
+		t.Fatal("expected an error for an invalid operation")
+	}
+	if _, err := PerftDivide(INITIAL_FEN_POSITION, 0); err == nil {
+		t.Fatal("expected an error for depth 0")
+	}
+}
@@ -2,9 +2,11 @@ package chess
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"regexp"
+	"slices"
 	"strings"
 )
 
@@ -13,16 +15,109 @@ import (
 // from concatenated PGN files.  It is designed to
 // replace GamesFromPGN in order to handle very large
 // PGN database files such as https://database.lichess.org/.
+//
+// Games start at their tag pairs or, without any, at the first
+// line of their move text and end at their result.  Games that
+// can't be decoded are skipped and reported by Errors.  Escape lines
+// starting with % are kept with the game that follows them.  CRLF line
+// endings and a byte order mark are ignored.
 type Scanner struct {
-	scanr *bufio.Scanner
-	game  *Game
-	err   error
+	split  *pgnSplitter
+	game   *Game
+	offset int64
+	line   int
+	errs   []*ScanError
+	err    error
 }
 
 // NewScanner returns a new scanner.
 func NewScanner(r io.Reader) *Scanner {
-	scanr := bufio.NewScanner(r)
-	return &Scanner{scanr: scanr}
+	return &Scanner{split: newPGNSplitter(r)}
+}
+
+// ScanError is the error decoding a game skipped by Scanner.
+type ScanError struct {
+	Err error
+	// Offset is the byte offset of the start of the game.
+	Offset int64
+	// Line is the line number of the start of the game,
+	// starting at one.
+	Line int
+}
+
+// Error implements the error interface.
+func (e *ScanError) Error() string {
+	return fmt.Sprintf("chess: pgn game at line %d offset %d: %s", e.Line, e.Offset, e.Err)
+}
+
+// Unwrap returns Err.
+func (e *ScanError) Unwrap() error {
+	return e.Err
+}
+
+// Scan returns false if EOF was reached or there was an
+// error reading the input.  Running scan populates data
+// for Next(), Offset(), Line() and Err().
+func (s *Scanner) Scan() bool {
+	if s.err != nil {
+		return false
+	}
+	for {
+		raw, err := s.split.next()
+		if err != nil {
+			s.err = err
+			return false
+		}
+		game, err := decodePGN(nil, raw.text)
+		if err != nil {
+			s.errs = append(s.errs, &ScanError{Err: err, Offset: raw.offset, Line: raw.line})
+			continue
+		}
+		s.game, s.offset, s.line = game, raw.offset, raw.line
+		return true
+	}
+}
+
+// Next returns the game from the most recent Scan.
+func (s *Scanner) Next() *Game {
+	return s.game
+}
+
+// Offset returns the byte offset of the start of the game from
+// the most recent Scan.
+func (s *Scanner) Offset() int64 {
+	return s.offset
+}
+
+// Line returns the line number of the start of the game from
+// the most recent Scan, starting at one.
+func (s *Scanner) Line() int {
+	return s.line
+}
+
+// Errors returns the errors of the games skipped so far.
+func (s *Scanner) Errors() []*ScanError {
+	return append([]*ScanError(nil), s.errs...)
+}
+
+// Err returns an error encountered during scanning.
+// Typically this will be an io.EOF at the end of the
+// input or an error reading it.
+func (s *Scanner) Err() error {
+	return s.err
+}
+
+// rawGame is the text of a single game and where it starts.
+type rawGame struct {
+	text   string
+	offset int64
+	line   int
+}
+
+type pgnLine struct {
+	text   string
+	offset int64
+	num    int
 }
 
 type scanState int
@@ -33,70 +128,139 @@ const (
 	inMoves
 )
 
-// Scan returns false if there was an error parsing
-// a game or EOF was reached.  Running scan populates
-// data for Next() and Err().
-func (s *Scanner) Scan() bool {
-	moveListRe := regexp.MustCompile(`^\d+\.`)
-	if s.err == io.EOF {
-		return false
+// pgnSplitter splits a PGN stream into the text of its games.
+type pgnSplitter struct {
+	r       *bufio.Reader
+	offset  int64
+	lines   int
+	pending *pgnLine
+}
+
+func newPGNSplitter(r io.Reader) *pgnSplitter {
+	return &pgnSplitter{r: bufio.NewReader(r)}
+}
+
+var (
+	tagLineRe    = regexp.MustCompile(`^\s*\[\w+\s+".*"\s*\]\s*$`)
+	moveResultRe = regexp.MustCompile(`(?:^|\s)(?:1-0|0-1|1/2-1/2|\*)(?:\s|$)`)
+)
+
+// readLine returns the next line without its line ending.
+func (s *pgnSplitter) readLine() (*pgnLine, error) {
+	if l := s.pending; l != nil {
+		s.pending = nil
+		return l, nil
 	}
-	s.err = nil
+	text, err := s.r.ReadString('\n')
+	if text == "" {
+		return nil, err
+	}
+	l := &pgnLine{offset: s.offset, num: s.lines + 1}
+	s.offset += int64(len(text))
+	s.lines++
+	text = strings.TrimSuffix(text, "\n")
+	text = strings.TrimSuffix(text, "\r")
+	if l.num == 1 {
+		text = strings.TrimPrefix(text, "\ufeff")
+	}
+	l.text = text
+	return l, nil
+}
+
+// next returns the next game or io.EOF if there are no more.
+func (s *pgnSplitter) next() (*rawGame, error) {
+	var g *rawGame
 	var sb strings.Builder
 	state := notInPGN
-	setGame := func() bool {
-		game, err := decodePGN(nil, sb.String())
+	blank, inComment, ended, depth := false, false, false, 0
+	for {
+		l, err := s.readLine()
 		if err != nil {
-			s.err = err
-			return false
+			if err == io.EOF && g != nil {
+				g.text = sb.String()
+				return g, nil
+			}
+			return nil, err
 		}
-		s.game = game
-		return true
-	}
-	for {
-		scan := s.scanr.Scan()
-		if !scan {
-			s.err = s.scanr.Err()
-			// err is nil if io.EOF
-			if s.err == nil {
-				s.err = io.EOF
+		trimmed := strings.TrimSpace(l.text)
+		isTagPair := tagLineRe.MatchString(l.text) && !inComment
+		escape := strings.HasPrefix(l.text, "%") && !inComment
+		switch {
+		case state == notInPGN:
+			if escape {
+				// escape lines before the game are kept with it
+				sb.WriteString(l.text)
+				sb.WriteString("\n")
+				continue
+			}
+			if trimmed == "" {
+				continue
 			}
-			return setGame()
+			g = &rawGame{offset: l.offset, line: l.num}
+			state = inMoves
+			if strings.HasPrefix(trimmed, "[") {
+				state = inTagPairs
+			}
+		case isTagPair && (state == inMoves || blank):
+			// the tag pairs of the next game
+			s.pending = l
+			g.text = sb.String()
+			return g, nil
+		}
+		sb.WriteString(l.text)
+		sb.WriteString("\n")
+		if escape {
+			continue
 		}
-		line := strings.TrimSpace(s.scanr.Text())
-		isTagPair := strings.HasPrefix(line, "[")
-		isMoveSeq := moveListRe.MatchString(line) || strings.HasPrefix(line, "*")
-		switch state {
-		case notInPGN:
-			if !isTagPair {
-				break
+		if state == inTagPairs {
+			if trimmed == "" {
+				blank = true
+				continue
 			}
-			state = inTagPairs
-			sb.WriteString(line + "\n")
-		case inTagPairs:
-			if isMoveSeq {
-				state = inMoves
+			if strings.HasPrefix(trimmed, "[") {
+				continue
 			}
-			sb.WriteString(line + "\n")
-		case inMoves:
-			if line == "" {
-				return setGame()
+			state = inMoves
+		}
+		// the game ends after a comment open at its result
+		if scanMoveText(l.text, &inComment, &depth) || ended {
+			ended = true
+			if !inComment {
+				g.text = sb.String()
+				return g, nil
 			}
-			sb.WriteString(line + "\n")
 		}
 	}
 }
 
-// Next returns the game from the most recent Scan.
-func (s *Scanner) Next() *Game {
-	return s.game
-}
-
-// Err returns an error encountered during scanning.
-// Typically this will be a PGN parsing error or an
-// io.EOF.
-func (s *Scanner) Err() error {
-	return s.err
+// scanMoveText follows the comments and variations of a line of move
+// text and returns true if it holds the game's result.
+func scanMoveText(line string, inComment *bool, depth *int) bool {
+	plain := []byte(line)
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case *inComment:
+			*inComment = c != '}'
+		case c == '{':
+			*inComment = true
+		case c == ';':
+			for ; i < len(line); i++ {
+				plain[i] = ' '
+			}
+			continue
+		case c == '(':
+			*depth++
+		case c == ')':
+			if *depth > 0 {
+				*depth--
+			}
+		case *depth == 0:
+			continue
+		}
+		plain[i] = ' '
+	}
+	return moveResultRe.Match(plain)
 }
 
 // GamesFromPGN returns all PGN decoding games from the
@@ -138,15 +302,38 @@ func GamesFromPGN(r io.Reader) ([]*Game, error) {
 }
 
 func decodePGN(f func(*Game), pgn string) (*Game, error) {
+	pgn, escapes := splitEscapes(strings.TrimPrefix(pgn, "\ufeff"))
 	tagPairs := getTagPairs(pgn)
-	moveComments, outcome := moveListWithComments(pgn)
+	moveComments, comments, outcome, err := moveListWithComments(pgn)
+	if err != nil {
+		return nil, err
+	}
 	gameFuncs := []func(*Game){}
 	if f != nil {
 		gameFuncs = append(gameFuncs, f)
 	}
+	chess960 := false
+	var variant Variant
+	for _, tp := range tagPairs {
+		if strings.ToLower(tp.Key) == "variant" {
+			v, is960, err := variantFromTag(tp.Value)
+			if err != nil {
+				return nil, fmt.Errorf("chess: pgn decode error %s on tag %s", err.Error(), tp.Key)
+			}
+			gameFuncs = append(gameFuncs, WithVariant(v))
+			variant, chess960 = v, is960
+			break
+		}
+	}
 	for _, tp := range tagPairs {
 		if strings.ToLower(tp.Key) == "fen" {
 			fenFunc, err := FEN(tp.Value)
+			if chess960 {
+				fenFunc, err = FENWithMode(tp.Value, FENChess960)
+			}
+			if !isStandard(variant) {
+				fenFunc, err = VariantFEN(variant, tp.Value)
+			}
 			if err != nil {
 				return nil, fmt.Errorf("chess: pgn decode error %s on tag %s", err.Error(), tp.Key)
 			}
@@ -154,65 +341,98 @@ func decodePGN(f func(*Game), pgn string) (*Game, error) {
 			break
 		}
 	}
+	if chess960 {
+		gameFuncs = append(gameFuncs, func(g *Game) {
+			g.pos.chess960 = true
+			g.pos.validMoves = nil
+		})
+	}
 	gameFuncs = append(gameFuncs, TagPairs(tagPairs))
 	g := NewGame(gameFuncs...)
 	g.ignoreAutomaticDraws = true
-	decoder := g.Notation()
-	for index, move := range moveComments {
-		m, err := decoder.Decode(g.Position(), move.MoveStr)
-		if err != nil {
-			return nil, fmt.Errorf("chess: pgn decode error %s on move %d", err.Error(), g.Position().moveCount)
-		}
-		if err := g.Move(m); err != nil {
-			return nil, fmt.Errorf("chess: pgn invalid move error %s on move %d", err.Error(), g.Position().moveCount)
-		}
-		g.comments[index] = move.Comments
+	g.escapes = escapes
+	g.root.comments = comments
+	if tp := g.GetTagPair("TimeControl"); tp != nil {
+		// unknown or invalid values are left out, see TimeControlError
+		g.timeControl, _ = ParseTimeControl(tp.Value)
+	}
+	if err := decodeMoveList(g, g.root, moveComments); err != nil {
+		return nil, err
 	}
 	g.outcome = outcome
+	if tp := g.GetTagPair("Termination"); tp != nil && outcome != NoOutcome && g.method == NoMethod {
+		g.method = terminationMethods[strings.ToLower(tp.Value)]
+	}
 	return g, nil
 }
 
-func encodePGN(g *Game) string {
-	s := ""
-	for _, tag := range g.tagPairs {
-		s += fmt.Sprintf("[%s \"%s\"]\n", tag.Key, tag.Value)
+// terminationMethods are the methods of the Termination tag values
+// the outcome doesn't tell.
+var terminationMethods = map[string]Method{
+	"time forfeit":     Timeout,
+	"abandoned":        Abandoned,
+	"rules infraction": RulesInfraction,
+}
+
+// splitEscapes removes the escape lines, which start with %, from the
+// game's text and returns them.
+func splitEscapes(pgn string) (string, []string) {
+	lines := strings.Split(pgn, "\n")
+	cp := make([]string, 0, len(lines))
+	escapes := []string(nil)
+	inComment, depth := false, 0
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "%") && !inComment:
+			escapes = append(escapes, strings.TrimSuffix(line, "\r"))
+			continue
+		case !tagLineRe.MatchString(line):
+			scanMoveText(line, &inComment, &depth)
+		}
+		cp = append(cp, line)
 	}
-	s += "\n"
-
-	moveIndex := 0
-	for i, move := range g.moves {
-		pos := g.positions[i]
-		txt := g.notation.Encode(pos, move)
-		if i == 0 {
-			if pos.turn == Black {
-				moveIndex = 1
-			}
+	return strings.Join(cp, "\n"), escapes
+}
+
+// decodeMoveList plays the moves after the node, adding their
+// variations on the way.
+func decodeMoveList(g *Game, n *MoveNode, moves []moveWithComment) error {
+	decoder := g.Notation()
+	for _, move := range moves {
+		m, err := decoder.Decode(n.pos, move.MoveStr)
+		if err != nil {
+			return fmt.Errorf("chess: pgn decode error %s on move %d", err.Error(), n.pos.moveCount)
+		}
+		next, err := g.addMove(n, m)
+		if err != nil {
+			return fmt.Errorf("chess: pgn invalid move error %s on move %d", err.Error(), n.pos.moveCount)
 		}
-		if i == 0 {
-			if pos.turn == White {
-				s += fmt.Sprintf("%d. %s", (moveIndex/2)+1, txt)
-			} else {
-				s += fmt.Sprintf("%d... %s", (moveIndex/2)+1, txt)
+		// a move already in the tree, like a variation repeating the
+		// main line's move, keeps its annotations
+		next.comments = append(next.comments, move.Comments...)
+		next.preComments = append(next.preComments, move.PreComments...)
+		for _, nag := range move.NAGs {
+			if !slices.Contains(next.nags, nag) {
+				next.nags = append(next.nags, nag)
 			}
-		} else if moveIndex%2 == 0 {
-			s += fmt.Sprintf("%d. %s", (moveIndex/2)+1, txt)
-		} else {
-			s += txt
 		}
-		if len(g.comments) > i {
-			for _, c := range g.comments[i] {
-				s += " { " + c + " } "
+		for _, variation := range move.Variations {
+			if err := decodeMoveList(g, n, variation); err != nil {
+				return err
 			}
 		}
-		s += " "
-		moveIndex++
+		n = next
 	}
-	s += string(g.outcome)
-	return s
+	return nil
+}
+
+func encodePGN(g *Game) string {
+	return strings.TrimSuffix(pgnEncoderConfig{compact: true}.encode(g), "\n")
 }
 
 var (
-	tagPairRegex = regexp.MustCompile(`\[(.*)\s\"(.*)\"\]`)
+	tagPairRegex     = regexp.MustCompile(`\[(.*)\s\"(.*)\"\]`)
+	tagValueUnescape = strings.NewReplacer(`\\`, `\`, `\"`, `"`)
 )
 
 func getTagPairs(pgn string) []*TagPair {
@@ -223,7 +443,7 @@ func getTagPairs(pgn string) []*TagPair {
 		if len(results) == 3 {
 			pair := &TagPair{
 				Key:   results[1],
-				Value: results[2],
+				Value: tagValueUnescape.Replace(results[2]),
 			}
 			tagPairs = append(tagPairs, pair)
 		}
@@ -232,47 +452,128 @@ func getTagPairs(pgn string) []*TagPair {
 }
 
 type moveWithComment struct {
-	MoveStr  string
-	Comments []string
+	MoveStr     string
+	Comments    []string
+	PreComments []string
+	NAGs        []NAG
+	// Variations are the lines replacing the move.
+	Variations [][]moveWithComment
 }
 
-var moveListTokenRe = regexp.MustCompile(`(?:\d+\.)|(O-O(?:-O)?|\w*[abcdefgh][12345678]\w*(?:=[QRBN])?(?:\+|#)?)|(?:\{([^}]*)\})|(?:\([^)]*\))|(\*|0-1|1-0|1\/2-1\/2)`)
+var moveListTokenRe = regexp.MustCompile(`(?:\d+\.)|([O0]-[O0](?:-[O0])?|(?:[\w\p{L}\p{So}]*@)?[\w\p{L}\p{So}:-]*[abcdefgh][12345678][\w\p{L}\p{So}]*(?:=[\p{L}\p{So}]+)?(?:\+|#)?(?:e\.p\.)?|[KQRBNP]{1,3}(?:/[KQRBN]*\d?|\([KQRBN]*\d?\))?[-x][KQRBNP/\d]+(?:=[QRBN]|\([QRBN]\))?(?:ch|mate)?|\d{4,5})|(?:\{([^}]*)\})|(\$\d+|[!?]{1,2}|[=∞⩲⩱±∓]|\+-|-\+)|([()])|(\*|0-1|1-0|1\/2-1\/2)|(?:;([^\n]*))`)
 
-func moveListWithComments(pgn string) ([]moveWithComment, Outcome) {
-	pgn = stripTagPairs(pgn)
-	var outcome Outcome
-	moves := []moveWithComment{}
-
-	for _, match := range moveListTokenRe.FindAllStringSubmatch(pgn, -1) {
-		move, commentText, outcomeText := match[1], match[2], match[3]
-		if len(move+commentText+outcomeText) == 0 {
-			continue
-		}
+func moveListWithComments(pgn string) ([]moveWithComment, []string, Outcome, error) {
+	p := &moveListParser{tokens: moveListTokenRe.FindAllStringSubmatch(stripTagPairs(pgn), -1)}
+	moves, err := p.line(0)
+	return moves, p.comments, p.outcome, err
+}
 
-		if outcomeText != "" {
-			outcome = Outcome(outcomeText)
-			break
-		}
+// moveListParser reads the tokens of a move list into nested lines.
+type moveListParser struct {
+	tokens  [][]string
+	i       int
+	outcome Outcome
+	// comments are the game's comments before its first move
+	comments []string
+}
 
-		if commentText != "" {
-			moves[len(moves)-1].Comments = append(moves[len(moves)-1].Comments, strings.TrimSpace(commentText))
+// line reads the moves up to the end of the line nested at the given
+// depth, where zero is the main line.
+func (p *moveListParser) line(depth int) ([]moveWithComment, error) {
+	moves := []moveWithComment{}
+	// pending are the comments before the next move, which open a
+	// variation or follow one
+	pending := []string{}
+	end := func() {
+		switch {
+		case len(moves) > 0:
+			moves[len(moves)-1].Comments = append(moves[len(moves)-1].Comments, pending...)
+		case depth == 0:
+			p.comments = append(p.comments, pending...)
 		}
-
-		if move != "" {
-			moves = append(moves, moveWithComment{MoveStr: move})
+	}
+	for ; p.i < len(p.tokens); p.i++ {
+		match := p.tokens[p.i]
+		move, commentText, nag, paren, outcomeText := match[1], match[2]+match[6], match[3], match[4], match[5]
+		switch {
+		case outcomeText != "":
+			// results may end variations too but only the main
+			// line's is the game's
+			if depth == 0 {
+				p.outcome = Outcome(outcomeText)
+				// keep the comments after the result
+				for p.i++; p.i < len(p.tokens); p.i++ {
+					if c := p.tokens[p.i][2] + p.tokens[p.i][6]; c != "" {
+						pending = append(pending, cleanComment(c))
+					}
+				}
+				end()
+				return moves, nil
+			}
+		case commentText != "":
+			comment := cleanComment(commentText)
+			switch {
+			case len(moves) == 0 && depth == 0:
+				p.comments = append(p.comments, comment)
+			case len(moves) == 0 || len(pending) > 0 || len(moves[len(moves)-1].Variations) > 0:
+				pending = append(pending, comment)
+			default:
+				moves[len(moves)-1].Comments = append(moves[len(moves)-1].Comments, comment)
+			}
+		case nag != "":
+			n, err := ParseNAG(nag)
+			if err != nil {
+				return nil, err
+			}
+			if len(moves) > 0 {
+				moves[len(moves)-1].NAGs = append(moves[len(moves)-1].NAGs, n)
+			}
+		case paren == "(":
+			if len(moves) == 0 {
+				return nil, errors.New("chess: pgn variation before any move")
+			}
+			p.i++
+			variation, err := p.line(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			moves[len(moves)-1].Variations = append(moves[len(moves)-1].Variations, variation)
+		case paren == ")":
+			if depth == 0 {
+				return nil, errors.New("chess: pgn unbalanced variation")
+			}
+			end()
+			return moves, nil
+		case move != "":
+			moves = append(moves, moveWithComment{MoveStr: move, PreComments: pending})
+			pending = []string{}
 		}
 	}
-	return moves, outcome
+	if depth > 0 {
+		return nil, errors.New("chess: pgn unbalanced variation")
+	}
+	end()
+	return moves, nil
+}
+
+// cleanComment returns the comment's text as written, without the
+// spaces padding it and with its line endings made \n.
+func cleanComment(text string) string {
+	return strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
 }
 
+// stripTagPairs removes the tag pair lines outside of comments,
+// leaving the move text as written.
 func stripTagPairs(pgn string) string {
 	lines := strings.Split(pgn, "\n")
 	cp := []string{}
+	inComment, depth := false, 0
 	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line != "" && !strings.HasPrefix(line, "[") {
-			cp = append(cp, line)
+		if !inComment && tagLineRe.MatchString(line) {
+			continue
 		}
+		scanMoveText(line, &inComment, &depth)
+		cp = append(cp, line)
 	}
 	return strings.Join(cp, "\n")
 }
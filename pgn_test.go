@@ -3,6 +3,8 @@ package chess
 import (
 	"io"
 	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -168,6 +170,165 @@ func TestScanner(t *testing.T) {
 	}
 }
 
+func TestScannerRecovery(t *testing.T) {
+	pgn := "\ufeff[Event \"first\"]\r\n\r\n1. e4 e5 ; king pawn\r\n2. Nf3 1-0\r\n\r\n" +
+		"% escaped line\n" +
+		"[Event \"bad\"]\n\n1. e4 e4 *\n\n" +
+		"{ no tag pairs } 1. d4 { a comment\n[%clk 0:01:00] } d5 (1... Nf6\n2. c4) 0-1\n" +
+		"[Event \"no result\"]\n\n1. c4\n" +
+		"[Event \"last\"]\n1. f4 *"
+	scanner := NewScanner(strings.NewReader(pgn))
+	type scanned struct {
+		line  int
+		moves int
+		event string
+	}
+	expected := []scanned{{1, 3, "first"}, {11, 2, ""}, {14, 1, "no result"}, {17, 1, "last"}}
+	got := []scanned{}
+	for scanner.Scan() {
+		game := scanner.Next()
+		event := ""
+		if tp := game.GetTagPair("Event"); tp != nil {
+			event = tp.Value
+		}
+		got = append(got, scanned{scanner.Line(), len(game.Moves()), event})
+		start := pgn[scanner.Offset():]
+		if event != "" && !strings.HasPrefix(start, "[Event \""+event) && !strings.HasPrefix(start, "\ufeff[Event") {
+			t.Fatalf("expected game %s at offset %d but found %.20q", event, scanner.Offset(), start)
+		}
+	}
+	if scanner.Err() != io.EOF {
+		t.Fatalf("expected io.EOF but got %v", scanner.Err())
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("expected games %v but got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("expected game %d to be %v but got %v", i, expected[i], got[i])
+		}
+	}
+	errs := scanner.Errors()
+	if len(errs) != 1 || errs[0].Line != 7 || !strings.HasPrefix(pgn[errs[0].Offset:], "[Event \"bad\"]") {
+		t.Fatalf("expected the bad game at line 7 to be reported but got %v", errs)
+	}
+	if errs[0].Unwrap() == nil || !strings.Contains(errs[0].Error(), "line 7") {
+		t.Fatalf("unexpected error %s", errs[0])
+	}
+}
+
+func TestPGNRoundTrip(t *testing.T) {
+	fnames, err := filepath.Glob("fixtures/pgns/*.pgn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fname := range fnames {
+		f, err := os.Open(fname)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		scanner := NewScanner(f)
+		for n := 0; scanner.Scan(); n++ {
+			game := scanner.Next()
+			for _, compact := range []bool{false, true} {
+				cfg := pgnEncoderConfig{compact: compact, width: 80}
+				pgn := cfg.encode(game)
+				cp, err := decodePGN(nil, pgn)
+				if err != nil {
+					t.Fatalf("%s game %d: %s", fname, n, err)
+				}
+				if cfg.encode(cp) != pgn {
+					t.Fatalf("%s game %d: expected\n%s\nbut got\n%s", fname, n, pgn, cfg.encode(cp))
+				}
+				if cp.Position().String() != game.Position().String() || cp.Outcome() != game.Outcome() || cp.Method() != game.Method() {
+					t.Fatalf("%s game %d: expected %s %s %s but got %s %s %s", fname, n,
+						game.Position(), game.Outcome(), game.Method(), cp.Position(), cp.Outcome(), cp.Method())
+				}
+				// comments wrapped at the line width read back with line
+				// breaks in place of the spaces
+				if !reflect.DeepEqual(commentWords(cp), commentWords(game)) || !reflect.DeepEqual(cp.NAGs(), game.NAGs()) {
+					t.Fatalf("%s game %d: expected the comments and NAGs to round trip", fname, n)
+				}
+			}
+		}
+		if len(scanner.Errors()) > 0 {
+			t.Fatalf("%s: %v", fname, scanner.Errors())
+		}
+	}
+}
+
+func commentWords(g *Game) [][][]string {
+	words := [][][]string{}
+	for _, comments := range g.Comments() {
+		w := [][]string{}
+		for _, c := range comments {
+			w = append(w, strings.Fields(c))
+		}
+		words = append(words, w)
+	}
+	return words
+}
+
+func TestPGNRoundTripLossless(t *testing.T) {
+	pgn := `% exported by hand
+[Event "?"]
+[Termination "Time forfeit"]
+
+{Played in the first round.} 1. e4 e5 2. Nf3 (2. f4 exf4) ({Or} 2. Nc3 {the Vienna}
+Nf6) {Back to the game.} 2... Nc6 1-0 {White won on time.
+Well played.}
+`
+	game, err := decodePGN(nil, pgn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if game.Method() != Timeout {
+		t.Fatalf("expected %s but got %s", Timeout, game.Method())
+	}
+	if c := game.Root().Comments(); len(c) != 1 || c[0] != "Played in the first round." {
+		t.Fatalf("expected the comment before the first move but got %v", c)
+	}
+	if c := game.Node(4).Comments(); len(c) != 1 || c[0] != "White won on time.\nWell played." {
+		t.Fatalf("expected the comment after the result but got %v", c)
+	}
+	if c := game.Node(2).Children()[2].PreComments(); len(c) != 1 || c[0] != "Or" {
+		t.Fatalf("expected the comment opening the variation but got %v", c)
+	}
+	if c := game.Node(4).PreComments(); len(c) != 1 || c[0] != "Back to the game." {
+		t.Fatalf("expected the comment after the variations but got %v", c)
+	}
+	expected := `% exported by hand
+[Event "?"]
+[Termination "Time forfeit"]
+
+{Played in the first round.} 1. e4 e5 2. Nf3 (2. f4 exf4) ({Or} 2. Nc3 {the Vienna} 2... Nf6) {Back to the game.} 2... Nc6 {White won on time.
+Well played.} 1-0`
+	if game.String() != expected {
+		t.Fatalf("expected\n%s\nbut got\n%s", expected, game.String())
+	}
+	scanner := NewScanner(strings.NewReader(pgn + "\n% next\n\n" + pgn))
+	for n := 0; n < 2; n++ {
+		if !scanner.Scan() {
+			t.Fatalf("expected game %d but got %v", n, scanner.Err())
+		}
+		if escapes := scanner.Next().escapes; len(escapes) != n+1 {
+			t.Fatalf("expected the escape lines of game %d but got %v", n, escapes)
+		}
+	}
+	if scanner.Scan() {
+		t.Fatal("expected two games")
+	}
+	game.RemoveTagPair("Termination")
+	sb := strings.Builder{}
+	if err := NewPGNEncoder(&sb).Encode(game); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sb.String(), `[Termination "time forfeit"]`) {
+		t.Fatalf("expected the Termination tag in\n%s", sb.String())
+	}
+}
+
 func BenchmarkPGN(b *testing.B) {
 	pgn, _ := mustParsePGN()("fixtures/pgns/0001.pgn")
 	b.ResetTimer()
@@ -222,7 +383,7 @@ func TestScannerWithFromPosFENs(t *testing.T) {
 	games := []*Game{}
 	for idx := 0; scanner.Scan(); {
 		game := scanner.Next()
-		if len(game.moves) == 0 {
+		if len(game.Moves()) == 0 {
 			continue
 		}
 		finalPos := game.Position().String()
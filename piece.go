@@ -131,12 +131,11 @@ var (
 // NewPiece returns the piece matching the PieceType and Color.
 // NoPiece is returned if the PieceType or Color isn't valid.
 func NewPiece(t PieceType, c Color) Piece {
-	for _, p := range allPieces {
-		if p.Color() == c && p.Type() == t {
-			return p
-		}
+	if t < King || t > Pawn || (c != White && c != Black) {
+		return NoPiece
 	}
-	return NoPiece
+	// pieces are declared by color in the same order as the piece types
+	return Piece(int8(c-White)*6 + int8(t))
 }
 
 // Type returns the type of the piece.
@@ -0,0 +1,185 @@
+package chess
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"sync"
+)
+
+// DecodedGame is a game decoded by DecodeGames.
+type DecodedGame struct {
+	// Game is the decoded game or nil if Err is set.
+	Game *Game
+	// Err is a *ScanError for a game that couldn't be decoded or
+	// the error reading the input, which ends the results.
+	Err error
+	// Index is the position of the game in the input, from zero.
+	Index int
+	// Offset is the byte offset of the start of the game.
+	Offset int64
+	// Line is the line number of the start of the game, starting
+	// at one.
+	Line int
+}
+
+// DecodeOption configures DecodeGames.
+type DecodeOption func(*decodeConfig)
+
+type decodeConfig struct {
+	workers   int
+	unordered bool
+}
+
+// DecodeWorkers returns an option that decodes games on n goroutines.
+// The default is GOMAXPROCS.
+func DecodeWorkers(n int) DecodeOption {
+	return func(c *decodeConfig) {
+		c.workers = n
+	}
+}
+
+// DecodeUnordered returns an option that sends games as soon as they
+// are decoded rather than in input order.
+func DecodeUnordered() DecodeOption {
+	return func(c *decodeConfig) {
+		c.unordered = true
+	}
+}
+
+type decodeJob struct {
+	index int
+	raw   *rawGame
+	err   error
+}
+
+// DecodeGames splits the PGN read from r into games on one goroutine,
+// the way Scanner does, and decodes them on a pool of workers.  The
+// games are sent on the returned channel in input order unless
+// DecodeUnordered is given.  Games that can't be decoded are sent with
+// their error and decoding carries on.  The channel is closed at the
+// end of the input, after a read error or once ctx is done.
+//
+//	for dg := range chess.DecodeGames(ctx, f, chess.DecodeWorkers(8)) {
+//		if dg.Err != nil {
+//			// handle error
+//			continue
+//		}
+//		fmt.Println(dg.Game.GetTagPair("Site"))
+//	}
+func DecodeGames(ctx context.Context, r io.Reader, options ...DecodeOption) <-chan DecodedGame {
+	cfg := decodeConfig{workers: runtime.GOMAXPROCS(0)}
+	for _, f := range options {
+		if f != nil {
+			f(&cfg)
+		}
+	}
+	if cfg.workers < 1 {
+		cfg.workers = 1
+	}
+	jobs := make(chan decodeJob, cfg.workers)
+	results := make(chan DecodedGame, cfg.workers)
+	out := make(chan DecodedGame, cfg.workers)
+	// window bounds the games between the splitter and the
+	// output so that waiting for a slow game doesn't buffer
+	// the rest of the input
+	window := make(chan struct{}, 4*cfg.workers)
+
+	go func() {
+		defer close(jobs)
+		split := newPGNSplitter(r)
+		for i := 0; ; i++ {
+			raw, err := split.next()
+			if err == io.EOF {
+				return
+			}
+			select {
+			case window <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- decodeJob{index: i, raw: raw, err: err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < cfg.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				var job decodeJob
+				var ok bool
+				select {
+				case job, ok = <-jobs:
+					if !ok {
+						return
+					}
+				case <-ctx.Done():
+					return
+				}
+				res := DecodedGame{Index: job.index, Err: job.err}
+				if job.raw != nil {
+					res.Offset, res.Line = job.raw.offset, job.raw.line
+					game, err := decodePGN(nil, job.raw.text)
+					if err != nil {
+						res.Err = &ScanError{Err: err, Offset: job.raw.offset, Line: job.raw.line}
+					}
+					res.Game = game
+				}
+				select {
+				case results <- res:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	go func() {
+		defer close(out)
+		send := func(res DecodedGame) bool {
+			select {
+			case out <- res:
+				<-window
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		pending := map[int]DecodedGame{}
+		next := 0
+		for res := range results {
+			if cfg.unordered {
+				if !send(res) {
+					return
+				}
+				continue
+			}
+			pending[res.Index] = res
+			for {
+				res, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				if !send(res) {
+					return
+				}
+				next++
+			}
+		}
+	}()
+	return out
+}
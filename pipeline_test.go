@@ -0,0 +1,103 @@
+package chess
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func pipelinePGN(t *testing.T) string {
+	t.Helper()
+	pgn := ""
+	for _, fname := range []string{"fixtures/pgns/0006.pgn", "fixtures/pgns/0007.pgn", "fixtures/pgns/0014.pgn"} {
+		b, err := os.ReadFile(fname)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pgn += string(b) + "\n\n"
+	}
+	return pgn + "[Event \"bad\"]\n\n1. e4 e4 *\n\n[Event \"last\"]\n\n1. d4 *\n"
+}
+
+func TestDecodeGamesOrdered(t *testing.T) {
+	pgn := pipelinePGN(t)
+	scanner := NewScanner(strings.NewReader(pgn))
+	expected := []string{}
+	for scanner.Scan() {
+		expected = append(expected, scanner.Next().Position().String())
+	}
+	got := []string{}
+	index := 0
+	for dg := range DecodeGames(context.Background(), strings.NewReader(pgn), DecodeWorkers(4)) {
+		if dg.Index != index {
+			t.Fatalf("expected game %d but got %d", index, dg.Index)
+		}
+		index++
+		if dg.Err != nil {
+			var scanErr *ScanError
+			if !errors.As(dg.Err, &scanErr) || dg.Index != 15 || scanErr.Line != dg.Line {
+				t.Fatalf("unexpected error for game %d: %v", dg.Index, dg.Err)
+			}
+			continue
+		}
+		if !strings.HasPrefix(pgn[dg.Offset:], "[Event") {
+			t.Fatalf("expected game %d at offset %d", dg.Index, dg.Offset)
+		}
+		got = append(got, dg.Game.Position().String())
+	}
+	if len(got) != 16 || strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("expected the scanner's %d games but got %d", len(expected), len(got))
+	}
+}
+
+func TestDecodeGamesUnordered(t *testing.T) {
+	pgn := pipelinePGN(t)
+	indexes := []int{}
+	for dg := range DecodeGames(context.Background(), strings.NewReader(pgn), DecodeWorkers(3), DecodeUnordered()) {
+		indexes = append(indexes, dg.Index)
+	}
+	sort.Ints(indexes)
+	if len(indexes) != 17 {
+		t.Fatalf("expected 17 games but got %d", len(indexes))
+	}
+	for i, index := range indexes {
+		if i != index {
+			t.Fatalf("expected every game once but got %v", indexes)
+		}
+	}
+}
+
+func TestDecodeGamesCancel(t *testing.T) {
+	pgn := strings.Repeat(pipelinePGN(t), 20)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	n := 0
+	for range DecodeGames(ctx, strings.NewReader(pgn), DecodeWorkers(2)) {
+		n++
+		if n == 3 {
+			cancel()
+		}
+	}
+	if n >= 17*20 {
+		t.Fatalf("expected decoding to stop after cancel but got %d games", n)
+	}
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, errors.New("read failed")
+}
+
+func TestDecodeGamesReadError(t *testing.T) {
+	results := []DecodedGame{}
+	for dg := range DecodeGames(context.Background(), errReader{}) {
+		results = append(results, dg)
+	}
+	if len(results) != 1 || results[0].Err == nil || results[0].Err.Error() != "read failed" {
+		t.Fatalf("expected the read error but got %v", results)
+	}
+}
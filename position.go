@@ -55,8 +55,25 @@ type Position struct {
 	moveCount       int
 	inCheck         bool
 	validMoves      []*Move
+	hash            uint64
+	chess960        bool
+	castleRooks     [4]Square
+	variant         Variant
+	crazyhouse      bool
+	pockets         [3]Pocket
+	promoted        Bitboard
+	threeCheck      bool
+	checks          [3]uint8
+	kingOfTheHill   bool
+	atomic          bool
+	antichess       bool
+	horde           bool
 }
 
+// standardCastleRooks are the rook squares of the castle rights
+// in the order of the castle bits used by MarshalBinary.
+var standardCastleRooks = [4]Square{H1, A1, H8, A8}
+
 const (
 	INITIAL_FEN_POSITION = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
 	INITIAL_POSITION     = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR"
@@ -74,40 +91,171 @@ func StartingPosition() *Position {
 // Game's Move method.  This method is more performant for bots that
 // rely on the ValidMoves because it skips redundant validation.
 func (pos *Position) Update(m *Move) *Position {
-	if m == nil {
-		return &Position{
-			board:           pos.board.copy(),
-			turn:            pos.turn,
-			castleRights:    pos.castleRights,
-			enPassantSquare: pos.enPassantSquare,
-			halfMoveClock:   pos.halfMoveClock,
-			moveCount:       pos.moveCount,
-			inCheck:         pos.inCheck,
+	cp := pos.copy()
+	if m != nil {
+		cp.MakeMove(m)
+	}
+	return cp
+}
+
+// MoveUndo holds the state that MakeMove overwrites and that
+// UnmakeMove needs to restore the position.
+type MoveUndo struct {
+	captured        Piece
+	castleRights    CastleRights
+	enPassantSquare Square
+	halfMoveClock   int
+	inCheck         bool
+	validMoves      []*Move
+	hash            uint64
+	pockets         [3]Pocket
+	promoted        Bitboard
+	checks          [3]uint8
+	exploded        [9]Piece
+}
+
+// Captured returns the piece captured by the move or NoPiece.
+func (u MoveUndo) Captured() Piece {
+	return u.captured
+}
+
+// MakeMove applies the move to the position in place and returns
+// the record UnmakeMove needs to take it back.  Like Update, the
+// move isn't validated.  Unlike Update, MakeMove doesn't allocate
+// which makes it suited to search and perft loops:
+//
+//	u := pos.MakeMove(m)
+//	// ... inspect pos ...
+//	pos.UnmakeMove(m, u)
+func (pos *Position) MakeMove(m *Move) MoveUndo {
+	u := MoveUndo{
+		castleRights:    pos.castleRights,
+		enPassantSquare: pos.enPassantSquare,
+		halfMoveClock:   pos.halfMoveClock,
+		inCheck:         pos.inCheck,
+		validMoves:      pos.validMoves,
+		hash:            pos.hash,
+		pockets:         pos.pockets,
+		promoted:        pos.promoted,
+		checks:          pos.checks,
+	}
+	h := pos.hash ^ zobristCastle[pos.castleRights.bits()] ^ pos.zobristEnPassant()
+	p := NewPiece(m.drop, pos.turn)
+	if m.drop == NoPieceType {
+		p = pos.board.Piece(m.s1)
+		pos.castleRights = pos.updateCastleRights(m)
+		pos.enPassantSquare = pos.updateEnPassantSquare(m)
+	} else {
+		pos.enPassantSquare = NoSquare
+	}
+	if m.drop != NoPieceType {
+		pos.board.put(p, m.s2)
+		h ^= zobristPieces[p][m.s2] ^ pos.addToPocket(p.Color(), m.drop, -1)
+	} else if m.HasTag(KingSideCastle) || m.HasTag(QueenSideCastle) {
+		kingTo, rookFrom, rookTo := pos.castleSquares(m, p.Color())
+		pos.board.castle(p.Color(), m.s1, kingTo, rookFrom, rookTo)
+		rook := NewPiece(Rook, p.Color())
+		h ^= zobristPieces[p][m.s1] ^ zobristPieces[p][kingTo]
+		h ^= zobristPieces[rook][rookFrom] ^ zobristPieces[rook][rookTo]
+	} else {
+		u.captured = pos.board.update(m)
+		p2 := p
+		if m.promo != NoPieceType {
+			p2 = NewPiece(m.promo, p.Color())
+		}
+		h ^= zobristPieces[p][m.s1] ^ zobristPieces[p2][m.s2]
+		if u.captured != NoPiece {
+			capSq := m.s2
+			if m.HasTag(EnPassant) {
+				capSq = enPassantCaptureSquare(m.s2, p.Color())
+			}
+			h ^= zobristPieces[u.captured][capSq]
+		}
+		if pos.crazyhouse {
+			h ^= pos.pocketCapture(m, p.Color(), u.captured)
+		}
+		if pos.atomic && u.captured != NoPiece {
+			h ^= pos.explode(m, &u.exploded)
 		}
 	}
-	moveCount := pos.moveCount
+	if pos.threeCheck && m.HasTag(Check) {
+		h ^= pos.addCheck(p.Color())
+	}
+	if p.Type() == Pawn || u.captured != NoPiece || m.HasTag(Capture) {
+		pos.halfMoveClock = 0
+	} else {
+		pos.halfMoveClock++
+	}
 	if pos.turn == Black {
-		moveCount++
+		pos.moveCount++
 	}
-	ncr := pos.updateCastleRights(m)
-	p := pos.board.Piece(m.s1)
-	halfMove := pos.halfMoveClock
-	if p.Type() == Pawn || m.HasTag(Capture) {
-		halfMove = 0
+	pos.turn = pos.turn.Other()
+	pos.inCheck = m.HasTag(Check)
+	pos.validMoves = nil
+	pos.hash = h ^ zobristCastle[pos.castleRights.bits()] ^ pos.zobristEnPassant() ^ zobristTurn
+	return u
+}
+
+// UnmakeMove takes back a move applied with MakeMove.  Moves must
+// be taken back in the reverse order they were made.
+func (pos *Position) UnmakeMove(m *Move, u MoveUndo) {
+	pos.turn = pos.turn.Other()
+	if m.drop != NoPieceType {
+		pos.board.remove(NewPiece(m.drop, pos.turn), m.s2)
+	} else if m.HasTag(KingSideCastle) || m.HasTag(QueenSideCastle) {
+		kingTo, rookFrom, rookTo := pos.castleSquares(m, pos.turn)
+		pos.board.castle(pos.turn, kingTo, m.s1, rookTo, rookFrom)
 	} else {
-		halfMove++
+		if pos.atomic && u.captured != NoPiece {
+			pos.board.unexplode(m.s2, &u.exploded)
+		}
+		pos.board.undo(m, u.captured)
 	}
-	b := pos.board.copy()
-	b.update(m)
-	return &Position{
-		board:           b,
-		turn:            pos.turn.Other(),
-		castleRights:    ncr,
-		enPassantSquare: pos.updateEnPassantSquare(m),
-		halfMoveClock:   halfMove,
-		moveCount:       moveCount,
-		inCheck:         m.HasTag(Check),
+	if pos.turn == Black {
+		pos.moveCount--
+	}
+	pos.castleRights = u.castleRights
+	pos.enPassantSquare = u.enPassantSquare
+	pos.halfMoveClock = u.halfMoveClock
+	pos.inCheck = u.inCheck
+	pos.validMoves = u.validMoves
+	pos.hash = u.hash
+	pos.pockets = u.pockets
+	pos.promoted = u.promoted
+	pos.checks = u.checks
+}
+
+// castleSquares returns the king's destination and the rook's origin
+// and destination of the castle move m by color c.  In Chess960
+// positions castle moves are encoded as the king taking its own
+// rook, otherwise as the king moving two squares.
+func (pos *Position) castleSquares(m *Move, c Color) (Square, Square, Square) {
+	i, kingFile, rookFile := 0, FileG, FileF
+	if m.HasTag(QueenSideCastle) {
+		i, kingFile, rookFile = 1, FileC, FileD
+	}
+	if c == Black {
+		i += 2
+	}
+	rank := m.s1.Rank()
+	return NewSquare(kingFile, rank), pos.castleRooks[i], NewSquare(rookFile, rank)
+}
+
+// Chess960 returns true if the position is a Chess960 (Fischer
+// Random) position.  Castle moves of Chess960 positions are encoded
+// as the king taking its own rook, Ex. b1a1 or O-O-O.
+func (pos *Position) Chess960() bool {
+	return pos.chess960
+}
+
+// validMove returns the valid move matching the squares and promotion
+// of m or nil.  Unlike ValidMoves, the cached moves are neither copied
+// nor sorted.
+func (pos *Position) validMove(m *Move) *Move {
+	if pos.validMoves == nil {
+		pos.validMoves = pos.calcMoves()
 	}
+	return MoveSlice(pos.validMoves).find(m)
 }
 
 // ValidMoves returns a list of valid moves for the position.
@@ -116,17 +264,28 @@ func (pos *Position) ValidMoves() []*Move {
 	if pos.validMoves != nil {
 		temp = append([]*Move(nil), pos.validMoves...)
 	} else {
-		pos.validMoves = engine{}.CalcMoves(pos, false)
+		pos.validMoves = pos.calcMoves()
 		temp = append([]*Move(nil), pos.validMoves...)
 	}
 	sort.Sort(MoveSlice(temp))
 	return temp
 }
 
+// calcMoves generates the valid moves by the position's variant.
+func (pos *Position) calcMoves() []*Move {
+	if pos.variant == nil {
+		return engine{}.CalcMoves(pos, false)
+	}
+	return pos.variant.ValidMoves(pos)
+}
+
 // Status returns the position's status as one of the outcome methods.
 // Possible returns values include Checkmate, Stalemate, and NoMethod.
 func (pos *Position) Status() Method {
-	return engine{}.Status(pos)
+	if pos.variant == nil {
+		return engine{}.Status(pos)
+	}
+	return pos.variant.Status(pos)
 }
 
 // Board returns the position's board.
@@ -164,10 +323,19 @@ func (pos *Position) CastleRights() CastleRights {
 
 // String implements the fmt.Stringer interface and returns a
 // string with the FEN format: rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1
+// Variants may extend the FEN.
 func (pos *Position) String() string {
+	if pos.variant == nil {
+		return pos.fen()
+	}
+	return pos.variant.EncodeFEN(pos)
+}
+
+// fen returns the position's standard FEN.
+func (pos *Position) fen() string {
 	b := pos.board.String()
 	t := pos.turn.String()
-	c := pos.castleRights.String()
+	c := pos.xfenCastleRights()
 	sq := "-"
 	if pos.enPassantSquare != NoSquare {
 		sq = pos.enPassantSquare.String()
@@ -191,17 +359,13 @@ func (pos *Position) MarshalText() (text []byte, err error) {
 // UnmarshalText implements the encoding.TextUnarshaler interface and
 // assumes the data is in the FEN format.
 func (pos *Position) UnmarshalText(text []byte) error {
-	cp, err := decodeFEN(string(text))
+	cp, err := pos.Variant().DecodeFEN(string(text))
 	if err != nil {
 		return err
 	}
-	pos.board = cp.board
-	pos.castleRights = cp.castleRights
-	pos.turn = cp.turn
-	pos.enPassantSquare = cp.enPassantSquare
-	pos.halfMoveClock = cp.halfMoveClock
-	pos.moveCount = cp.moveCount
-	pos.inCheck = isInCheck(cp)
+	cp.setVariant(pos.variant)
+	cp.inCheck = isInCheck(cp)
+	*pos = *cp
 	return nil
 }
 
@@ -230,19 +394,7 @@ func (pos *Position) MarshalBinary() (data []byte, err error) {
 	if err := binary.Write(buf, binary.BigEndian, pos.enPassantSquare); err != nil {
 		return nil, err
 	}
-	var b uint8
-	if pos.castleRights.CanCastle(White, KingSide) {
-		b = b | bitsCastleWhiteKing
-	}
-	if pos.castleRights.CanCastle(White, QueenSide) {
-		b = b | bitsCastleWhiteQueen
-	}
-	if pos.castleRights.CanCastle(Black, KingSide) {
-		b = b | bitsCastleBlackKing
-	}
-	if pos.castleRights.CanCastle(Black, QueenSide) {
-		b = b | bitsCastleBlackQueen
-	}
+	b := pos.castleRights.bits()
 	if pos.turn == Black {
 		b = b | bitsTurn
 	}
@@ -306,10 +458,20 @@ func (pos *Position) UnmarshalBinary(data []byte) error {
 	if b&bitsHasEnPassant == 0 {
 		pos.enPassantSquare = NoSquare
 	}
+	pos.chess960 = false
+	pos.castleRooks = standardCastleRooks
 	pos.inCheck = isInCheck(pos)
+	pos.validMoves = nil
+	pos.hash = pos.zobrist()
 	return nil
 }
 
+// Clone returns a copy of the position that can be changed, ex. by
+// MakeMove, without changing the original.
+func (pos *Position) Clone() *Position {
+	return pos.copy()
+}
+
 func (pos *Position) copy() *Position {
 	return &Position{
 		board:           pos.board.copy(),
@@ -319,28 +481,66 @@ func (pos *Position) copy() *Position {
 		halfMoveClock:   pos.halfMoveClock,
 		moveCount:       pos.moveCount,
 		inCheck:         pos.inCheck,
+		hash:            pos.hash,
+		chess960:        pos.chess960,
+		castleRooks:     pos.castleRooks,
+		variant:         pos.variant,
+		crazyhouse:      pos.crazyhouse,
+		pockets:         pos.pockets,
+		promoted:        pos.promoted,
+		threeCheck:      pos.threeCheck,
+		checks:          pos.checks,
+		kingOfTheHill:   pos.kingOfTheHill,
+		atomic:          pos.atomic,
+		antichess:       pos.antichess,
+		horde:           pos.horde,
 	}
 }
 
 func (pos *Position) updateCastleRights(m *Move) CastleRights {
-	cr := string(pos.castleRights)
-	p := pos.board.Piece(m.s1)
-	if p == WhiteKing || m.s1 == H1 || m.s2 == H1 {
-		cr = strings.Replace(cr, "K", "", -1)
-	}
-	if p == WhiteKing || m.s1 == A1 || m.s2 == A1 {
-		cr = strings.Replace(cr, "Q", "", -1)
+	if pos.castleRights == "-" {
+		return pos.castleRights
+	}
+	// moving the king or moving from or to a rook square loses the rights
+	rights := pos.castleRights.bits()
+	moved := bbForSquare(m.s1) | bbForSquare(m.s2)
+	for i, sq := range pos.castleRooks {
+		if moved&bbForSquare(sq) != 0 {
+			rights &^= 1 << i
+		}
 	}
-	if p == BlackKing || m.s1 == H8 || m.s2 == H8 {
-		cr = strings.Replace(cr, "k", "", -1)
+	if m.s1 == pos.board.whiteKingSq {
+		rights &^= bitsCastleWhiteKing | bitsCastleWhiteQueen
 	}
-	if p == BlackKing || m.s1 == A8 || m.s2 == A8 {
-		cr = strings.Replace(cr, "q", "", -1)
+	if m.s1 == pos.board.blackKingSq {
+		rights &^= bitsCastleBlackKing | bitsCastleBlackQueen
 	}
-	if cr == "" {
-		cr = "-"
+	return castleRightsStrs[rights]
+}
+
+// bits returns the rights as the castle bits used by MarshalBinary.
+func (cr CastleRights) bits() uint8 {
+	var b uint8
+	for _, r := range cr {
+		switch r {
+		case 'K':
+			b |= bitsCastleWhiteKing
+		case 'Q':
+			b |= bitsCastleWhiteQueen
+		case 'k':
+			b |= bitsCastleBlackKing
+		case 'q':
+			b |= bitsCastleBlackQueen
+		}
 	}
-	return CastleRights(cr)
+	return b
+}
+
+// castleRightsStrs maps the castle bits used by MarshalBinary
+// to their FEN string.
+var castleRightsStrs = [16]CastleRights{
+	"-", "K", "Q", "KQ", "k", "Kk", "Qk", "KQk",
+	"q", "Kq", "Qq", "KQq", "kq", "Kkq", "Qkq", "KQkq",
 }
 
 func (pos *Position) updateEnPassantSquare(m *Move) Square {
@@ -360,50 +560,25 @@ func (pos *Position) updateEnPassantSquare(m *Move) Square {
 	return NoSquare
 }
 
-func (pos *Position) samePosition(pos2 *Position) bool {
-	return pos.board.Equal(pos2.board) &&
-		pos.turn == pos2.turn &&
-		pos.castleRights.String() == pos2.castleRights.String() &&
-		pos.enPassantSquare == pos2.enPassantSquare
-}
-
 // XFENString() is similar to String() except that it returns a string with
 // the X-FEN format
 func (pos *Position) XFENString() string {
 	b := pos.board.String()
 	t := pos.turn.String()
-	c := pos.castleRights.String()
+	c := pos.xfenCastleRights()
 	sq := "-"
-	if pos.enPassantSquare != NoSquare {
-		// Check if there is a pawn in a position to capture en passant
-		var rank Rank
-		if pos.turn == White {
-			rank = Rank5
-		} else {
-			rank = Rank4
-		}
-		// The en passant target square will always be on the rank opposite the current turn's pawns
-		file := pos.enPassantSquare.File()
-		potentialPawnFiles := []File{file - 1, file + 1} // Pawns that could capture en passant will be on an adjacent file
-
-		for _, f := range potentialPawnFiles {
-			if f < FileA || f > FileH { // Ensure file is within bounds
-				continue
-			}
-
-			potentialPawnSquare := NewSquare(f, rank)
-			potentialPawn := pos.board.Piece(potentialPawnSquare)
-			if potentialPawn == NoPiece {
-				continue
-			}
-			if potentialPawn.Type() != Pawn {
-				continue
-			}
-			if potentialPawn.Color() == pos.turn {
-				sq = pos.enPassantSquare.String()
-				break
-			}
-		}
+	if pos.enPassantCapturable() {
+		sq = pos.enPassantSquare.String()
 	}
 	return fmt.Sprintf("%s %s %s %s %d %d", b, t, c, sq, pos.halfMoveClock, pos.moveCount)
 }
+
+// enPassantCapturable returns true if a pawn of the side to move
+// stands next to the pawn that can be captured en passant.
+func (pos *Position) enPassantCapturable() bool {
+	if pos.enPassantSquare == NoSquare {
+		return false
+	}
+	pawns := pos.board.bbForPiece(NewPiece(Pawn, pos.turn))
+	return bbPawnAttacks[pos.turn.Other()][pos.enPassantSquare]&pawns != 0
+}
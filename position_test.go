@@ -86,3 +86,59 @@ func TestPositionMarshalling2(t *testing.T) {
 		t.Fatal("Missing error")
 	}
 }
+
+func TestPositionMakeUnmakeMove(t *testing.T) {
+	for _, fen := range validFENs {
+		pos, err := decodeFEN(fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range pos.ValidMoves() {
+			expected := pos.Update(m).String()
+			u := pos.MakeMove(m)
+			if pos.String() != expected {
+				t.Fatalf("after move %s from %s expected %s but got %s", m, fen, expected, pos.String())
+			}
+			pos.UnmakeMove(m, u)
+			if pos.String() != fen {
+				t.Fatalf("after taking back move %s expected %s but got %s", m, fen, pos.String())
+			}
+		}
+	}
+}
+
+func TestPositionClone(t *testing.T) {
+	pos := StartingPosition()
+	cp := pos.Clone()
+	m := cp.ValidMoves()[0]
+	cp.MakeMove(m)
+	if pos.String() != StartingPosition().String() || pos.Hash() != StartingPosition().Hash() {
+		t.Fatalf("expected making %s on the clone not to change the position but got %s", m, pos)
+	}
+}
+
+func TestPositionMakeMoveAllocs(t *testing.T) {
+	pos := unsafeFEN("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+	moves := pos.ValidMoves()
+	allocs := testing.AllocsPerRun(100, func() {
+		for _, m := range moves {
+			u := pos.MakeMove(m)
+			pos.UnmakeMove(m, u)
+		}
+	})
+	if allocs != 0 {
+		t.Fatalf("expected make and unmake move not to allocate but got %f allocations", allocs)
+	}
+}
+
+func BenchmarkPositionMakeUnmakeMove(b *testing.B) {
+	pos := unsafeFEN("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+	moves := pos.ValidMoves()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for _, m := range moves {
+			u := pos.MakeMove(m)
+			pos.UnmakeMove(m, u)
+		}
+	}
+}
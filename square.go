@@ -27,6 +27,43 @@ func NewSquare(f File, r Rank) Square {
 	return Square(int8(r)*numOfSquaresInRow + int8(f))
 }
 
+// Bitboard returns the bitboard holding only the square.
+func (sq Square) Bitboard() Bitboard {
+	if sq < A1 || sq > H8 {
+		return 0
+	}
+	return bbForSquare(sq)
+}
+
+// Diagonal returns the a1-h8 direction diagonal through the square.
+func (sq Square) Diagonal() Bitboard {
+	return Ray(sq, NorthEast) | Ray(sq, SouthWest) | sq.Bitboard()
+}
+
+// AntiDiagonal returns the a8-h1 direction diagonal through the square.
+func (sq Square) AntiDiagonal() Bitboard {
+	return Ray(sq, NorthWest) | Ray(sq, SouthEast) | sq.Bitboard()
+}
+
+// Distance returns the Chebyshev distance between the squares, the
+// number of moves a king needs to go from one to the other.
+func (sq Square) Distance(other Square) int {
+	return max(abs(int(sq.File())-int(other.File())), abs(int(sq.Rank())-int(other.Rank())))
+}
+
+// ManhattanDistance returns the number of files plus the number of
+// ranks between the squares.
+func (sq Square) ManhattanDistance(other Square) int {
+	return abs(int(sq.File())-int(other.File())) + abs(int(sq.Rank())-int(other.Rank()))
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
 func (sq Square) color() Color {
 	if ((sq / 8) % 2) == (sq % 2) {
 		return Black
@@ -121,6 +158,14 @@ const (
 	Rank8
 )
 
+// Bitboard returns the squares of the rank.
+func (r Rank) Bitboard() Bitboard {
+	if r < Rank1 || r > Rank8 {
+		return 0
+	}
+	return bbRanks[r]
+}
+
 func (r Rank) String() string {
 	return rankChars[r : r+1]
 }
@@ -139,6 +184,14 @@ const (
 	FileH
 )
 
+// Bitboard returns the squares of the file.
+func (f File) Bitboard() Bitboard {
+	if f < FileA || f > FileH {
+		return 0
+	}
+	return bbFiles[f]
+}
+
 func (f File) String() string {
 	return fileChars[f : f+1]
 }
@@ -27,3 +27,24 @@ func TestNewSquare(t *testing.T) {
 		}
 	}
 }
+
+func TestSquareDistance(t *testing.T) {
+	testCases := []struct {
+		s1, s2    Square
+		distance  int
+		manhattan int
+	}{
+		{A1, A1, 0, 0},
+		{A1, H8, 7, 14},
+		{E4, F6, 2, 3},
+		{B7, G7, 5, 5},
+	}
+	for _, testCase := range testCases {
+		if d := testCase.s1.Distance(testCase.s2); d != testCase.distance {
+			t.Fatalf("expected distance %d between %s and %s, got %d", testCase.distance, testCase.s1, testCase.s2, d)
+		}
+		if d := testCase.s2.ManhattanDistance(testCase.s1); d != testCase.manhattan {
+			t.Fatalf("expected manhattan distance %d between %s and %s, got %d", testCase.manhattan, testCase.s1, testCase.s2, d)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package chess
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ThreeCheckVariant is Three-check: standard chess that is also won
+// by giving check three times.
+//
+// Three-check FENs append the number of checks each side has given,
+// Ex. rnbqkbnr/ppp2ppp/8/3pp3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 3 +1+0
+// The lichess form of the checks left as a fifth field, Ex. 2+3, is
+// decoded too.
+type ThreeCheckVariant struct{}
+
+// String implements the Variant interface.
+func (ThreeCheckVariant) String() string {
+	return "Three-check"
+}
+
+// StartingPosition implements the Variant interface.
+func (ThreeCheckVariant) StartingPosition() *Position {
+	pos := StartingPosition()
+	pos.setVariant(ThreeCheckVariant{})
+	return pos
+}
+
+// DecodeFEN implements the Variant interface.
+func (ThreeCheckVariant) DecodeFEN(fen string) (*Position, error) {
+	parts := strings.Fields(fen)
+	var checks [3]uint8
+	var err error
+	switch {
+	case len(parts) == 7 && strings.HasPrefix(parts[6], "+"):
+		checks, err = fenChecks(parts[6][1:], "+", false)
+		parts = parts[:6]
+	case len(parts) == 7:
+		checks, err = fenChecks(parts[4], "+", true)
+		parts = append(parts[:4], parts[5:]...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	pos, err := decodeFEN(strings.Join(parts, " "))
+	if err != nil {
+		return nil, err
+	}
+	pos.checks = checks
+	pos.setVariant(ThreeCheckVariant{})
+	return pos, nil
+}
+
+// fenChecks reads the checks given by white and black, or the checks
+// they have left if remaining is true, separated by sep.
+func fenChecks(s, sep string, remaining bool) ([3]uint8, error) {
+	var checks [3]uint8
+	err := fmt.Errorf("chess: fen invalid checks %s", s)
+	counts := strings.Split(s, sep)
+	if len(counts) != 2 {
+		return checks, err
+	}
+	for i, c := range []Color{White, Black} {
+		n, convErr := strconv.Atoi(counts[i])
+		if convErr != nil || n < 0 || n > 3 {
+			return checks, err
+		}
+		if remaining {
+			n = 3 - n
+		}
+		checks[c] = uint8(n)
+	}
+	return checks, nil
+}
+
+// EncodeFEN implements the Variant interface.
+func (ThreeCheckVariant) EncodeFEN(pos *Position) string {
+	return fmt.Sprintf("%s +%d+%d", pos.fen(), pos.checks[White], pos.checks[Black])
+}
+
+// ValidMoves implements the Variant interface.
+func (ThreeCheckVariant) ValidMoves(pos *Position) []*Move {
+	return engine{}.CalcMoves(pos, false)
+}
+
+// Status implements the Variant interface.  ThreeChecks is returned
+// once a side has given the third check.
+func (ThreeCheckVariant) Status(pos *Position) Method {
+	return engine{}.Status(pos)
+}
+
+// Outcome implements the Variant interface.
+func (ThreeCheckVariant) Outcome(pos *Position, method Method) Outcome {
+	return variantOutcome(pos, method)
+}
+
+// Checks returns the number of checks the given color has given in
+// a Three-check game.
+func (pos *Position) Checks(c Color) int {
+	if c != White && c != Black {
+		return 0
+	}
+	return int(pos.checks[c])
+}
+
+// addCheck counts a check given by color c and returns the change of
+// the Zobrist key.
+func (pos *Position) addCheck(c Color) uint64 {
+	old := pos.checks[c]
+	if old >= 3 {
+		return 0
+	}
+	pos.checks[c]++
+	return zobristChecks[c][old] ^ zobristChecks[c][old+1]
+}
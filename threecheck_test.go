@@ -0,0 +1,69 @@
+package chess
+
+import "testing"
+
+func TestThreeCheck(t *testing.T) {
+	g := NewGame(WithVariant(ThreeCheckVariant{}))
+	for _, s := range []string{"e4", "f6", "Qh5+"} {
+		if err := g.MoveStr(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if g.Position().Checks(White) != 1 || g.Position().Checks(Black) != 0 {
+		t.Fatalf("expected one check by white but got %s", g.Position())
+	}
+	expected := "rnbqkbnr/ppppp1pp/5p2/7Q/4P3/8/PPPP1PPP/RNB1KBNR b KQkq - 1 2 +1+0"
+	if g.Position().String() != expected {
+		t.Fatalf("expected %s but got %s", expected, g.Position().String())
+	}
+	fen, err := VariantFEN(ThreeCheckVariant{}, "rnbqkbnr/ppp2ppp/8/3pp3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 0 3 +2+0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g = NewGame(fen)
+	if g.Variant().String() != "Three-check" {
+		t.Fatalf("expected a Three-check game but got %s", g.Variant())
+	}
+	if err := g.MoveStr("Bb5+"); err != nil {
+		t.Fatal(err)
+	}
+	if g.Outcome() != WhiteWon || g.Method() != ThreeChecks {
+		t.Fatalf("expected white to win by three checks but got %s by %s", g.Outcome(), g.Method())
+	}
+}
+
+func TestThreeCheckFEN(t *testing.T) {
+	tests := []struct {
+		fen      string
+		expected string
+	}{
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1 +0+0"},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1 +2+1", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1 +2+1"},
+		// lichess counts the checks left
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 3+1 0 1", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1 +0+2"},
+	}
+	for _, test := range tests {
+		pos := unsafeVariantFEN(ThreeCheckVariant{}, test.fen)
+		if pos.String() != test.expected {
+			t.Fatalf("expected %s but got %s", test.expected, pos.String())
+		}
+	}
+	if _, err := (ThreeCheckVariant{}).DecodeFEN("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1 +4+0"); err == nil {
+		t.Fatal("expected an error for four checks")
+	}
+	h1 := unsafeVariantFEN(ThreeCheckVariant{}, tests[0].fen).ZobristHash()
+	h2 := unsafeVariantFEN(ThreeCheckVariant{}, tests[1].fen).ZobristHash()
+	if h1 == h2 {
+		t.Fatal("expected the checks to change the key")
+	}
+}
+
+func TestThreeCheckPerft(t *testing.T) {
+	res, err := PerftDivide("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 1+1 0 1", 3, PerftVariant(ThreeCheckVariant{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total.Nodes != 97848 {
+		t.Fatalf("expected 97848 nodes but got %d", res.Total.Nodes)
+	}
+}
@@ -0,0 +1,137 @@
+package chess
+
+// ColorFlip returns the position with the board flipped top to bottom
+// and the colors of the pieces swapped, so that White plays Black's
+// game and vice versa.  The side to move, castle rights, en passant
+// square, pockets and checks are swapped along with them.  Horde
+// positions, whose sides play by different rules, are returned
+// unchanged.
+func (pos *Position) ColorFlip() *Position {
+	if pos.horde {
+		return pos.copy()
+	}
+	return pos.transform(flipRank, true)
+}
+
+// Mirror returns the position with the board flipped left to right.
+// Castling isn't symmetric as the king stands on the e file, so the
+// castle rights are dropped.
+func (pos *Position) Mirror() *Position {
+	return pos.transform(flipFile, false)
+}
+
+// Canonical returns a single representative of the positions that are
+// the same up to symmetry: the position itself, its color flip and,
+// without castle rights, its mirror.  Positions without pawns or
+// castle rights are also the same under rotations and diagonal flips.
+// The representative is the one with the smallest FEN so equivalent
+// positions share the same canonical position.
+func (pos *Position) Canonical() *Position {
+	maps := []func(Square) Square{identitySquare}
+	if pos.castleRights == "-" {
+		maps = append(maps, flipFile)
+		if pos.board.bbWhitePawn|pos.board.bbBlackPawn == 0 && !pos.crazyhouse {
+			maps = append(maps, flipRank, rotate180, transposeSquare, antiTransposeSquare, rotate90, rotate270)
+		}
+	}
+	best := pos.copy()
+	bestFEN := best.String()
+	for _, f := range maps {
+		for _, swap := range []bool{false, true} {
+			if swap && pos.horde {
+				continue
+			}
+			g := f
+			if swap {
+				g = func(sq Square) Square { return flipRank(f(sq)) }
+			}
+			cand := pos.transform(g, swap)
+			if fen := cand.String(); fen < bestFEN {
+				best, bestFEN = cand, fen
+			}
+		}
+	}
+	return best
+}
+
+// transform returns the position with every square mapped by f and,
+// if swap is true, the colors swapped.  Castle rights are only kept
+// by maps that leave the files alone.
+func (pos *Position) transform(f func(Square) Square, swap bool) *Position {
+	cp := pos.copy()
+	m := map[Square]Piece{}
+	for sq, p := range pos.board.SquareMap() {
+		if swap {
+			p = NewPiece(p.Type(), p.Color().Other())
+		}
+		m[f(sq)] = p
+	}
+	cp.board = NewBoard(m)
+	cp.promoted = 0
+	for _, sq := range pos.promoted.Squares() {
+		cp.promoted |= bbForSquare(f(sq))
+	}
+	if pos.enPassantSquare != NoSquare {
+		cp.enPassantSquare = f(pos.enPassantSquare)
+	}
+	cp.castleRights, cp.castleRooks = "-", standardCastleRooks
+	if pos.castleRights != "-" && f(A1).File() == FileA && f(H1).File() == FileH {
+		rights := pos.castleRights.bits()
+		var bits uint8
+		for i, sq := range pos.castleRooks {
+			j := i
+			if swap {
+				j = (i + 2) % 4
+			}
+			if rights&(1<<i) != 0 {
+				bits |= 1 << j
+			}
+			cp.castleRooks[j] = f(sq)
+		}
+		cp.castleRights = castleRightsStrs[bits]
+	} else {
+		cp.chess960 = false
+	}
+	if swap {
+		cp.turn = pos.turn.Other()
+		cp.pockets[White], cp.pockets[Black] = pos.pockets[Black], pos.pockets[White]
+		cp.checks[White], cp.checks[Black] = pos.checks[Black], pos.checks[White]
+	}
+	cp.inCheck = isInCheck(cp)
+	cp.hash = cp.zobrist()
+	return cp
+}
+
+func identitySquare(sq Square) Square {
+	return sq
+}
+
+func flipRank(sq Square) Square {
+	return NewSquare(sq.File(), Rank8-sq.Rank())
+}
+
+func flipFile(sq Square) Square {
+	return NewSquare(FileH-sq.File(), sq.Rank())
+}
+
+func rotate180(sq Square) Square {
+	return flipFile(flipRank(sq))
+}
+
+// transposeSquare flips the square over the a1-h8 diagonal.
+func transposeSquare(sq Square) Square {
+	return NewSquare(File(sq.Rank()), Rank(sq.File()))
+}
+
+// antiTransposeSquare flips the square over the a8-h1 diagonal.
+func antiTransposeSquare(sq Square) Square {
+	return rotate180(transposeSquare(sq))
+}
+
+func rotate90(sq Square) Square {
+	return flipFile(transposeSquare(sq))
+}
+
+func rotate270(sq Square) Square {
+	return flipRank(transposeSquare(sq))
+}
@@ -0,0 +1,89 @@
+package chess
+
+import "testing"
+
+func TestColorFlip(t *testing.T) {
+	pos := unsafeFEN("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1")
+	flipped := pos.ColorFlip()
+	expected := "rnbqkbnr/pppp1ppp/8/4p3/8/8/PPPPPPPP/RNBQKBNR w KQkq e6 0 1"
+	if flipped.String() != expected {
+		t.Fatalf("expected %s but got %s", expected, flipped.String())
+	}
+	if flipped.ZobristHash() != unsafeFEN(expected).ZobristHash() {
+		t.Fatal("expected the key to match the FEN's")
+	}
+	if back := flipped.ColorFlip(); back.String() != pos.String() {
+		t.Fatalf("expected flipping twice to give %s but got %s", pos.String(), back.String())
+	}
+	kiwipete := unsafeFEN("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1").ColorFlip()
+	res, err := PerftDivide(kiwipete.String(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total.Nodes != 97862 {
+		t.Fatalf("expected the flipped position to have 97862 nodes but got %d", res.Total.Nodes)
+	}
+}
+
+func TestColorFlipVariants(t *testing.T) {
+	pos := unsafeVariantFEN(CrazyhouseVariant{}, "r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R[Nnp] w KQkq - 2 3")
+	expected := "rnbqkb1r/pppp1ppp/5n2/4p3/4P3/2N5/PPPP1PPP/R1BQKBNR[NPn] b KQkq - 2 3"
+	if flipped := pos.ColorFlip(); flipped.String() != expected {
+		t.Fatalf("expected %s but got %s", expected, flipped.String())
+	}
+	pos = unsafeVariantFEN(ThreeCheckVariant{}, "4k3/8/8/8/8/8/8/4K3 w - - 0 1 +2+1")
+	expected = "4k3/8/8/8/8/8/8/4K3 b - - 0 1 +1+2"
+	if flipped := pos.ColorFlip(); flipped.String() != expected {
+		t.Fatalf("expected %s but got %s", expected, flipped.String())
+	}
+}
+
+func TestMirror(t *testing.T) {
+	pos := unsafeFEN("4k3/8/8/3pP3/8/8/8/R3K2R w KQ d6 0 1")
+	expected := "3k4/8/8/3Pp3/8/8/8/R2K3R w - e6 0 1"
+	if mirrored := pos.Mirror(); mirrored.String() != expected {
+		t.Fatalf("expected %s but got %s", expected, mirrored.String())
+	}
+	if len(pos.Mirror().ValidMoves()) != len(unsafeFEN("4k3/8/8/3pP3/8/8/8/R3K2R w - d6 0 1").ValidMoves()) {
+		t.Fatal("expected the mirrored position to have as many moves as the position without castle rights")
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	tests := []struct {
+		fens  []string
+		equal bool
+	}{
+		{[]string{
+			"7k/8/8/8/8/8/8/KR6 w - - 0 1",
+			"kr6/8/8/8/8/8/8/7K b - - 0 1",
+			"7k/8/8/8/8/8/R7/K7 w - - 0 1",
+			"k7/8/8/8/8/8/8/6RK w - - 0 1",
+		}, true},
+		{[]string{
+			"4k3/8/8/8/8/8/4P3/4K3 w - - 0 1",
+			"3k4/8/8/8/8/8/3P4/3K4 w - - 0 1",
+			"3k4/3p4/8/8/8/8/8/3K4 b - - 0 1",
+		}, true},
+		{[]string{
+			"4k3/8/8/8/8/8/4P3/4K3 w - - 0 1",
+			"4k3/8/8/8/8/8/3P4/4K3 w - - 0 1",
+		}, false},
+		{[]string{
+			"r3k3/8/8/8/8/8/8/4K3 w q - 0 1",
+			"3k3r/8/8/8/8/8/8/3K4 w - - 0 1",
+		}, false},
+	}
+	for _, test := range tests {
+		canonical := unsafeFEN(test.fens[0]).Canonical().String()
+		for _, fen := range test.fens[1:] {
+			c := unsafeFEN(fen).Canonical().String()
+			if (c == canonical) != test.equal {
+				t.Fatalf("expected %s and %s to be equivalent %t but got %s and %s", test.fens[0], fen, test.equal, canonical, c)
+			}
+		}
+		if again := unsafeFEN(canonical).Canonical().String(); again != canonical {
+			t.Fatalf("expected the canonical position %s to be its own but got %s", canonical, again)
+		}
+	}
+}
@@ -169,9 +169,13 @@ func (cmd CmdSetOption) ProcessResponse(e *Engine) error {
 // if the game was played  from the start position the string "startpos" will be sent
 // Note: no "new" command is needed. However, if this position is from a different game than
 // the last position sent to the engine, the GUI should have sent a "ucinewgame" inbetween.
+//
+// Chess960 should be set if the engine's UCI_Chess960 option is on so
+// castles are sent as the king taking its own rook.
 type CmdPosition struct {
 	Position *chess.Position
 	Moves    []*chess.Move
+	Chess960 bool
 }
 
 func (cmd CmdPosition) String() string {
@@ -181,10 +185,13 @@ func (cmd CmdPosition) String() string {
 	if len(cmd.Moves) == 0 {
 		return "position fen " + cmd.Position.String()
 	}
+	notation := chess.UCINotation{Chess960: cmd.Chess960}
 	moveStrs := []string{}
+	pos := cmd.Position
 	for _, m := range cmd.Moves {
-		mStr := chess.UCINotation{}.Encode(nil, m)
+		mStr := notation.Encode(pos, m)
 		moveStrs = append(moveStrs, mStr)
+		pos = pos.Update(m)
 	}
 	return fmt.Sprintf("position fen %s moves %s", cmd.Position, strings.Join(moveStrs, " "))
 }
@@ -0,0 +1,261 @@
+package chess
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Variant is the interface implemented by rule sets.  A variant
+// decides the starting position, the valid moves, when the game is
+// over and how its positions are written as FEN.  Games play standard
+// chess unless they are constructed with the WithVariant option or
+// decoded from a PGN with a Variant tag.
+//
+// How moves are made, such as drops, explosions and counting checks,
+// can't be changed: it is fixed by the built in variant a variant
+// embeds.  Variants are made by embedding one of them, which the
+// interface's unexported method requires, and overriding its methods.
+// Ex. a variant embedding AtomicVariant explodes captures whatever its
+// ValidMoves and Status return and one embedding StandardVariant makes
+// moves as in standard chess.
+type Variant interface {
+	// String returns the variant's name as used by the PGN Variant tag.
+	String() string
+	// StartingPosition returns the position games of the variant
+	// start from.
+	StartingPosition() *Position
+	// DecodeFEN decodes a FEN including the variant's extensions.
+	DecodeFEN(fen string) (*Position, error)
+	// EncodeFEN encodes the position as FEN including the variant's
+	// extensions.
+	EncodeFEN(pos *Position) string
+	// ValidMoves returns the legal moves of the position.
+	ValidMoves(pos *Position) []*Move
+	// Status returns the position's status as one of the outcome
+	// methods, NoMethod or InCheck if the game goes on.
+	Status(pos *Position) Method
+	// Outcome returns the outcome of a game that ended by the method
+	// returned by Status.
+	Outcome(pos *Position, method Method) Outcome
+
+	// rules returns the rules of the built in variant by which moves
+	// are made.
+	rules() variantRules
+}
+
+// StandardVariant is the standard rule set of the FIDE laws of
+// chess.  Chess960 games are played with the standard rules.
+type StandardVariant struct{}
+
+// String implements the Variant interface.
+func (StandardVariant) String() string {
+	return "Standard"
+}
+
+// StartingPosition implements the Variant interface.
+func (StandardVariant) StartingPosition() *Position {
+	return StartingPosition()
+}
+
+// DecodeFEN implements the Variant interface.
+func (StandardVariant) DecodeFEN(fen string) (*Position, error) {
+	return decodeFEN(fen)
+}
+
+// EncodeFEN implements the Variant interface.
+func (StandardVariant) EncodeFEN(pos *Position) string {
+	return pos.fen()
+}
+
+// ValidMoves implements the Variant interface.
+func (StandardVariant) ValidMoves(pos *Position) []*Move {
+	return engine{}.CalcMoves(pos, false)
+}
+
+// Status implements the Variant interface.
+func (StandardVariant) Status(pos *Position) Method {
+	return engine{}.Status(pos)
+}
+
+// Outcome implements the Variant interface.
+func (StandardVariant) Outcome(pos *Position, method Method) Outcome {
+	switch method {
+	case Checkmate:
+		if pos.Turn() == White {
+			return BlackWon
+		}
+		return WhiteWon
+	case Stalemate, InsufficientMaterial:
+		return Draw
+	}
+	return NoOutcome
+}
+
+// WithVariant returns a function that starts the game from the
+// variant's starting position and plays it by the variant's rules.
+// Options applied after it, such as FEN, use the variant too.  The
+// returned function is designed to be used in the NewGame constructor.
+func WithVariant(v Variant) func(*Game) {
+	return func(g *Game) {
+		pos := v.StartingPosition()
+		pos.setVariant(v)
+		g.setRoot(&MoveNode{pos: pos})
+		if !isStandard(v) {
+			g.AddTagPair("Variant", v.String())
+		}
+		g.updatePosition()
+	}
+}
+
+// variants are the variants the PGN Variant tag knows about.
+var variants = []Variant{
+	StandardVariant{},
+	CrazyhouseVariant{},
+	ThreeCheckVariant{},
+	KingOfTheHillVariant{},
+	AtomicVariant{},
+	AntichessVariant{},
+	HordeVariant{},
+}
+
+// variantFromTag returns the variant named by a PGN Variant tag.
+// Chess960 games are standard games with Chess960 castling.
+func variantFromTag(tag string) (Variant, bool, error) {
+	name := variantKey(tag)
+	switch name {
+	case "", "fromposition":
+		return StandardVariant{}, false, nil
+	case "chess960", "fischerandom", "fischerrandom", "960":
+		return StandardVariant{}, true, nil
+	case "losingchess":
+		return AntichessVariant{}, false, nil
+	}
+	for _, v := range variants {
+		if variantKey(v.String()) == name {
+			return v, false, nil
+		}
+	}
+	return nil, false, fmt.Errorf("chess: unsupported variant %s", tag)
+}
+
+// variantKey lowercases the name and drops spaces and dashes so that
+// "King of the Hill" and "kingOfTheHill" match.
+func variantKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' || r == '_' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(name)))
+}
+
+// variantOutcome returns the outcome of the built in variants.  The
+// variant wins count for the side that just moved.
+func variantOutcome(pos *Position, method Method) Outcome {
+	switch method {
+	case ThreeChecks, KingOfTheHill, Explosion, AllPiecesCaptured:
+		if pos.Turn() == White {
+			return BlackWon
+		}
+		return WhiteWon
+	}
+	return StandardVariant{}.Outcome(pos, method)
+}
+
+// variantEnd returns the method by which a game of the built in
+// variants ended or NoMethod.  Neither side has moves once the game
+// is over.
+func (pos *Position) variantEnd() Method {
+	switch {
+	case pos.threeCheck && (pos.checks[White] >= 3 || pos.checks[Black] >= 3):
+		return ThreeChecks
+	case pos.kingOfTheHill && (pos.board.bbWhiteKing|pos.board.bbBlackKing)&bbHill != 0:
+		return KingOfTheHill
+	case pos.atomic && (pos.board.bbWhiteKing == 0) != (pos.board.bbBlackKing == 0):
+		return Explosion
+	case (pos.antichess || pos.horde) && pos.board.Occupied(pos.turn) == 0:
+		return AllPiecesCaptured
+	}
+	return NoMethod
+}
+
+// hasSufficientMaterial returns false if the game is drawn because
+// neither side can win by the rules of the position's variant.
+func (pos *Position) hasSufficientMaterial() bool {
+	b := pos.board
+	switch {
+	case pos.crazyhouse, pos.kingOfTheHill, pos.antichess, pos.horde:
+		// captured pieces come back, kings can walk to the hill and
+		// the games without checkmate are won by losing pieces
+		return true
+	case pos.atomic:
+		return b.atomicHasSufficientMaterial()
+	case pos.threeCheck:
+		return ^b.emptySqs&^(b.bbWhiteKing|b.bbBlackKing) != 0
+	}
+	return b.HasSufficientMaterial()
+}
+
+func isStandard(v Variant) bool {
+	_, ok := v.(StandardVariant)
+	return v == nil || ok
+}
+
+// Variant returns the variant whose rules the position is played by.
+func (pos *Position) Variant() Variant {
+	if pos.variant == nil {
+		return StandardVariant{}
+	}
+	return pos.variant
+}
+
+// variantRules are the rules of the built in variants that change how
+// moves are generated and made.
+type variantRules uint8
+
+const (
+	crazyhouseRules variantRules = 1 << iota
+	threeCheckRules
+	kingOfTheHillRules
+	atomicRules
+	antichessRules
+	hordeRules
+)
+
+func (StandardVariant) rules() variantRules      { return 0 }
+func (CrazyhouseVariant) rules() variantRules    { return crazyhouseRules }
+func (ThreeCheckVariant) rules() variantRules    { return threeCheckRules }
+func (KingOfTheHillVariant) rules() variantRules { return kingOfTheHillRules }
+func (AtomicVariant) rules() variantRules        { return atomicRules }
+func (AntichessVariant) rules() variantRules     { return antichessRules }
+func (HordeVariant) rules() variantRules         { return hordeRules }
+
+// setVariant sets the position's variant and switches the rules of
+// the built in variants on or off to match it.  The pockets and checks
+// are cleared unless the variant keeps them.  Standard positions keep
+// a nil variant so they don't pay for the indirection.
+func (pos *Position) setVariant(v Variant) {
+	var rules variantRules
+	if v != nil {
+		rules = v.rules()
+	}
+	if isStandard(v) {
+		v = nil
+	}
+	pos.variant = v
+	pos.crazyhouse = rules&crazyhouseRules != 0
+	pos.threeCheck = rules&threeCheckRules != 0
+	pos.kingOfTheHill = rules&kingOfTheHillRules != 0
+	pos.atomic = rules&atomicRules != 0
+	pos.antichess = rules&antichessRules != 0
+	pos.horde = rules&hordeRules != 0
+	if !pos.crazyhouse {
+		pos.pockets = [3]Pocket{}
+		pos.promoted = 0
+	}
+	if !pos.threeCheck {
+		pos.checks = [3]uint8{}
+	}
+	pos.hash = pos.zobrist()
+	pos.validMoves = nil
+}
@@ -0,0 +1,159 @@
+package chess
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// noCastleVariant is standard chess without castling.
+type noCastleVariant struct{ StandardVariant }
+
+func (noCastleVariant) String() string { return "No Castle" }
+
+func (v noCastleVariant) ValidMoves(pos *Position) []*Move {
+	moves := []*Move{}
+	for _, m := range v.StandardVariant.ValidMoves(pos) {
+		if !m.HasTag(KingSideCastle) && !m.HasTag(QueenSideCastle) {
+			moves = append(moves, m)
+		}
+	}
+	return moves
+}
+
+// firstCheckVariant is won by the first player to give check.
+type firstCheckVariant struct{ StandardVariant }
+
+func (firstCheckVariant) String() string { return "First Check" }
+
+func (v firstCheckVariant) Status(pos *Position) Method {
+	if pos.inCheck {
+		return Checkmate
+	}
+	return v.StandardVariant.Status(pos)
+}
+
+func TestWithVariant(t *testing.T) {
+	g := NewGame(WithVariant(noCastleVariant{}))
+	if g.Variant().String() != "No Castle" {
+		t.Fatalf("expected the No Castle variant but got %s", g.Variant())
+	}
+	if tp := g.GetTagPair("Variant"); tp == nil || tp.Value != "No Castle" {
+		t.Fatal("expected a Variant tag pair")
+	}
+	for _, s := range []string{"e4", "e5", "Nf3", "Nc6", "Bc4", "Bc5"} {
+		if err := g.MoveStr(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := g.MoveStr("O-O"); err == nil {
+		t.Fatal("expected O-O to be invalid without castling")
+	}
+	if g.Position().Variant().String() != "No Castle" {
+		t.Fatal("expected positions to keep the variant")
+	}
+}
+
+func TestVariantOutcome(t *testing.T) {
+	fen, err := FEN("rnbqkbnr/ppp2ppp/8/3pp3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 0 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGame(WithVariant(firstCheckVariant{}), fen)
+	if err := g.MoveStr("Bb5+"); err != nil {
+		t.Fatal(err)
+	}
+	if g.Outcome() != WhiteWon || g.Method() != Checkmate {
+		t.Fatalf("expected white to win by the first check but got %s by %s", g.Outcome(), g.Method())
+	}
+}
+
+func TestFENOptionCopiesPosition(t *testing.T) {
+	fen, err := FEN("4k3/8/8/8/8/8/4P3/4K3 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g1, g2 := NewGame(fen), NewGame(fen)
+	if g1.Position() == g2.Position() {
+		t.Fatal("expected the games not to share a position")
+	}
+	g1.Position().MakeMove(g1.ValidMoves()[0])
+	if g2.Position().String() != "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1" {
+		t.Fatalf("expected the second game not to change but got %s", g2.Position())
+	}
+}
+
+func TestPGNVariantTag(t *testing.T) {
+	tests := []struct {
+		tag      string
+		chess960 bool
+	}{
+		{"Standard", false},
+		{"From Position", false},
+		{"Chess960", true},
+		{"fischerandom", true},
+	}
+	for _, test := range tests {
+		pgn := `[Variant "` + test.tag + `"]
+
+1. e4 e5 *`
+		g := NewGame()
+		if err := g.UnmarshalText([]byte(pgn)); err != nil {
+			t.Fatal(err)
+		}
+		if !isStandard(g.Variant()) {
+			t.Fatalf("expected %s to be played by the standard rules", test.tag)
+		}
+		if g.Position().Chess960() != test.chess960 {
+			t.Fatalf("expected %s chess960 to be %v", test.tag, test.chess960)
+		}
+	}
+	g := NewGame()
+	err := g.UnmarshalText([]byte("[Variant \"Bughouse\"]\n\n1. e4 *"))
+	if err == nil || !strings.Contains(err.Error(), "unsupported variant") {
+		t.Fatalf("expected an unsupported variant error but got %v", err)
+	}
+}
+
+// explodingVariant plays by the Atomic rules under another name.
+type explodingVariant struct{ AtomicVariant }
+
+func (explodingVariant) String() string { return "Exploding" }
+
+func TestVariantRules(t *testing.T) {
+	const zhFEN = "rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR[Nn] w KQkq - 0 2"
+	var fenErr *FENError
+	if _, err := FEN(zhFEN); !errors.As(err, &fenErr) {
+		t.Fatalf("expected the Crazyhouse FEN to be an invalid standard FEN but got %v", err)
+	}
+	if _, err := VariantFEN(AtomicVariant{}, zhFEN); err == nil {
+		t.Fatal("expected the Crazyhouse FEN to be invalid for Atomic")
+	}
+
+	// the rules follow the variant, not the variant the FEN was read as
+	pos := unsafeVariantFEN(CrazyhouseVariant{}, zhFEN)
+	pos.setVariant(AtomicVariant{})
+	if pos.crazyhouse || !pos.atomic || pos.pockets[White].counts[Knight] != 0 {
+		t.Fatal("expected the Crazyhouse rules and pockets to be cleared")
+	}
+	if pos.ZobristHash() != pos.zobrist() {
+		t.Fatal("expected the key to be updated")
+	}
+
+	for _, v := range []Variant{AtomicVariant{}, explodingVariant{}} {
+		fen, err := VariantFEN(v, "rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		g := NewGame(fen)
+		if g.Variant().String() != v.String() {
+			t.Fatalf("expected the %s variant but got %s", v, g.Variant())
+		}
+		if err := g.MoveStr("exd5"); err != nil {
+			t.Fatal(err)
+		}
+		if g.Position().Board().Piece(D5) != NoPiece {
+			t.Fatalf("expected %s captures to explode", v)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package chess
+
+import (
+	"errors"
+	"fmt"
+)
+
+// A MoveNode is a move in the game's tree of variations.  The root
+// node holds the starting position and no move.  The first child of
+// a node continues its line, the others are variations replacing
+// that continuation.  The game's main line follows the first child
+// from the root.
+type MoveNode struct {
+	parent   *MoveNode
+	move     *Move
+	pos      *Position
+	comments []string
+	// preComments are the comments before the move
+	preComments []string
+	nags        []NAG
+	children    []*MoveNode
+}
+
+// Move returns the move leading to the node or nil for the root.
+func (n *MoveNode) Move() *Move {
+	return n.move
+}
+
+// Position returns the position after the move.
+func (n *MoveNode) Position() *Position {
+	return n.pos
+}
+
+// Parent returns the node before the move or nil for the root.
+func (n *MoveNode) Parent() *MoveNode {
+	return n.parent
+}
+
+// Next returns the node continuing the line or nil at its end.
+func (n *MoveNode) Next() *MoveNode {
+	if len(n.children) == 0 {
+		return nil
+	}
+	return n.children[0]
+}
+
+// Children returns the nodes following the node, the continuation
+// of its line first and then the variations.
+func (n *MoveNode) Children() []*MoveNode {
+	return append([]*MoveNode(nil), n.children...)
+}
+
+// Comments returns the comments after the move.  The root's comments
+// come before the game's first move.
+func (n *MoveNode) Comments() []string {
+	return append([]string(nil), n.comments...)
+}
+
+// SetComments replaces the comments after the move.
+func (n *MoveNode) SetComments(comments []string) {
+	n.comments = append([]string(nil), comments...)
+}
+
+// PreComments returns the comments before the move, such as a comment
+// opening a variation.
+func (n *MoveNode) PreComments() []string {
+	return append([]string(nil), n.preComments...)
+}
+
+// SetPreComments replaces the comments before the move.
+func (n *MoveNode) SetPreComments(comments []string) {
+	n.preComments = append([]string(nil), comments...)
+}
+
+// Ply returns the number of moves from the root to the node.
+func (n *MoveNode) Ply() int {
+	ply := 0
+	for ; n.parent != nil; n = n.parent {
+		ply++
+	}
+	return ply
+}
+
+// IsMainLine returns true if the node is on the main line.
+func (n *MoveNode) IsMainLine() bool {
+	for ; n.parent != nil; n = n.parent {
+		if n.parent.children[0] != n {
+			return false
+		}
+	}
+	return true
+}
+
+// Walk visits the node and the nodes below it depth first, the
+// continuation of each line before its variations.  The nodes below
+// a node are skipped if f returns false for it.
+func (n *MoveNode) Walk(f func(*MoveNode) bool) {
+	if !f(n) {
+		return
+	}
+	for _, c := range n.children {
+		c.Walk(f)
+	}
+}
+
+func (n *MoveNode) root() *MoveNode {
+	for n.parent != nil {
+		n = n.parent
+	}
+	return n
+}
+
+func (n *MoveNode) clone(parent *MoveNode) *MoveNode {
+	cp := &MoveNode{
+		parent:      parent,
+		move:        n.move,
+		pos:         n.pos,
+		comments:    n.Comments(),
+		preComments: n.PreComments(),
+		nags:        n.NAGs(),
+	}
+	for _, c := range n.children {
+		cp.children = append(cp.children, c.clone(cp))
+	}
+	return cp
+}
+
+// Root returns the root of the game's variation tree.
+func (g *Game) Root() *MoveNode {
+	return g.root
+}
+
+// Node returns the main line node after the given number of moves or
+// nil if the main line is shorter.  Node(0) returns the root.
+func (g *Game) Node(ply int) *MoveNode {
+	if ply < 0 || ply >= len(g.nodes) {
+		return nil
+	}
+	return g.nodes[ply]
+}
+
+// AddVariation plays the moves after the node and returns the node
+// of the last one.  Moves already in the tree are followed rather than
+// added twice.  A line added after the end of the main line extends
+// the main line, otherwise it becomes a variation.  An error is
+// returned if the node isn't in the game or a move is invalid.
+func (g *Game) AddVariation(n *MoveNode, moves ...*Move) (*MoveNode, error) {
+	if n == nil || n.root() != g.root {
+		return nil, errors.New("chess: node isn't in the game")
+	}
+	for _, m := range moves {
+		next, err := g.addMove(n, m)
+		if err != nil {
+			return nil, err
+		}
+		n = next
+	}
+	return n, nil
+}
+
+// AddVariationStr decodes the moves in the game's notation and calls
+// AddVariation.
+func (g *Game) AddVariationStr(n *MoveNode, moves ...string) (*MoveNode, error) {
+	if n == nil || n.root() != g.root {
+		return nil, errors.New("chess: node isn't in the game")
+	}
+	for _, s := range moves {
+		m, err := g.notation.Decode(n.pos, s)
+		if err != nil {
+			return nil, err
+		}
+		if n, err = g.addMove(n, m); err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+// PromoteVariation makes the line through the node the main line by
+// moving it and each node above it ahead of their variations.  The
+// game's position, outcome and method follow the new main line.
+func (g *Game) PromoteVariation(n *MoveNode) error {
+	if n == nil || n.root() != g.root {
+		return errors.New("chess: node isn't in the game")
+	}
+	for ; n.parent != nil; n = n.parent {
+		siblings := n.parent.children
+		for i, c := range siblings {
+			if c == n {
+				copy(siblings[1:i+1], siblings[:i])
+				siblings[0] = n
+				break
+			}
+		}
+	}
+	g.syncMainLine()
+	return nil
+}
+
+// DeleteVariation removes the node and the nodes below it.  Deleting
+// a main line node makes its first variation, if any, the main line.
+func (g *Game) DeleteVariation(n *MoveNode) error {
+	if n == nil || n.root() != g.root {
+		return errors.New("chess: node isn't in the game")
+	}
+	if n.parent == nil {
+		return errors.New("chess: can't delete the root node")
+	}
+	siblings := n.parent.children
+	for i, c := range siblings {
+		if c == n {
+			n.parent.children = append(siblings[:i:i], siblings[i+1:]...)
+			break
+		}
+	}
+	g.syncMainLine()
+	return nil
+}
+
+// addMove adds the move after the node unless it is already there.
+func (g *Game) addMove(n *MoveNode, m *Move) (*MoveNode, error) {
+	valid := n.pos.validMove(m)
+	if valid == nil {
+		return nil, fmt.Errorf("chess: invalid move %s", m)
+	}
+	for _, c := range n.children {
+		if c.move == valid {
+			return c, nil
+		}
+	}
+	child := &MoveNode{parent: n, move: valid, pos: n.pos.Update(valid)}
+	n.children = append(n.children, child)
+	if n == g.nodes[len(g.nodes)-1] {
+		g.nodes = append(g.nodes, child)
+		g.pos = child.pos
+		g.updatePosition()
+	}
+	return child, nil
+}
+
+// setRoot replaces the game's tree and follows its main line to the
+// current position.
+func (g *Game) setRoot(root *MoveNode) {
+	g.root = root
+	g.nodes = []*MoveNode{root}
+	for n := root.Next(); n != nil; n = n.Next() {
+		g.nodes = append(g.nodes, n)
+	}
+	g.pos = g.nodes[len(g.nodes)-1].pos
+}
+
+// syncMainLine updates the game after its main line changed.
+func (g *Game) syncMainLine() {
+	pos := g.pos
+	g.setRoot(g.root)
+	if g.pos != pos {
+		g.outcome = NoOutcome
+		g.method = NoMethod
+		g.updatePosition()
+	}
+}
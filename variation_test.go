@@ -0,0 +1,256 @@
+package chess
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVariationsDecode(t *testing.T) {
+	pgn, err := mustParsePGN()("fixtures/pgns/0003.pgn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	game, err := decodePGN(nil, pgn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(game.Moves()) != 5 {
+		t.Fatalf("expected 5 main line moves but got %d", len(game.Moves()))
+	}
+	n := game.Node(1)
+	children := n.Children()
+	if len(children) != 2 {
+		t.Fatalf("expected 2 replies to Nd5 but got %d", len(children))
+	}
+	variation := children[1]
+	if variation.IsMainLine() || !children[0].IsMainLine() {
+		t.Fatal("expected exd5 on the main line and hxg5 in a variation")
+	}
+	moves := []string{}
+	for v := variation; v != nil; v = v.Next() {
+		moves = append(moves, AlgebraicNotation{}.Encode(v.Parent().Position(), v.Move()))
+	}
+	if got := strings.Join(moves, " "); got != "hxg5 Nxe7+ Nxe7" {
+		t.Fatalf("expected variation hxg5 Nxe7+ Nxe7 but got %s", got)
+	}
+}
+
+func TestNestedVariations(t *testing.T) {
+	pgn := `[Event "?"]
+
+1. e4 { king pawn } (1. d4 d5 (1... Nf6 { indian } 2. c4 (2. Nf3)) 2. c4) (1. c4) 1... e5 2. Nf3 *`
+	game, err := decodePGN(nil, pgn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := game.Root()
+	if len(root.Children()) != 3 {
+		t.Fatalf("expected 3 first moves but got %d", len(root.Children()))
+	}
+	d4 := root.Children()[1]
+	nf6 := d4.Children()[1]
+	if c := nf6.Comments(); len(c) != 1 || c[0] != "indian" {
+		t.Fatalf("expected comment indian on Nf6 but got %v", c)
+	}
+	if len(nf6.Children()) != 2 || nf6.Ply() != 2 {
+		t.Fatalf("expected Nf6 at ply 2 with 2 replies")
+	}
+	game2, err := decodePGN(nil, game.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if game.String() != game2.String() {
+		t.Fatalf("expected round trip\n%s\nbut got\n%s", game.String(), game2.String())
+	}
+	if !strings.Contains(game.String(), "(1. d4 d5 (1... Nf6 {indian} 2. c4 (2. Nf3)) 2. c4) (1. c4) 1... e5") {
+		t.Fatalf("unexpected variations in %s", game.String())
+	}
+}
+
+func TestInvalidVariations(t *testing.T) {
+	for _, pgn := range []string{
+		"1. e4 (1. d4 e5 *",
+		"1. e4 e5) 2. Nf3 *",
+		"( 1. e4 ) 1. d4 *",
+		"1. e4 (1. e5) *",
+	} {
+		if _, err := decodePGN(nil, pgn); err == nil {
+			t.Fatalf("expected error decoding %s", pgn)
+		}
+	}
+}
+
+func TestAddVariation(t *testing.T) {
+	game := NewGame()
+	for _, m := range []string{"e4", "e5", "Nf3"} {
+		if err := game.MoveStr(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	n, err := game.AddVariationStr(game.Node(1), "c5", "Nf3", "d6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.Ply() != 4 || n.IsMainLine() {
+		t.Fatalf("expected variation node at ply 4")
+	}
+	if len(game.Moves()) != 3 || game.Position() != game.Node(3).Position() {
+		t.Fatal("expected the main line to be unchanged")
+	}
+	again, err := game.AddVariationStr(game.Node(1), "c5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != n.Parent().Parent() || len(game.Node(1).Children()) != 2 {
+		t.Fatal("expected an existing move to be followed")
+	}
+	if _, err := game.AddVariationStr(game.Node(1), "e4"); err == nil {
+		t.Fatal("expected error for invalid move")
+	}
+	if _, err := game.AddVariation(NewGame().Root(), game.Moves()[0]); err == nil {
+		t.Fatal("expected error for node of another game")
+	}
+	end, err := game.AddVariationStr(game.Node(3), "Nc6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !end.IsMainLine() || len(game.Moves()) != 4 || game.Position() != end.Position() {
+		t.Fatal("expected a line added at the end to extend the main line")
+	}
+}
+
+func TestPromoteAndDeleteVariation(t *testing.T) {
+	game := NewGame()
+	for _, m := range []string{"e4", "e5", "Nf3"} {
+		if err := game.MoveStr(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	n, err := game.AddVariationStr(game.Node(1), "c5", "Nf3", "d6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := game.PromoteVariation(n); err != nil {
+		t.Fatal(err)
+	}
+	if !n.IsMainLine() || len(game.Moves()) != 4 || game.Position() != n.Position() {
+		t.Fatal("expected the variation to become the main line")
+	}
+	old := game.Node(1).Children()[1]
+	if old.Ply() != 2 || len(old.Children()) != 1 {
+		t.Fatal("expected the old main line to become a variation")
+	}
+	if err := game.DeleteVariation(old); err != nil {
+		t.Fatal(err)
+	}
+	if len(game.Node(1).Children()) != 1 {
+		t.Fatal("expected the variation to be deleted")
+	}
+	if err := game.DeleteVariation(game.Node(2)); err != nil {
+		t.Fatal(err)
+	}
+	if len(game.Moves()) != 1 || game.Position() != game.Node(1).Position() {
+		t.Fatal("expected deleting a main line node to shorten the main line")
+	}
+	if err := game.DeleteVariation(game.Root()); err == nil {
+		t.Fatal("expected error deleting the root")
+	}
+}
+
+func TestPromoteVariationOutcome(t *testing.T) {
+	game := NewGame()
+	for _, m := range []string{"f3", "e5", "g4", "Nc6"} {
+		if err := game.MoveStr(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mate, err := game.AddVariationStr(game.Node(3), "Qh4#")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if game.Outcome() != NoOutcome {
+		t.Fatalf("expected no outcome but got %s", game.Outcome())
+	}
+	if err := game.PromoteVariation(mate); err != nil {
+		t.Fatal(err)
+	}
+	if game.Outcome() != BlackWon || game.Method() != Checkmate {
+		t.Fatalf("expected checkmate after promoting but got %s %s", game.Outcome(), game.Method())
+	}
+}
+
+func TestWalkVariations(t *testing.T) {
+	game, err := decodePGN(nil, "1. e4 (1. d4 d5) (1. c4) 1... e5 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	moves := []string{}
+	game.Root().Walk(func(n *MoveNode) bool {
+		if n.Move() != nil {
+			moves = append(moves, n.Move().String())
+		}
+		return n.Move() == nil || n.Move().String() != "d2d4"
+	})
+	if got := strings.Join(moves, " "); got != "e2e4 e7e5 d2d4 c2c4" {
+		t.Fatalf("unexpected walk order %s", got)
+	}
+}
+
+func TestCloneVariations(t *testing.T) {
+	game, err := decodePGN(nil, "1. e4 { c } (1. d4 d5) 1... e5 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cp := game.Clone()
+	if cp.String() != game.String() {
+		t.Fatalf("expected clone %s but got %s", game.String(), cp.String())
+	}
+	if err := cp.DeleteVariation(cp.Root().Children()[1]); err != nil {
+		t.Fatal(err)
+	}
+	cp.Node(1).SetComments(nil)
+	if len(game.Root().Children()) != 2 || len(game.Node(1).Comments()) != 1 {
+		t.Fatal("expected clone to be independent of the game")
+	}
+}
+
+func TestUndoMoveDropsMoves(t *testing.T) {
+	game := NewGame()
+	for _, m := range []string{"e4", "e5", "Nf3"} {
+		if err := game.MoveStr(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := game.UndoMoves(2); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := game.MoveStr("d5"); err != nil {
+			t.Fatal(err)
+		}
+		if err := game.UndoMove(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := game.MoveStr("c5"); err != nil {
+		t.Fatal(err)
+	}
+	if s := strings.TrimSpace(game.String()); s != "1. e4 c5 *" || len(game.Node(1).Children()) != 1 {
+		t.Fatalf("expected the undone moves to be dropped but got %s", s)
+	}
+}
+
+func TestRepeatedMoveAnnotations(t *testing.T) {
+	pgn := "1. e4 e5 {main} (1... e5 $1 {also} 2. Nf3) 2. Nf3 *"
+	game, err := decodePGN(nil, pgn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := game.Node(2)
+	if len(n.Parent().Children()) != 1 {
+		t.Fatal("expected the repeated move to be followed")
+	}
+	if c := n.Comments(); len(c) != 2 || c[0] != "main" || c[1] != "also" || len(n.NAGs()) != 1 || n.NAGs()[0] != 1 {
+		t.Fatalf("expected the annotations of both to be kept but got %v %v", c, n.NAGs())
+	}
+}
@@ -0,0 +1,121 @@
+package chess
+
+// The Zobrist keys are drawn from a fixed seed so a position's
+// key is the same across runs and can be stored.
+var (
+	zobristPieces    [13][64]uint64
+	zobristCastle    [16]uint64
+	zobristEnPassant [8]uint64
+	zobristTurn      uint64
+	// zobristPocket is keyed on the number of pieces of a type in
+	// a Crazyhouse pocket, an empty pocket hashes to zero
+	zobristPocket [3][7][maxPocketCount + 1]uint64
+	// zobristPromoted is keyed on the squares of promoted pieces in
+	// Crazyhouse which go back to the pocket as pawns
+	zobristPromoted [64]uint64
+	// zobristChecks is keyed on the checks given in Three-check
+	zobristChecks [3][4]uint64
+)
+
+func init() {
+	// splitmix64
+	seed := uint64(0x9e3779b97f4a7c15)
+	next := func() uint64 {
+		seed += 0x9e3779b97f4a7c15
+		z := seed
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		return z ^ (z >> 31)
+	}
+	for _, p := range allPieces {
+		for sq := 0; sq < numOfSquaresInBoard; sq++ {
+			zobristPieces[p][sq] = next()
+		}
+	}
+	// each right has its own key and a set of rights
+	// hashes to the xor of its members
+	var rights [4]uint64
+	for i := range rights {
+		rights[i] = next()
+	}
+	for bits := range zobristCastle {
+		for i, k := range rights {
+			if bits&(1<<i) != 0 {
+				zobristCastle[bits] ^= k
+			}
+		}
+	}
+	for i := range zobristEnPassant {
+		zobristEnPassant[i] = next()
+	}
+	zobristTurn = next()
+	for _, c := range []Color{White, Black} {
+		for pt := Queen; pt <= Pawn; pt++ {
+			for n := 1; n <= maxPocketCount; n++ {
+				zobristPocket[c][pt][n] = next()
+			}
+		}
+	}
+	for _, c := range []Color{White, Black} {
+		for n := 1; n <= 3; n++ {
+			zobristChecks[c][n] = next()
+		}
+	}
+	for sq := range zobristPromoted {
+		zobristPromoted[sq] = next()
+	}
+}
+
+// ZobristHash returns the position's 64 bit Zobrist key.  Unlike
+// Hash, the key ignores the move counters and only counts the en
+// passant square when a capture is possible, so transpositions share
+// the same key.  The key is kept up to date by Update and MakeMove.
+func (pos *Position) ZobristHash() uint64 {
+	return pos.hash
+}
+
+// zobrist computes the position's key from scratch.
+func (pos *Position) zobrist() uint64 {
+	var h uint64
+	for _, p := range allPieces {
+		for bb := pos.board.bbForPiece(p); bb != 0; bb &= bb - 1 {
+			h ^= zobristPieces[p][bb.lastSquare()]
+		}
+	}
+	h ^= zobristCastle[pos.castleRights.bits()]
+	h ^= pos.zobristEnPassant()
+	if pos.crazyhouse {
+		for _, c := range []Color{White, Black} {
+			for pt := Queen; pt <= Pawn; pt++ {
+				h ^= zobristPocket[c][pt][pos.pockets[c].counts[pt]]
+			}
+		}
+		h ^= zobristPromotedSquares(pos.promoted)
+	}
+	if pos.threeCheck {
+		h ^= zobristChecks[White][pos.checks[White]] ^ zobristChecks[Black][pos.checks[Black]]
+	}
+	if pos.turn == Black {
+		h ^= zobristTurn
+	}
+	return h
+}
+
+// zobristEnPassant returns the en passant part of the key or
+// zero if the side to move can't capture en passant.
+func (pos *Position) zobristEnPassant() uint64 {
+	if !pos.enPassantCapturable() {
+		return 0
+	}
+	return zobristEnPassant[pos.enPassantSquare.File()]
+}
+
+// zobristPromotedSquares returns the part of the key for promoted
+// pieces on the squares.
+func zobristPromotedSquares(bb Bitboard) uint64 {
+	var h uint64
+	for ; bb != 0; bb &= bb - 1 {
+		h ^= zobristPromoted[bb.lastSquare()]
+	}
+	return h
+}
@@ -0,0 +1,116 @@
+package chess
+
+import "testing"
+
+func TestZobristHashIncremental(t *testing.T) {
+	fens := []string{
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+		"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+		"rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8",
+	}
+	var walk func(pos *Position, depth int)
+	walk = func(pos *Position, depth int) {
+		if depth == 0 {
+			return
+		}
+		for _, m := range pos.ValidMoves() {
+			before := pos.hash
+			u := pos.MakeMove(m)
+			if pos.hash != pos.zobrist() {
+				t.Fatalf("after move %s to %s expected key %x but got %x", m, pos, pos.zobrist(), pos.hash)
+			}
+			walk(pos, depth-1)
+			pos.UnmakeMove(m, u)
+			if pos.hash != before {
+				t.Fatalf("after taking back move %s expected key %x but got %x", m, before, pos.hash)
+			}
+		}
+	}
+	for _, fen := range fens {
+		walk(unsafeFEN(fen), 3)
+	}
+}
+
+func TestZobristHashTransposition(t *testing.T) {
+	g1 := NewGame()
+	for _, s := range []string{"Nc3", "e5", "Nf3"} {
+		if err := g1.MoveStr(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	g2 := NewGame()
+	for _, s := range []string{"Nf3", "e5", "Nc3"} {
+		if err := g2.MoveStr(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if g1.Position().ZobristHash() != g2.Position().ZobristHash() {
+		t.Fatalf("expected keys to be equal but got %x and %x", g1.Position().ZobristHash(), g2.Position().ZobristHash())
+	}
+}
+
+func TestZobristHashEnPassant(t *testing.T) {
+	tests := []struct {
+		fen   string
+		noEP  string
+		equal bool
+	}{
+		// no black pawn can capture on e3
+		{
+			fen:   "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1",
+			noEP:  "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1",
+			equal: true,
+		},
+		// the d4 pawn can capture on e3
+		{
+			fen:   "rnbqkbnr/ppp1pppp/8/8/3pP3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1",
+			noEP:  "rnbqkbnr/ppp1pppp/8/8/3pP3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1",
+			equal: false,
+		},
+	}
+	for _, test := range tests {
+		h1 := unsafeFEN(test.fen).ZobristHash()
+		h2 := unsafeFEN(test.noEP).ZobristHash()
+		if (h1 == h2) != test.equal {
+			t.Fatalf("expected keys of %s and %s to be equal %t but got %x and %x", test.fen, test.noEP, test.equal, h1, h2)
+		}
+	}
+}
+
+func TestZobristHashUnmarshal(t *testing.T) {
+	pos := unsafeFEN("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+	b, err := pos.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cp := &Position{}
+	if err := cp.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	if cp.ZobristHash() != pos.ZobristHash() {
+		t.Fatalf("expected key %x but got %x", pos.ZobristHash(), cp.ZobristHash())
+	}
+}
+
+func TestZobristHashPromoted(t *testing.T) {
+	// the same board and pockets with the queen promoted or not
+	promoted := unsafeCrazyhouseFEN("4k3/8/8/8/8/8/3q~4/4K3[P] w - - 0 1")
+	original := unsafeCrazyhouseFEN("4k3/8/8/8/8/8/3q4/4K3[P] w - - 0 1")
+	if promoted.ZobristHash() == original.ZobristHash() {
+		t.Fatal("expected the promoted queen to change the key")
+	}
+	// a promoted queen reached by moves transposes to the FEN
+	pos := unsafeCrazyhouseFEN("4k3/8/8/8/8/8/2p5/4K3[P] b - - 0 1")
+	for _, s := range []string{"c2c1q", "e1f2", "c1d2", "f2g1"} {
+		m, err := UCINotation{}.Decode(pos, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pos.MakeMove(m)
+	}
+	expected := unsafeCrazyhouseFEN("4k3/8/8/8/8/8/3q~4/6K1[P] b - - 3 3")
+	if pos.String() != expected.String() || pos.ZobristHash() != expected.ZobristHash() {
+		t.Fatalf("expected %s with key %x but got %s with key %x", expected, expected.ZobristHash(), pos, pos.ZobristHash())
+	}
+}